@@ -0,0 +1,136 @@
+package main
+
+import (
+  "bytes"
+  "errors"
+  "flag"
+  "fmt"
+  "io"
+  "io/ioutil"
+  "os"
+  "encoding/json"
+  "github.com/nokia/danm/pkg/admit"
+  "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+var(
+  version, commitHash string
+)
+
+const (
+  usage = `Usage: danmctl <command> [options]
+
+Commands:
+  validate  validates, and mutates DANM API objects offline, exactly as the DANM webhook would do upon their creation
+  version   prints Git version information of the binary
+`
+)
+
+func main() {
+  if len(os.Args) < 2 {
+    fmt.Fprint(os.Stderr, usage)
+    os.Exit(2)
+  }
+  switch os.Args[1] {
+  case "validate":
+    os.Exit(validate(os.Args[2:]))
+  case "version":
+    fmt.Println("DANM binary was built from release: " + version)
+    fmt.Println("DANM binary was built from commit: " + commitHash)
+  default:
+    fmt.Fprint(os.Stderr, usage)
+    os.Exit(2)
+  }
+}
+
+//validate runs the webhook's validation and mutation logic on all the objects of the given files.
+//The patches the webhook would send back are printed for every admitted object.
+//Returns 1 if any of the objects would be denied
+func validate(args []string) int {
+  flags := flag.NewFlagSet("validate", flag.ExitOnError)
+  var files fileList
+  flags.Var(&files, "f", "YAML, or JSON file containing DanmNet, TenantNetwork, ClusterNetwork, or TenantConfig objects. Can be repeated, - reads from standard input")
+  flags.Parse(args)
+  if len(files) == 0 {
+    fmt.Fprintln(os.Stderr, "ERROR: at least one file must be provided with -f")
+    return 2
+  }
+  var rawObjects [][]byte
+  for _, file := range files {
+    objects, err := readObjects(file)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, "ERROR: objects cannot be read from file:" + file + ", because:" + err.Error())
+      return 2
+    }
+    rawObjects = append(rawObjects, objects...)
+  }
+  exitCode := 0
+  for _, result := range admit.DryRun(rawObjects) {
+    objectId := result.Kind + " " + result.Name
+    if result.Namespace != "" {
+      objectId = result.Kind + " " + result.Namespace + "/" + result.Name
+    }
+    if fieldError, isFieldError := result.Err.(*admit.FieldError); isFieldError {
+      fmt.Println(objectId + " denied: " + fieldError.Field + ": " + fieldError.Error())
+      exitCode = 1
+      continue
+    }
+    if result.Err != nil {
+      fmt.Println(objectId + " denied: " + result.Err.Error())
+      exitCode = 1
+      continue
+    }
+    fmt.Println(objectId + " admitted")
+    if len(result.Patches) == 0 {
+      continue
+    }
+    patch, _ := json.MarshalIndent(result.Patches, "", "  ")
+    fmt.Println(string(patch))
+  }
+  return exitCode
+}
+
+func readObjects(file string) ([][]byte, error) {
+  var content []byte
+  var err error
+  if file == "-" {
+    content, err = ioutil.ReadAll(os.Stdin)
+  } else {
+    content, err = ioutil.ReadFile(file)
+  }
+  if err != nil {
+    return nil, err
+  }
+  var rawObjects [][]byte
+  decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 4096)
+  for {
+    var rawObject json.RawMessage
+    err = decoder.Decode(&rawObject)
+    if err == io.EOF {
+      break
+    }
+    if err != nil {
+      return nil, err
+    }
+    //Empty YAML documents, e.g. after a trailing document separator, are skipped
+    if len(rawObject) == 0 || string(rawObject) == "null" {
+      continue
+    }
+    rawObjects = append(rawObjects, rawObject)
+  }
+  if len(rawObjects) == 0 {
+    return nil, errors.New("file does not contain any objects")
+  }
+  return rawObjects, nil
+}
+
+type fileList []string
+
+func (files *fileList) String() string {
+  return fmt.Sprint(*files)
+}
+
+func (files *fileList) Set(file string) error {
+  *files = append(*files, file)
+  return nil
+}
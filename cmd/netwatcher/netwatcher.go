@@ -4,8 +4,11 @@ import (
   "flag"
   "os"
   "log"
+  "time"
   "k8s.io/client-go/rest"
   "k8s.io/client-go/tools/clientcmd"
+  danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
+  "github.com/nokia/danm/pkg/danmvip"
   "github.com/nokia/danm/pkg/netcontrol"
 )
 
@@ -22,6 +25,8 @@ func getClientConfig(kubeConfig *string) (*rest.Config, error) {
 
 func main() {
   printVersion := flag.Bool("version", false, "prints Git version information of the binary to standard out")
+  reconcileInterval := flag.Duration("reconcile-interval", time.Minute, "how often the VLAN, and VxLAN host interfaces are reconciled with the networks, and their state is reported in the NodeNetworkState of the node. Zero disables the reconciliation, the state is then reported every -node-state-interval instead.")
+  nodeStateInterval := flag.Duration("node-state-interval", time.Minute, "how often the state of the host interfaces is reported in the NodeNetworkState of the node without repairing them, when -reconcile-interval is zero. The peer discovery of unicast VxLANs relies on this state. When both intervals are zero, the NodeNetworkState of the node is removed.")
   flag.Parse()
   if *printVersion {
     log.Println("DANM binary was built from release: " + version)
@@ -44,5 +49,19 @@ func main() {
     os.Exit(-1)
   }
   netWatcher.Run(&stopCh)
+  vipClient, err := danmclientset.NewForConfig(config)
+  if err != nil {
+    log.Println("ERROR: Creation of DanmVip client failed with error:" + err.Error() + " , exiting")
+    os.Exit(-1)
+  }
+  //Virtual IPs are moved between the Pods by the netwatcher of the node hosting them, DanmEps record the hostname of their node
+  danmvip.NewVipPlumber(vipClient, netWatcher.HostName).Run(stopCh)
+  if *reconcileInterval > 0 {
+    go netWatcher.RunReconciler(*reconcileInterval, &stopCh)
+  } else if *nodeStateInterval > 0 {
+    go netWatcher.RunStateReporter(*nodeStateInterval, &stopCh)
+  } else {
+    netWatcher.RemoveNodeNetworkState()
+  }
   select {}
 }
@@ -3,11 +3,16 @@ package main
 import (
   "flag"
   "log"
+  "os"
   "strconv"
+  "strings"
   "time"
   "crypto/tls"
+  "math/rand"
   "net/http"
   "github.com/nokia/danm/pkg/admit"
+  "github.com/nokia/danm/pkg/certwatcher"
+  "github.com/nokia/danm/pkg/danmvip"
 )
 
 var(
@@ -19,6 +24,13 @@ func main() {
   key := flag.String("tls-private-key-file", "", "file containing the x509 private key matching --tls-cert-bundle.")
   port := flag.Int("bind-port", 8443, "the port on which to serve. Default is 8443.")
   address := flag.String("bind-address", "", "the IP address on which to listen. Default is all interfaces.")
+  certReloadInterval := flag.Duration("tls-reload-interval", 10 * time.Second, "how often the TLS certificate and private key files are checked for changes. Changed files are reloaded without restarting the server.")
+  cniUsers := flag.String("cni-users", admit.DefaultCniUser, "comma separated list of the users DANM CNI authenticates with. Only these users are allowed to create, or change DanmEps.")
+  webhookUsers := flag.String("webhook-users", admit.DefaultWebhookUser, "comma separated list of the users DANM Webhook authenticates with. Only these users are allowed to free VNIs used by existing TenantNetworks.")
+  netwatcherUsers := flag.String("netwatcher-users", admit.DefaultNetwatcherUser, "comma separated list of the users DANM netwatcher authenticates with. Network updates only changing the IP allocations are admitted without validation when done by DANM CNI, netwatcher, or Webhook users.")
+  vniAuditInterval := flag.Duration("vni-audit-interval", 5 * time.Minute, "how often the VNI allocations of TenantConfigs are audited against the existing TenantNetworks. Zero disables the audit.")
+  vniAuditRepair := flag.Bool("vni-audit-repair", false, "repairs leaked, and unreserved VNIs found by the VNI audit instead of only reporting them. The audit only runs in the Webhook replica holding the leader Lease, so repairs never race with each other")
+  leaseNamespace := flag.String("lease-namespace", admit.DefaultLeaseNamespace, "namespace of the Lease the Webhook replicas elect the one running the background controllers with")
   printVersion := flag.Bool("version", false, "prints Git version information of the binary to standard out")
   flag.Parse()
   if *printVersion {
@@ -30,7 +42,7 @@ func main() {
     log.Println("ERROR: Configuring TLS is mandatory, --tls-cert-bundle and --tls-private-key-file cannot be empty!")
     return
   }
-  tlsConf, err := tls.LoadX509KeyPair(*cert, *key)
+  certWatcher, err := certwatcher.NewCertWatcher(*cert, *key)
   if err != nil {
     log.Println("ERROR: TLS configuration could not be initialized, because:" + err.Error())
     return
@@ -40,12 +52,43 @@ func main() {
     log.Println("ERROR: Cannot create DANM REST client, because:" + err.Error())
     return
   }
+  //Random placement of TenantNetworks draws from the global source, which is safe to use from the concurrently served requests
+  rand.Seed(time.Now().UnixNano())
+  validator.CniUsers = strings.Split(*cniUsers, ",")
+  validator.WebhookUsers = strings.Split(*webhookUsers, ",")
+  validator.NetwatcherUsers = strings.Split(*netwatcherUsers, ",")
   http.HandleFunc("/netvalidation", validator.ValidateNetwork)
   http.HandleFunc("/confvalidation", validator.ValidateTenantConfig)
   http.HandleFunc("/netdeletion", validator.DeleteNetwork)
+  http.HandleFunc("/podvalidation", validator.ValidatePod)
+  http.HandleFunc("/epvalidation", validator.ValidateDanmEp)
+  http.HandleFunc("/quotas", validator.ServeQuotaUsage)
+  http.HandleFunc("/metrics", admit.ServeMetrics)
+  http.HandleFunc("/healthz", admit.Healthz)
+  http.HandleFunc("/readyz", validator.Readyz)
+  //The Pod name is unique among the replicas
+  identity, err := os.Hostname()
+  if err != nil {
+    log.Println("ERROR: Cannot get the identity of the Webhook instance for leader election, because:" + err.Error())
+    return
+  }
+  err = validator.RunAsLeader(*leaseNamespace, identity, func(leaderStopCh <-chan struct{}) {
+    //IPs of DanmEps deleted by anyone else than DANM CNI are freed by one replica, so they are never freed twice
+    validator.RunEpCollector(leaderStopCh)
+    //Virtual IPs are reserved, and freed by one replica, so they are never allocated, or freed twice
+    danmvip.NewVipAllocator(validator.Client).Run(leaderStopCh)
+    if *vniAuditInterval > 0 {
+      validator.RunVniAudit(*vniAuditInterval, *vniAuditRepair, leaderStopCh)
+    }
+  }, make(chan struct{}))
+  if err != nil {
+    log.Println("ERROR: Cannot start leader election, because:" + err.Error())
+    return
+  }
+  go certWatcher.Watch(*certReloadInterval, make(chan struct{}))
   server := &http.Server{
     Addr:         *address + ":" + strconv.Itoa(*port),
-    TLSConfig:    &tls.Config{Certificates: []tls.Certificate{tlsConf}},
+    TLSConfig:    &tls.Config{GetCertificate: certWatcher.GetCertificate},
     ReadTimeout:  5 * time.Second,
     WriteTimeout: 5 * time.Second,
   }
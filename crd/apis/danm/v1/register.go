@@ -30,6 +30,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&DanmEp{},
 		&DanmEpList{},
+		&DanmVip{},
+		&DanmVipList{},
+		&NodeNetworkState{},
+		&NodeNetworkStateList{},
 		&DanmNet{},
 		&DanmNetList{},
 		&ClusterNetwork{},
@@ -27,6 +27,20 @@ type DanmNetOption struct {
   DevicePool string  `json:"device_pool,omitempty"`
   // the vxlan id on the host device (creation of vxlan interface)
   Vxlan  int  `json:"vxlan,omitempty"`
+  // How BUM traffic of the VxLAN is delivered to the other hosts: multicast (default), or unicast (head-end replication)
+  VxlanMode string `json:"vxlan_mode,omitempty"`
+  // Destination UDP port of the VxLAN, 4789 by default
+  VxlanPort int `json:"vxlan_port,omitempty"`
+  // TTL of the VxLAN encapsulated packets, 0 lets the kernel decide
+  VxlanTtl int `json:"vxlan_ttl,omitempty"`
+  // TOS of the VxLAN encapsulated packets, 1 inherits the TOS of the inner packet
+  VxlanTos int `json:"vxlan_tos,omitempty"`
+  // The VTEP uses the first address of the host device from this CIDR, instead of its first address
+  VxlanSourceCidr string `json:"vxlan_source_cidr,omitempty"`
+  // Whether the VxLAN learns the location of remote MAC addresses from the received packets, enabled by default
+  VxlanLearning *bool `json:"vxlan_learning,omitempty"`
+  // Multicast group of the VxLAN, derived from the VxLAN ID by default
+  VxlanGroup string `json:"vxlan_group,omitempty"`
   // The name of the interface in the container
   Prefix string  `json:"container_prefix,omitempty"`
   // IPv4 specific parameters
@@ -47,8 +61,12 @@ type DanmNetOption struct {
   Alloc6  string  `json:"alloc6,omitempty"`
   // subset of the IPv6 subnet from which IPs can be allocated
   Pool6   IpPoolV6 `json:"allocation_pool_v6,omitEmpty"`
+  // How IPv6 addresses are assigned to the interfaces: static (DANM IPAM), slaac, or dhcpv6-passthrough
+  Ipv6Mode string `json:"ipv6_mode,omitempty"`
   // Routing table number for policy routing
   RTables int `json:"rt_tables,omitempty"`
+  // Name of the VRF device the Pod interfaces are enslaved to. The VRF uses the RTables routing table
+  Vrf string `json:"vrf,omitempty"`
   // the VLAN id of the VLAN interface created on top of the host device
   Vlan  int  `json:"vlan,omitempty"`
 }
@@ -109,6 +127,85 @@ type DanmEpList struct {
   Items            []DanmEp `json:"items"`
 }
 
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type DanmVip struct {
+  meta_v1.TypeMeta   `json:",inline"`
+  meta_v1.ObjectMeta `json:"metadata"`
+  Spec               DanmVipSpec `json:"spec"`
+  Status             DanmVipStatus `json:"status,omitempty"`
+}
+
+type DanmVipSpec struct {
+  // Name of the network the virtual IP addresses are reserved from
+  NetworkName string `json:"NetworkName"`
+  // Kind of the network: DanmNet, TenantNetwork, or ClusterNetwork
+  ApiType     string `json:"apiType"`
+  // The requested virtual IPv4 address: "dynamic", or a static IP
+  Address     string `json:"Address,omitempty"`
+  // The requested virtual IPv6 address: "dynamic", or a static IP
+  AddressIPv6 string `json:"AddressIPv6,omitempty"`
+  // Name of the DanmEp which shall hold the virtual IP addresses
+  Endpoint    string `json:"Endpoint,omitempty"`
+}
+
+type DanmVipStatus struct {
+  // The reserved virtual IPv4 address
+  Address     string `json:"Address,omitempty"`
+  // The reserved virtual IPv6 address
+  AddressIPv6 string `json:"AddressIPv6,omitempty"`
+  // Name of the DanmEp currently holding the virtual IP addresses
+  Endpoint    string `json:"Endpoint,omitempty"`
+  // Host of the DanmEp currently holding the virtual IP addresses
+  Host        string `json:"Host,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type DanmVipList struct {
+  meta_v1.TypeMeta `json:",inline"`
+  meta_v1.ListMeta `json:"metadata"`
+  Items            []DanmVip `json:"items"`
+}
+
+// VERY IMPORTANT NOT TO CHANGE THIS, INCLUDING THE EMPTY LINE BETWEEN THE ANNOTATIONS!!!
+// https://github.com/kubernetes/code-generator/issues/59
+// +genclient:nonNamespaced
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +genclient
+//NodeNetworkState is maintained by the netwatcher of the node it is named after
+type NodeNetworkState struct {
+  meta_v1.TypeMeta   `json:",inline"`
+  meta_v1.ObjectMeta `json:"metadata"`
+  Networks           []NetworkProvisioningState `json:"networks,omitempty"`
+  // How often the netwatcher of the node reconciles the host interfaces
+  ReconcileInterval  meta_v1.Duration `json:"reconcileInterval,omitempty"`
+  // Last time the netwatcher of the node confirmed the provisioning states. States not confirmed for two reconcile intervals are stale
+  LastReconcileTime  meta_v1.Time `json:"lastReconcileTime,omitempty"`
+}
+
+//NetworkProvisioningState tells whether the host interfaces a network requires are provisioned on the node
+type NetworkProvisioningState struct {
+  // Kind of the network: DanmNet, TenantNetwork, or ClusterNetwork
+  ApiType            string       `json:"apiType"`
+  Namespace          string       `json:"namespace,omitempty"`
+  Name               string       `json:"name"`
+  Ready              bool         `json:"ready"`
+  Reason             string       `json:"reason,omitempty"`
+  Message            string       `json:"message,omitempty"`
+  LastTransitionTime meta_v1.Time `json:"lastTransitionTime,omitempty"`
+  // Local VTEP address of unicast VxLAN networks, used by the other nodes as head-end replication destination
+  Vtep               string       `json:"vtep,omitempty"`
+}
+
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type NodeNetworkStateList struct {
+  meta_v1.TypeMeta `json:",inline"`
+  meta_v1.ListMeta `json:"metadata"`
+  Items            []NodeNetworkState `json:"items"`
+}
+
 // VERY IMPORTANT NOT TO CHANGE THIS, INCLUDING THE EMPTY LINE BETWEEN THE ANNOTATIONS!!!
 // https://github.com/kubernetes/code-generator/issues/59
 // +genclient:nonNamespaced
@@ -120,6 +217,53 @@ type TenantConfig struct {
   meta_v1.ObjectMeta            `json:"metadata"`
   HostDevices []IfaceProfile    `json:"hostDevices,omitempty"`
   NetworkIds  map[string]string `json:"networkIds,omitempty"`
+  NamespaceSelector *meta_v1.LabelSelector `json:"namespaceSelector,omitempty"`
+  Namespaces  []string          `json:"namespaces,omitempty"`
+  Quota       *TenantQuota      `json:"quota,omitempty"`
+  PlacementPolicy string        `json:"placementPolicy,omitempty"`
+  //LastPlacement is maintained by the webhook, it is the interface profile the last TenantNetwork was attached to with round-robin placement
+  LastPlacement string          `json:"lastPlacement,omitempty"`
+  Supernets   []TenantSupernet  `json:"supernets,omitempty"`
+  //Status is maintained by the VNI audit of the webhook
+  Status      *TenantConfigStatus `json:"status,omitempty"`
+}
+
+//TenantConfigStatus is the result of the last consistency audit of the VNI allocations of a TenantConfig
+type TenantConfigStatus struct {
+  LastAuditTime meta_v1.Time         `json:"lastAuditTime,omitempty"`
+  Profiles      []IfaceProfileStatus `json:"profiles,omitempty"`
+}
+
+//IfaceProfileStatus compares the VNI allocation of an interface profile with the TenantNetworks using its VNIs
+type IfaceProfileStatus struct {
+  Name           string `json:"name"`
+  VniType        string `json:"vniType"`
+  UsedVnis       int    `json:"usedVnis"`
+  //LeakedVnis are reserved, but not used by any TenantNetwork
+  LeakedVnis     []int  `json:"leakedVnis,omitempty"`
+  //UnreservedVnis are used by TenantNetworks, but not reserved
+  UnreservedVnis []int  `json:"unreservedVnis,omitempty"`
+  //DuplicateVnis are used by more than one TenantNetwork
+  DuplicateVnis  []int  `json:"duplicateVnis,omitempty"`
+  RepairedVnis   []int  `json:"repairedVnis,omitempty"`
+}
+
+//TenantSupernet is an IPv4 range the subnets of TenantNetworks created without cidr are carved out of
+//NetworkType and Namespace restrict which TenantNetworks can use the supernet, empty means any
+type TenantSupernet struct {
+  Cidr         string `json:"cidr"`
+  PrefixLength int    `json:"prefixLength"`
+  NetworkType  string `json:"networkType,omitempty"`
+  Namespace    string `json:"namespace,omitempty"`
+  //Alloc tracks the carved subnets, it is maintained by the webhook
+  Alloc        string `json:"alloc,omitempty"`
+}
+
+//TenantQuota limits the resources every namespace served by a TenantConfig can use. Zero means unlimited
+type TenantQuota struct {
+  MaxNetworks       int `json:"maxNetworks,omitempty"`
+  MaxVnisPerProfile int `json:"maxVnisPerProfile,omitempty"`
+  MaxIps            int `json:"maxIps,omitempty"`
 }
 
 type IfaceProfile struct {
@@ -127,6 +271,8 @@ type IfaceProfile struct {
   VniType   string `json:"vniType,omitempty"`
   VniRange  string `json:"vniRange,omitempty"`
   Alloc     string  `json:"alloc,omitempty"`
+  Weight    int     `json:"weight,omitempty"`
+  Labels    map[string]string `json:"labels,omitempty"`
 }
 
 // +genclient:nonNamespaced
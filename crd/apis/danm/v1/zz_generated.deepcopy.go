@@ -21,6 +21,7 @@ limitations under the License.
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -254,6 +255,11 @@ func (in *DanmNetList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DanmNetOption) DeepCopyInto(out *DanmNetOption) {
 	*out = *in
+	if in.VxlanLearning != nil {
+		in, out := &in.VxlanLearning, &out.VxlanLearning
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Routes != nil {
 		in, out := &in.Routes, &out.Routes
 		*out = make(map[string]string, len(*in))
@@ -305,9 +311,109 @@ func (in *DanmNetSpec) DeepCopy() *DanmNetSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DanmVip) DeepCopyInto(out *DanmVip) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Status = in.Status
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DanmVip.
+func (in *DanmVip) DeepCopy() *DanmVip {
+	if in == nil {
+		return nil
+	}
+	out := new(DanmVip)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DanmVip) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DanmVipList) DeepCopyInto(out *DanmVipList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DanmVip, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DanmVipList.
+func (in *DanmVipList) DeepCopy() *DanmVipList {
+	if in == nil {
+		return nil
+	}
+	out := new(DanmVipList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DanmVipList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DanmVipSpec) DeepCopyInto(out *DanmVipSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DanmVipSpec.
+func (in *DanmVipSpec) DeepCopy() *DanmVipSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DanmVipSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DanmVipStatus) DeepCopyInto(out *DanmVipStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DanmVipStatus.
+func (in *DanmVipStatus) DeepCopy() *DanmVipStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DanmVipStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IfaceProfile) DeepCopyInto(out *IfaceProfile) {
 	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -321,6 +427,42 @@ func (in *IfaceProfile) DeepCopy() *IfaceProfile {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IfaceProfileStatus) DeepCopyInto(out *IfaceProfileStatus) {
+	*out = *in
+	if in.LeakedVnis != nil {
+		in, out := &in.LeakedVnis, &out.LeakedVnis
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.UnreservedVnis != nil {
+		in, out := &in.UnreservedVnis, &out.UnreservedVnis
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.DuplicateVnis != nil {
+		in, out := &in.DuplicateVnis, &out.DuplicateVnis
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.RepairedVnis != nil {
+		in, out := &in.RepairedVnis, &out.RepairedVnis
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IfaceProfileStatus.
+func (in *IfaceProfileStatus) DeepCopy() *IfaceProfileStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IfaceProfileStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IpPool) DeepCopyInto(out *IpPool) {
 	*out = *in
@@ -354,6 +496,91 @@ func (in *IpPoolV6) DeepCopy() *IpPoolV6 {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkProvisioningState) DeepCopyInto(out *NetworkProvisioningState) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkProvisioningState.
+func (in *NetworkProvisioningState) DeepCopy() *NetworkProvisioningState {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkProvisioningState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkState) DeepCopyInto(out *NodeNetworkState) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Networks != nil {
+		in, out := &in.Networks, &out.Networks
+		*out = make([]NetworkProvisioningState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.ReconcileInterval = in.ReconcileInterval
+	in.LastReconcileTime.DeepCopyInto(&out.LastReconcileTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkState.
+func (in *NodeNetworkState) DeepCopy() *NodeNetworkState {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeNetworkState) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeNetworkStateList) DeepCopyInto(out *NodeNetworkStateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NodeNetworkState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeNetworkStateList.
+func (in *NodeNetworkStateList) DeepCopy() *NodeNetworkStateList {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeNetworkStateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeNetworkStateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TenantConfig) DeepCopyInto(out *TenantConfig) {
 	*out = *in
@@ -362,7 +589,9 @@ func (in *TenantConfig) DeepCopyInto(out *TenantConfig) {
 	if in.HostDevices != nil {
 		in, out := &in.HostDevices, &out.HostDevices
 		*out = make([]IfaceProfile, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.NetworkIds != nil {
 		in, out := &in.NetworkIds, &out.NetworkIds
@@ -371,6 +600,31 @@ func (in *TenantConfig) DeepCopyInto(out *TenantConfig) {
 			(*out)[key] = val
 		}
 	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(TenantQuota)
+		**out = **in
+	}
+	if in.Supernets != nil {
+		in, out := &in.Supernets, &out.Supernets
+		*out = make([]TenantSupernet, len(*in))
+		copy(*out, *in)
+	}
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(TenantConfigStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -425,6 +679,30 @@ func (in *TenantConfigList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantConfigStatus) DeepCopyInto(out *TenantConfigStatus) {
+	*out = *in
+	in.LastAuditTime.DeepCopyInto(&out.LastAuditTime)
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = make([]IfaceProfileStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantConfigStatus.
+func (in *TenantConfigStatus) DeepCopy() *TenantConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TenantNetwork) DeepCopyInto(out *TenantNetwork) {
 	*out = *in
@@ -484,3 +762,35 @@ func (in *TenantNetworkList) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantQuota) DeepCopyInto(out *TenantQuota) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantQuota.
+func (in *TenantQuota) DeepCopy() *TenantQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantSupernet) DeepCopyInto(out *TenantSupernet) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantSupernet.
+func (in *TenantSupernet) DeepCopy() *TenantSupernet {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantSupernet)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -29,6 +29,8 @@ type DanmV1Interface interface {
 	ClusterNetworksGetter
 	DanmEpsGetter
 	DanmNetsGetter
+	DanmVipsGetter
+	NodeNetworkStatesGetter
 	TenantConfigsGetter
 	TenantNetworksGetter
 }
@@ -50,6 +52,14 @@ func (c *DanmV1Client) DanmNets(namespace string) DanmNetInterface {
 	return newDanmNets(c, namespace)
 }
 
+func (c *DanmV1Client) DanmVips(namespace string) DanmVipInterface {
+	return newDanmVips(c, namespace)
+}
+
+func (c *DanmV1Client) NodeNetworkStates() NodeNetworkStateInterface {
+	return newNodeNetworkStates(c)
+}
+
 func (c *DanmV1Client) TenantConfigs() TenantConfigInterface {
 	return newTenantConfigs(c)
 }
@@ -0,0 +1,178 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/nokia/danm/crd/apis/danm/v1"
+	scheme "github.com/nokia/danm/crd/client/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// DanmVipsGetter has a method to return a DanmVipInterface.
+// A group's client should implement this interface.
+type DanmVipsGetter interface {
+	DanmVips(namespace string) DanmVipInterface
+}
+
+// DanmVipInterface has methods to work with DanmVip resources.
+type DanmVipInterface interface {
+	Create(ctx context.Context, danmVip *v1.DanmVip, opts metav1.CreateOptions) (*v1.DanmVip, error)
+	Update(ctx context.Context, danmVip *v1.DanmVip, opts metav1.UpdateOptions) (*v1.DanmVip, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.DanmVip, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.DanmVipList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.DanmVip, err error)
+	DanmVipExpansion
+}
+
+// danmVips implements DanmVipInterface
+type danmVips struct {
+	client rest.Interface
+	ns     string
+}
+
+// newDanmVips returns a DanmVips
+func newDanmVips(c *DanmV1Client, namespace string) *danmVips {
+	return &danmVips{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the danmVip, and returns the corresponding danmVip object, and an error if there is any.
+func (c *danmVips) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.DanmVip, err error) {
+	result = &v1.DanmVip{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("danmvips").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of DanmVips that match those selectors.
+func (c *danmVips) List(ctx context.Context, opts metav1.ListOptions) (result *v1.DanmVipList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.DanmVipList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("danmvips").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested danmVips.
+func (c *danmVips) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("danmvips").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a danmVip and creates it.  Returns the server's representation of the danmVip, and an error, if there is any.
+func (c *danmVips) Create(ctx context.Context, danmVip *v1.DanmVip, opts metav1.CreateOptions) (result *v1.DanmVip, err error) {
+	result = &v1.DanmVip{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("danmvips").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(danmVip).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a danmVip and updates it. Returns the server's representation of the danmVip, and an error, if there is any.
+func (c *danmVips) Update(ctx context.Context, danmVip *v1.DanmVip, opts metav1.UpdateOptions) (result *v1.DanmVip, err error) {
+	result = &v1.DanmVip{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("danmvips").
+		Name(danmVip.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(danmVip).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the danmVip and deletes it. Returns an error if one occurs.
+func (c *danmVips) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("danmvips").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *danmVips) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("danmvips").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched danmVip.
+func (c *danmVips) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.DanmVip, err error) {
+	result = &v1.DanmVip{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("danmvips").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -40,6 +40,14 @@ func (c *FakeDanmV1) DanmNets(namespace string) v1.DanmNetInterface {
 	return &FakeDanmNets{c, namespace}
 }
 
+func (c *FakeDanmV1) DanmVips(namespace string) v1.DanmVipInterface {
+	return &FakeDanmVips{c, namespace}
+}
+
+func (c *FakeDanmV1) NodeNetworkStates() v1.NodeNetworkStateInterface {
+	return &FakeNodeNetworkStates{c}
+}
+
 func (c *FakeDanmV1) TenantConfigs() v1.TenantConfigInterface {
 	return &FakeTenantConfigs{c}
 }
@@ -0,0 +1,130 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	danmv1 "github.com/nokia/danm/crd/apis/danm/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeDanmVips implements DanmVipInterface
+type FakeDanmVips struct {
+	Fake *FakeDanmV1
+	ns   string
+}
+
+var danmvipsResource = schema.GroupVersionResource{Group: "danm.k8s.io", Version: "v1", Resource: "danmvips"}
+
+var danmvipsKind = schema.GroupVersionKind{Group: "danm.k8s.io", Version: "v1", Kind: "DanmVip"}
+
+// Get takes name of the danmVip, and returns the corresponding danmVip object, and an error if there is any.
+func (c *FakeDanmVips) Get(ctx context.Context, name string, options v1.GetOptions) (result *danmv1.DanmVip, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(danmvipsResource, c.ns, name), &danmv1.DanmVip{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*danmv1.DanmVip), err
+}
+
+// List takes label and field selectors, and returns the list of DanmVips that match those selectors.
+func (c *FakeDanmVips) List(ctx context.Context, opts v1.ListOptions) (result *danmv1.DanmVipList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(danmvipsResource, danmvipsKind, c.ns, opts), &danmv1.DanmVipList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &danmv1.DanmVipList{ListMeta: obj.(*danmv1.DanmVipList).ListMeta}
+	for _, item := range obj.(*danmv1.DanmVipList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested danmVips.
+func (c *FakeDanmVips) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(danmvipsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a danmVip and creates it.  Returns the server's representation of the danmVip, and an error, if there is any.
+func (c *FakeDanmVips) Create(ctx context.Context, danmVip *danmv1.DanmVip, opts v1.CreateOptions) (result *danmv1.DanmVip, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(danmvipsResource, c.ns, danmVip), &danmv1.DanmVip{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*danmv1.DanmVip), err
+}
+
+// Update takes the representation of a danmVip and updates it. Returns the server's representation of the danmVip, and an error, if there is any.
+func (c *FakeDanmVips) Update(ctx context.Context, danmVip *danmv1.DanmVip, opts v1.UpdateOptions) (result *danmv1.DanmVip, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(danmvipsResource, c.ns, danmVip), &danmv1.DanmVip{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*danmv1.DanmVip), err
+}
+
+// Delete takes name of the danmVip and deletes it. Returns an error if one occurs.
+func (c *FakeDanmVips) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(danmvipsResource, c.ns, name), &danmv1.DanmVip{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeDanmVips) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(danmvipsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &danmv1.DanmVipList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched danmVip.
+func (c *FakeDanmVips) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *danmv1.DanmVip, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(danmvipsResource, c.ns, name, pt, data, subresources...), &danmv1.DanmVip{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*danmv1.DanmVip), err
+}
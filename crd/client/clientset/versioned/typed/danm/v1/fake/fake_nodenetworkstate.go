@@ -0,0 +1,122 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	danmv1 "github.com/nokia/danm/crd/apis/danm/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeNodeNetworkStates implements NodeNetworkStateInterface
+type FakeNodeNetworkStates struct {
+	Fake *FakeDanmV1
+}
+
+var nodenetworkstatesResource = schema.GroupVersionResource{Group: "danm.k8s.io", Version: "v1", Resource: "nodenetworkstates"}
+
+var nodenetworkstatesKind = schema.GroupVersionKind{Group: "danm.k8s.io", Version: "v1", Kind: "NodeNetworkState"}
+
+// Get takes name of the nodeNetworkState, and returns the corresponding nodeNetworkState object, and an error if there is any.
+func (c *FakeNodeNetworkStates) Get(ctx context.Context, name string, options v1.GetOptions) (result *danmv1.NodeNetworkState, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(nodenetworkstatesResource, name), &danmv1.NodeNetworkState{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*danmv1.NodeNetworkState), err
+}
+
+// List takes label and field selectors, and returns the list of NodeNetworkStates that match those selectors.
+func (c *FakeNodeNetworkStates) List(ctx context.Context, opts v1.ListOptions) (result *danmv1.NodeNetworkStateList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(nodenetworkstatesResource, nodenetworkstatesKind, opts), &danmv1.NodeNetworkStateList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &danmv1.NodeNetworkStateList{ListMeta: obj.(*danmv1.NodeNetworkStateList).ListMeta}
+	for _, item := range obj.(*danmv1.NodeNetworkStateList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested nodeNetworkStates.
+func (c *FakeNodeNetworkStates) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(nodenetworkstatesResource, opts))
+}
+
+// Create takes the representation of a nodeNetworkState and creates it.  Returns the server's representation of the nodeNetworkState, and an error, if there is any.
+func (c *FakeNodeNetworkStates) Create(ctx context.Context, nodeNetworkState *danmv1.NodeNetworkState, opts v1.CreateOptions) (result *danmv1.NodeNetworkState, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(nodenetworkstatesResource, nodeNetworkState), &danmv1.NodeNetworkState{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*danmv1.NodeNetworkState), err
+}
+
+// Update takes the representation of a nodeNetworkState and updates it. Returns the server's representation of the nodeNetworkState, and an error, if there is any.
+func (c *FakeNodeNetworkStates) Update(ctx context.Context, nodeNetworkState *danmv1.NodeNetworkState, opts v1.UpdateOptions) (result *danmv1.NodeNetworkState, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(nodenetworkstatesResource, nodeNetworkState), &danmv1.NodeNetworkState{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*danmv1.NodeNetworkState), err
+}
+
+// Delete takes name of the nodeNetworkState and deletes it. Returns an error if one occurs.
+func (c *FakeNodeNetworkStates) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(nodenetworkstatesResource, name), &danmv1.NodeNetworkState{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeNodeNetworkStates) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(nodenetworkstatesResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &danmv1.NodeNetworkStateList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched nodeNetworkState.
+func (c *FakeNodeNetworkStates) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *danmv1.NodeNetworkState, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(nodenetworkstatesResource, name, pt, data, subresources...), &danmv1.NodeNetworkState{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*danmv1.NodeNetworkState), err
+}
@@ -24,6 +24,10 @@ type DanmEpExpansion interface{}
 
 type DanmNetExpansion interface{}
 
+type DanmVipExpansion interface{}
+
+type NodeNetworkStateExpansion interface{}
+
 type TenantConfigExpansion interface{}
 
 type TenantNetworkExpansion interface{}
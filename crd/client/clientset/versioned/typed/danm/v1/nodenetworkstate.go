@@ -0,0 +1,168 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/nokia/danm/crd/apis/danm/v1"
+	scheme "github.com/nokia/danm/crd/client/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// NodeNetworkStatesGetter has a method to return a NodeNetworkStateInterface.
+// A group's client should implement this interface.
+type NodeNetworkStatesGetter interface {
+	NodeNetworkStates() NodeNetworkStateInterface
+}
+
+// NodeNetworkStateInterface has methods to work with NodeNetworkState resources.
+type NodeNetworkStateInterface interface {
+	Create(ctx context.Context, nodeNetworkState *v1.NodeNetworkState, opts metav1.CreateOptions) (*v1.NodeNetworkState, error)
+	Update(ctx context.Context, nodeNetworkState *v1.NodeNetworkState, opts metav1.UpdateOptions) (*v1.NodeNetworkState, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.NodeNetworkState, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.NodeNetworkStateList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.NodeNetworkState, err error)
+	NodeNetworkStateExpansion
+}
+
+// nodeNetworkStates implements NodeNetworkStateInterface
+type nodeNetworkStates struct {
+	client rest.Interface
+}
+
+// newNodeNetworkStates returns a NodeNetworkStates
+func newNodeNetworkStates(c *DanmV1Client) *nodeNetworkStates {
+	return &nodeNetworkStates{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the nodeNetworkState, and returns the corresponding nodeNetworkState object, and an error if there is any.
+func (c *nodeNetworkStates) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.NodeNetworkState, err error) {
+	result = &v1.NodeNetworkState{}
+	err = c.client.Get().
+		Resource("nodenetworkstates").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of NodeNetworkStates that match those selectors.
+func (c *nodeNetworkStates) List(ctx context.Context, opts metav1.ListOptions) (result *v1.NodeNetworkStateList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.NodeNetworkStateList{}
+	err = c.client.Get().
+		Resource("nodenetworkstates").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested nodeNetworkStates.
+func (c *nodeNetworkStates) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("nodenetworkstates").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a nodeNetworkState and creates it.  Returns the server's representation of the nodeNetworkState, and an error, if there is any.
+func (c *nodeNetworkStates) Create(ctx context.Context, nodeNetworkState *v1.NodeNetworkState, opts metav1.CreateOptions) (result *v1.NodeNetworkState, err error) {
+	result = &v1.NodeNetworkState{}
+	err = c.client.Post().
+		Resource("nodenetworkstates").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeNetworkState).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a nodeNetworkState and updates it. Returns the server's representation of the nodeNetworkState, and an error, if there is any.
+func (c *nodeNetworkStates) Update(ctx context.Context, nodeNetworkState *v1.NodeNetworkState, opts metav1.UpdateOptions) (result *v1.NodeNetworkState, err error) {
+	result = &v1.NodeNetworkState{}
+	err = c.client.Put().
+		Resource("nodenetworkstates").
+		Name(nodeNetworkState.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeNetworkState).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the nodeNetworkState and deletes it. Returns an error if one occurs.
+func (c *nodeNetworkStates) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("nodenetworkstates").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *nodeNetworkStates) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("nodenetworkstates").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched nodeNetworkState.
+func (c *nodeNetworkStates) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.NodeNetworkState, err error) {
+	result = &v1.NodeNetworkState{}
+	err = c.client.Patch(pt).
+		Resource("nodenetworkstates").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
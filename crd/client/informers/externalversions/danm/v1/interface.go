@@ -30,6 +30,10 @@ type Interface interface {
 	DanmEps() DanmEpInformer
 	// DanmNets returns a DanmNetInformer.
 	DanmNets() DanmNetInformer
+	// DanmVips returns a DanmVipInformer.
+	DanmVips() DanmVipInformer
+	// NodeNetworkStates returns a NodeNetworkStateInformer.
+	NodeNetworkStates() NodeNetworkStateInformer
 	// TenantConfigs returns a TenantConfigInformer.
 	TenantConfigs() TenantConfigInformer
 	// TenantNetworks returns a TenantNetworkInformer.
@@ -62,6 +66,16 @@ func (v *version) DanmNets() DanmNetInformer {
 	return &danmNetInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// DanmVips returns a DanmVipInformer.
+func (v *version) DanmVips() DanmVipInformer {
+	return &danmVipInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// NodeNetworkStates returns a NodeNetworkStateInformer.
+func (v *version) NodeNetworkStates() NodeNetworkStateInformer {
+	return &nodeNetworkStateInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // TenantConfigs returns a TenantConfigInformer.
 func (v *version) TenantConfigs() TenantConfigInformer {
 	return &tenantConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
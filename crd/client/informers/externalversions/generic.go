@@ -59,6 +59,10 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Danm().V1().DanmEps().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("danmnets"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Danm().V1().DanmNets().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("danmvips"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Danm().V1().DanmVips().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("nodenetworkstates"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Danm().V1().NodeNetworkStates().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("tenantconfigs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Danm().V1().TenantConfigs().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("tenantnetworks"):
@@ -0,0 +1,94 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/nokia/danm/crd/apis/danm/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DanmVipLister helps list DanmVips.
+type DanmVipLister interface {
+	// List lists all DanmVips in the indexer.
+	List(selector labels.Selector) (ret []*v1.DanmVip, err error)
+	// DanmVips returns an object that can list and get DanmVips.
+	DanmVips(namespace string) DanmVipNamespaceLister
+	DanmVipListerExpansion
+}
+
+// danmVipLister implements the DanmVipLister interface.
+type danmVipLister struct {
+	indexer cache.Indexer
+}
+
+// NewDanmVipLister returns a new DanmVipLister.
+func NewDanmVipLister(indexer cache.Indexer) DanmVipLister {
+	return &danmVipLister{indexer: indexer}
+}
+
+// List lists all DanmVips in the indexer.
+func (s *danmVipLister) List(selector labels.Selector) (ret []*v1.DanmVip, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.DanmVip))
+	})
+	return ret, err
+}
+
+// DanmVips returns an object that can list and get DanmVips.
+func (s *danmVipLister) DanmVips(namespace string) DanmVipNamespaceLister {
+	return danmVipNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// DanmVipNamespaceLister helps list and get DanmVips.
+type DanmVipNamespaceLister interface {
+	// List lists all DanmVips in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1.DanmVip, err error)
+	// Get retrieves the DanmVip from the indexer for a given namespace and name.
+	Get(name string) (*v1.DanmVip, error)
+	DanmVipNamespaceListerExpansion
+}
+
+// danmVipNamespaceLister implements the DanmVipNamespaceLister
+// interface.
+type danmVipNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all DanmVips in the indexer for a given namespace.
+func (s danmVipNamespaceLister) List(selector labels.Selector) (ret []*v1.DanmVip, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.DanmVip))
+	})
+	return ret, err
+}
+
+// Get retrieves the DanmVip from the indexer for a given namespace and name.
+func (s danmVipNamespaceLister) Get(name string) (*v1.DanmVip, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("danmvip"), name)
+	}
+	return obj.(*v1.DanmVip), nil
+}
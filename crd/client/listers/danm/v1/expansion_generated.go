@@ -38,6 +38,18 @@ type DanmNetListerExpansion interface{}
 // DanmNetNamespaceLister.
 type DanmNetNamespaceListerExpansion interface{}
 
+// DanmVipListerExpansion allows custom methods to be added to
+// DanmVipLister.
+type DanmVipListerExpansion interface{}
+
+// DanmVipNamespaceListerExpansion allows custom methods to be added to
+// DanmVipNamespaceLister.
+type DanmVipNamespaceListerExpansion interface{}
+
+// NodeNetworkStateListerExpansion allows custom methods to be added to
+// NodeNetworkStateLister.
+type NodeNetworkStateListerExpansion interface{}
+
 // TenantConfigListerExpansion allows custom methods to be added to
 // TenantConfigLister.
 type TenantConfigListerExpansion interface{}
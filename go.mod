@@ -6,12 +6,14 @@ require (
 	github.com/apparentlymart/go-cidr v1.0.1
 	github.com/containernetworking/cni v0.7.1
 	github.com/containernetworking/plugins v0.8.5
+	github.com/evanphx/json-patch v4.2.0+incompatible
 	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b
 	github.com/intel/multus-cni v0.0.0-20200316130803-079c853eba60
 	github.com/intel/sriov-cni v2.1.0+incompatible
 	github.com/j-keck/arping v1.0.0
 	github.com/satori/go.uuid v1.2.1-0.20181028125025-b2ce2384e17b
 	github.com/vishvananda/netlink v1.1.1-0.20200221165523-c79a4b7b4066
+	golang.org/x/net v0.0.0-20200324143707-d3edc9973b7e
 	k8s.io/api v0.18.1
 	k8s.io/apimachinery v0.19.0-alpha.1.0.20200331211856-243f646b5bc8
 	k8s.io/client-go v0.0.0-20200404181738-fe32aa3b9449
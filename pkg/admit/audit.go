@@ -0,0 +1,98 @@
+package admit
+
+import (
+  "context"
+  "log"
+  "strconv"
+  "time"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/confman"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//RunVniAudit periodically audits the VNI allocations of all TenantConfigs until stopCh is closed
+func (validator *Validator) RunVniAudit(interval time.Duration, repair bool, stopCh <-chan struct{}) {
+  ticker := time.NewTicker(interval)
+  defer ticker.Stop()
+  for {
+    select {
+    case <-ticker.C:
+      err := validator.AuditTenantConfigs(repair)
+      if err != nil {
+        log.Println("ERROR: VNI audit of TenantConfigs failed, because:" + err.Error())
+      }
+    case <-stopCh:
+      return
+    }
+  }
+}
+
+//AuditTenantConfigs compares the VNI allocations of every TenantConfig with the VNIs of the TenantNetworks they serve, and records the result in their status
+//Leaked, and unreserved VNIs are repaired when repair is set
+func (validator *Validator) AuditTenantConfigs(repair bool) error {
+  tconfs, err := validator.Client.DanmV1().TenantConfigs().List(context.TODO(), meta_v1.ListOptions{})
+  if err != nil {
+    return err
+  }
+  tnets, err := validator.Client.DanmV1().TenantNetworks("").List(context.TODO(), meta_v1.ListOptions{})
+  if err != nil {
+    return err
+  }
+  namespaceLabels := make(map[string]map[string]string)
+  if validator.KubeClient != nil {
+    namespaces, err := validator.KubeClient.CoreV1().Namespaces().List(context.TODO(), meta_v1.ListOptions{})
+    if err != nil {
+      return err
+    }
+    for _, ns := range namespaces.Items {
+      namespaceLabels[ns.ObjectMeta.Name] = ns.ObjectMeta.Labels
+    }
+  }
+  servedNets := make(map[string][]danmtypes.TenantNetwork)
+  for _, tnet := range tnets.Items {
+    //TenantNetworks are attributed to the TenantConfig their VNI was reserved from, even if the labels of their namespace changed since
+    ownerName := tnet.ObjectMeta.Annotations[TenantConfigAnnotation]
+    if ownerName == "" {
+      tconf := confman.SelectTenantConfig(tconfs.Items, tnet.ObjectMeta.Namespace, namespaceLabels[tnet.ObjectMeta.Namespace])
+      if tconf == nil {
+        continue
+      }
+      ownerName = tconf.ObjectMeta.Name
+    }
+    servedNets[ownerName] = append(servedNets[ownerName], tnet)
+  }
+  for index := range tconfs.Items {
+    tconf := &tconfs.Items[index]
+    //https://github.com/kubernetes/client-go/issues/308
+    tconf.TypeMeta.Kind = confman.TenantConfigKind
+    status, err := confman.Audit(validator.Client, tconf, servedNets[tconf.ObjectMeta.Name], repair)
+    if err != nil {
+      log.Println("ERROR: VNI audit result of TenantConfig:" + tconf.ObjectMeta.Name + " cannot be persisted, because:" + err.Error())
+      continue
+    }
+    logAuditResult(tconf.ObjectMeta.Name, status)
+  }
+  return nil
+}
+
+func logAuditResult(tconfName string, status *danmtypes.TenantConfigStatus) {
+  for _, profile := range status.Profiles {
+    if len(profile.LeakedVnis) == 0 && len(profile.UnreservedVnis) == 0 && len(profile.DuplicateVnis) == 0 && len(profile.RepairedVnis) == 0 {
+      continue
+    }
+    log.Println("WARNING: VNI audit of interface profile:" + profile.Name + " (" + profile.VniType + ") in TenantConfig:" + tconfName +
+      " found leaked VNIs:" + formatVnis(profile.LeakedVnis) + ", unreserved VNIs:" + formatVnis(profile.UnreservedVnis) +
+      ", VNIs used by multiple TenantNetworks:" + formatVnis(profile.DuplicateVnis) + ", and repaired VNIs:" + formatVnis(profile.RepairedVnis))
+  }
+}
+
+func formatVnis(vnis []int) string {
+  formattedVnis := "["
+  for index, vni := range vnis {
+    if index > 0 {
+      formattedVnis += ","
+    }
+    formattedVnis += strconv.Itoa(vni)
+  }
+  return formattedVnis + "]"
+}
@@ -3,52 +3,68 @@ package admit
 import (
   "bytes"
   "errors"
-  "reflect"
   "encoding/json"
   "net/http"
-  "k8s.io/api/admission/v1beta1"
+  admissionv1 "k8s.io/api/admission/v1"
   danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
   "github.com/nokia/danm/pkg/bitarray"
+  "github.com/nokia/danm/pkg/confman"
 )
 
 const (
   //This is just a dimensioning decision to avoid reserving unnecessarily big bitarrays in TenantConfig
   MaxAllowedVni = 5000
-  HostDevicePath = "/hostDevices"
+  //DefaultWebhookUser is the user of the ServiceAccount DANM Webhook is deployed with, see integration/manifests/webhook/webhook.yaml
+  DefaultWebhookUser = "system:serviceaccount:kube-system:danm-webhook"
 )
 
 func (validator *Validator) ValidateTenantConfig(responseWriter http.ResponseWriter, request *http.Request) {
   admissionReview, err := DecodeAdmissionReview(request)
   if err != nil {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request, err)
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, err)
     return
   }
-  oldManifest, err := decodeTenantConfig(admissionReview.Request.OldObject.Raw)
+  isWebhookUser := validator.isWebhookUser(admissionReview.Request.UserInfo.Username)
+  _, patchList, err := reviewTenantConfig(validator.Client, admissionReview.Request.OldObject.Raw, admissionReview.Request.Object.Raw, admissionReview.Request.Operation, isWebhookUser)
   if err != nil {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request, err)
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, err)
     return
   }
-  newManifest, err := decodeTenantConfig(admissionReview.Request.Object.Raw)
+  SendAdmissionResponse(responseWriter, admissionReview, CreateReviewResponseFromPatches(patchList))
+}
+
+//reviewTenantConfig validates, and mutates a TenantConfig manifest, returning the mutated manifest together with the patches leading to it
+func reviewTenantConfig(client danmclientset.Interface, oldObject, newObject []byte, opType admissionv1.Operation, isWebhookUser bool) (*danmtypes.TenantConfig, []Patch, error) {
+  oldManifest, err := decodeTenantConfig(oldObject)
   if err != nil {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request, err)
-    return
+    return nil, nil, err
   }
-  origNewManifest := *newManifest
-  //Don't judge until you have tried deep copying an array (not a slice) in Golang
-  origDevices := make([]danmtypes.IfaceProfile, len(newManifest.HostDevices))
-  copy(origDevices, newManifest.HostDevices)
-  origNewManifest.HostDevices = origDevices
-  isManifestValid, err := validateConfig(oldManifest, newManifest, admissionReview.Request.Operation)
+  newManifest, err := decodeTenantConfig(newObject)
+  if err != nil {
+    return nil, nil, err
+  }
+  origNewManifest := newManifest.DeepCopy()
+  isManifestValid, err := validateConfig(client, oldManifest, newManifest, opType, isWebhookUser)
   if !isManifestValid {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request, err)
-    return
+    return nil, nil, err
   }
   mutateConfigManifest(newManifest)
-  responseAdmissionReview := v1beta1.AdmissionReview {
-    Response: CreateReviewResponseFromPatches(createPatchListFromConfigChanges(origNewManifest,newManifest)),
+  patchList, err := CreatePatchListFromObjectDiff(newObject, origNewManifest, newManifest)
+  return newManifest, patchList, err
+}
+
+func (validator *Validator) isWebhookUser(userName string) bool {
+  webhookUsers := validator.WebhookUsers
+  if len(webhookUsers) == 0 {
+    webhookUsers = []string{DefaultWebhookUser}
+  }
+  for _, webhookUser := range webhookUsers {
+    if userName == webhookUser {
+      return true
+    }
   }
-  responseAdmissionReview.Response.UID = admissionReview.Request.UID
-  SendAdmissionResponse(responseWriter, responseAdmissionReview)
+  return false
 }
 
 //TODO: can the return type be interface{}, and somehow encoding be input based?
@@ -70,35 +86,23 @@ func decodeTenantConfig(objectToReview []byte) (*danmtypes.TenantConfig,error) {
 
 //TODO: as above. Until reflection is figured out, this is somewhat of a duplication
 //Maybe a struct wrapping the exact object type could also work (that would push reflection responsibility on the validators though)
-func validateConfig(oldManifest, newManifest *danmtypes.TenantConfig, opType v1beta1.Operation) (bool,error) {
+func validateConfig(client danmclientset.Interface, oldManifest, newManifest *danmtypes.TenantConfig, opType admissionv1.Operation, isWebhookUser bool) (bool,error) {
   if newManifest.TypeMeta.Kind != "TenantConfig" {
     return false, errors.New("K8s API type:" + newManifest.TypeMeta.Kind + " is not handled by DANM webhook")
   }
-  err := validateTenantconfig(oldManifest,newManifest,opType)
+  err := recordValidation(validateTenantconfig, validateTenantconfig(oldManifest,newManifest,opType))
   if err != nil {
       return false, err
   }
-  return true, nil
-}
-
-func createPatchListFromConfigChanges(origConfig danmtypes.TenantConfig, changedConfig *danmtypes.TenantConfig) []Patch {
-  patchList := make([]Patch, 0)
-  var hostDevicesPatch string
-  if !reflect.DeepEqual(origConfig.HostDevices, changedConfig.HostDevices) && len(origConfig.HostDevices) != 0 && len(changedConfig.HostDevices) != 0 {
-    hostDevicesPatch = `[`
-    for ifaceIndex, ifaceConf := range changedConfig.HostDevices {
-      if ifaceIndex != 0 {
-        hostDevicesPatch += `,`
-      }
-      hostDevicesPatch += `{"name":"` + ifaceConf.Name +
-                          `","vniType":"` + ifaceConf.VniType +
-                          `","vniRange":"` + ifaceConf.VniRange +
-                          `","alloc":"` + ifaceConf.Alloc + `"}`
-    }
-    hostDevicesPatch += `]`
-    patchList = append(patchList, CreateGenericPatchFromChange(HostDevicePath, json.RawMessage(hostDevicesPatch)))
+  err = recordValidation(validateHostDeviceChange, validateHostDeviceChange(oldManifest, newManifest, opType, client, isWebhookUser))
+  if err != nil {
+      return false, err
   }
-  return patchList
+  err = recordValidation(validateNamespaceSelection, validateNamespaceSelection(newManifest, client))
+  if err != nil {
+      return false, err
+  }
+  return true, nil
 }
 
 func mutateConfigManifest(tconf *danmtypes.TenantConfig) {
@@ -110,5 +114,15 @@ func mutateConfigManifest(tconf *danmtypes.TenantConfig) {
     bitArray, _ := bitarray.NewBitArray(MaxAllowedVni+1)
     tconf.HostDevices[ifaceIndex].Alloc = bitArray.Encode()
   }
+  for supernetIndex, supernet := range tconf.Supernets {
+    if supernet.Alloc != "" {
+      continue
+    }
+    numberOfSubnets, _ := confman.GetNumberOfSubnets(supernet)
+    bitArray, _ := bitarray.NewBitArray(uint32(numberOfSubnets))
+    //Unlike VNI 0, the first subnet of a supernet is perfectly usable
+    bitArray.Reset(0)
+    tconf.Supernets[supernetIndex].Alloc = bitArray.Encode()
+  }
   return
 }
\ No newline at end of file
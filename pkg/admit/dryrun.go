@@ -0,0 +1,77 @@
+package admit
+
+import (
+  "context"
+  "errors"
+  "encoding/json"
+  admissionv1 "k8s.io/api/admission/v1"
+  danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
+  "github.com/nokia/danm/crd/client/clientset/versioned/fake"
+  "github.com/nokia/danm/pkg/netcontrol"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//DryRunResult is the verdict of the webhook about one object of a dry-run
+type DryRunResult struct {
+  Kind string
+  Name string
+  Namespace string
+  Patches []Patch
+  Err error
+}
+
+//DryRun validates, and mutates DANM API objects without a cluster, the same way the webhook would do upon their creation
+//Cluster-dependent rules are evaluated against a fake cluster only containing the admitted objects of the same dry-run.
+//TenantConfigs are admitted first so TenantNetworks can be mutated based on them, then networks follow in their original order.
+//Results are returned in the order of the input objects
+func DryRun(rawObjects [][]byte) []DryRunResult {
+  client := fake.NewSimpleClientset()
+  results := make([]DryRunResult, len(rawObjects))
+  objectMetas := make([]meta_v1.PartialObjectMetadata, len(rawObjects))
+  for index, rawObject := range rawObjects {
+    err := json.Unmarshal(rawObject, &objectMetas[index])
+    results[index] = DryRunResult{Kind: objectMetas[index].Kind, Name: objectMetas[index].Name, Namespace: objectMetas[index].Namespace, Err: err}
+  }
+  for index, rawObject := range rawObjects {
+    if results[index].Err == nil && results[index].Kind == "TenantConfig" {
+      results[index].Patches, results[index].Err = dryRunTenantConfig(client, rawObject)
+    }
+  }
+  for index, rawObject := range rawObjects {
+    if results[index].Err != nil || results[index].Kind == "TenantConfig" {
+      continue
+    }
+    if _, isTypeHandled := danmValidationConfig[results[index].Kind]; !isTypeHandled {
+      results[index].Err = errors.New("K8s API type:" + results[index].Kind + " is not handled by DANM webhook")
+      continue
+    }
+    results[index].Patches, results[index].Err = dryRunNetwork(client, rawObject)
+  }
+  return results
+}
+
+func dryRunTenantConfig(client danmclientset.Interface, rawObject []byte) ([]Patch, error) {
+  tconf, patchList, err := reviewTenantConfig(client, nil, rawObject, admissionv1.Create, false)
+  if err != nil {
+    return nil, err
+  }
+  _, err = client.DanmV1().TenantConfigs().Create(context.TODO(), tconf, meta_v1.CreateOptions{})
+  return patchList, err
+}
+
+func dryRunNetwork(client danmclientset.Interface, rawObject []byte) ([]Patch, error) {
+  dnet, patchList, err := reviewNetwork(client, nil, nil, rawObject, admissionv1.Create, false)
+  if err != nil {
+    return nil, err
+  }
+  //Admitted networks are stored, so the networks coming after them are validated against them
+  switch dnet.TypeMeta.Kind {
+  case netcontrol.TenantNetworkKind:
+    _, err = client.DanmV1().TenantNetworks(dnet.ObjectMeta.Namespace).Create(context.TODO(), netcontrol.ConvertDnetToTnet(dnet), meta_v1.CreateOptions{})
+  case netcontrol.ClusterNetworkKind:
+    _, err = client.DanmV1().ClusterNetworks().Create(context.TODO(), netcontrol.ConvertDnetToCnet(dnet), meta_v1.CreateOptions{})
+  default:
+    _, err = client.DanmV1().DanmNets(dnet.ObjectMeta.Namespace).Create(context.TODO(), dnet, meta_v1.CreateOptions{})
+  }
+  return patchList, err
+}
@@ -0,0 +1,225 @@
+package admit
+
+import (
+  "bytes"
+  "errors"
+  "log"
+  "net"
+  "reflect"
+  "encoding/json"
+  "net/http"
+  "time"
+  admissionv1 "k8s.io/api/admission/v1"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
+  danminformers "github.com/nokia/danm/crd/client/informers/externalversions"
+  "github.com/nokia/danm/pkg/bitarray"
+  "github.com/nokia/danm/pkg/danmep"
+  "github.com/nokia/danm/pkg/ipam"
+  "github.com/nokia/danm/pkg/netcontrol"
+  "k8s.io/client-go/tools/cache"
+)
+
+const (
+  //DefaultCniUser is the user of the ServiceAccount DANM CNI is deployed with, see integration/cni_config/danm_rbac.yaml
+  DefaultCniUser = "system:serviceaccount:kube-system:danm"
+  //DefaultNetwatcherUser is the user of the ServiceAccount DANM netwatcher is deployed with, see integration/manifests/netwatcher/0netwatcher_rbac.yaml
+  DefaultNetwatcherUser = "system:serviceaccount:kube-system:netwatcher"
+  //EpCollectorResync is how often the IPs of deleted DanmEps are retried to be freed, if it failed before
+  EpCollectorResync = time.Minute
+  epNetworkNameField = "spec.NetworkName"
+  epNetworkTypeField = "spec.NetworkType"
+  epPodField = "spec.Pod"
+  epIfaceNameField = "spec.Interface.Name"
+  epAddressField = "spec.Interface.Address"
+  epAddressIpv6Field = "spec.Interface.AddressIPv6"
+)
+
+// ValidateDanmEp protects DanmEps from being managed by anyone else than DANM CNI
+// Only the CNI is allowed to create DanmEps, or to change their spec, and the spec must be consistent with the referenced network
+// DANM IPAM allocated IPs of DanmEps deleted by anyone else than the CNI are freed by FreeDeletedDanmEp, as the CNI would do in danmep.DeleteDanmEp
+func (validator *Validator) ValidateDanmEp(responseWriter http.ResponseWriter, request *http.Request) {
+  admissionReview, err := DecodeAdmissionReview(request)
+  if err != nil {
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, err)
+    return
+  }
+  oldEp, err := getEpManifest(admissionReview.Request.OldObject.Raw)
+  if err != nil {
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, err)
+    return
+  }
+  newEp, err := getEpManifest(admissionReview.Request.Object.Raw)
+  if err != nil {
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, err)
+    return
+  }
+  isCniUser := validator.isCniUser(admissionReview.Request.UserInfo.Username)
+  switch admissionReview.Request.Operation {
+  case admissionv1.Create:
+    if !isCniUser {
+      err = forbiddenField("spec", "DanmEps can only be created by DANM CNI, user:" + admissionReview.Request.UserInfo.Username + " is not allowed to")
+      break
+    }
+    err = recordValidation(validateEp, validateEp(validator.Client, newEp))
+  case admissionv1.Update:
+    //Other DANM components, e.g. svcwatcher are allowed to maintain the metadata of DanmEps, but not their spec
+    if !isCniUser && !reflect.DeepEqual(oldEp.Spec, newEp.Spec) {
+      err = forbiddenField("spec", "the spec of DanmEps can only be changed by DANM CNI, user:" + admissionReview.Request.UserInfo.Username + " is not allowed to")
+      break
+    }
+    err = recordValidation(validateEp, validateEp(validator.Client, newEp))
+  }
+  if err != nil {
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, err)
+    return
+  }
+  SendAdmissionResponse(responseWriter, admissionReview, CreateReviewResponseFromPatches(nil))
+}
+
+func (validator *Validator) isCniUser(userName string) bool {
+  cniUsers := validator.CniUsers
+  if len(cniUsers) == 0 {
+    cniUsers = []string{DefaultCniUser}
+  }
+  for _, cniUser := range cniUsers {
+    if userName == cniUser {
+      return true
+    }
+  }
+  return false
+}
+
+func getEpManifest(objectToReview []byte) (*danmtypes.DanmEp,error) {
+  ep := danmtypes.DanmEp{}
+  if objectToReview == nil {
+    return &ep, nil
+  }
+  decoder := json.NewDecoder(bytes.NewReader(objectToReview))
+  decoder.DisallowUnknownFields()
+  err := decoder.Decode(&ep)
+  if err != nil {
+    return nil, errors.New("ERROR: unknown fields are not allowed:" + err.Error())
+  }
+  return &ep, nil
+}
+
+func validateEp(client danmclientset.Interface, ep *danmtypes.DanmEp) error {
+  if ep.Spec.NetworkName == "" {
+    return requiredField(epNetworkNameField, "DanmEp must reference a network")
+  }
+  if ep.Spec.Pod == "" {
+    return requiredField(epPodField, "DanmEp must belong to a Pod")
+  }
+  if ep.Spec.Iface.Name == "" {
+    return requiredField(epIfaceNameField, "DanmEp must define the name of its interface")
+  }
+  dnet, err := netcontrol.GetNetworkFromEp(client, ep)
+  if err != nil {
+    return invalidField(epNetworkNameField, "referenced network is invalid, because:" + err.Error())
+  }
+  if getNetworkTypeOrDefault(ep.Spec.NetworkType) != getNetworkTypeOrDefault(dnet.Spec.NetworkType) {
+    return invalidField(epNetworkTypeField, "NetworkType:" + ep.Spec.NetworkType + " does not match the type of network:" + dnet.ObjectMeta.Name + ", which is:" + dnet.Spec.NetworkType)
+  }
+  if !isAddressReserved(ep.Spec.Iface.Address, dnet.Spec.Options.Cidr, dnet.Spec.Options.Alloc) {
+    return invalidField(epAddressField, "IP:" + ep.Spec.Iface.Address + " is not reserved in network:" + dnet.ObjectMeta.Name)
+  }
+  if !ipam.IsIpv6Autoconfigured(dnet) && !isAddressReserved(ep.Spec.Iface.AddressIPv6, dnet.Spec.Options.Pool6.Cidr, dnet.Spec.Options.Alloc6) {
+    return invalidField(epAddressIpv6Field, "IP:" + ep.Spec.Iface.AddressIPv6 + " is not reserved in network:" + dnet.ObjectMeta.Name)
+  }
+  return nil
+}
+
+func getNetworkTypeOrDefault(neType string) string {
+  if neType == "" {
+    return "ipvlan"
+  }
+  return neType
+}
+
+//Addresses outside the CIDR of the network were not allocated by DANM IPAM, so they cannot be verified
+func isAddressReserved(address, allocCidr, alloc string) bool {
+  if !ipam.WasIpAllocatedByDanm(address, allocCidr) || alloc == "" {
+    return true
+  }
+  ip := net.ParseIP(address)
+  if ip == nil {
+    ip,_,_ = net.ParseCIDR(address)
+  }
+  _, subnet, _ := net.ParseCIDR(allocCidr)
+  return bitarray.NewBitArrayFromBase64(alloc).Get(ipam.GetIndexOfIp(ip, subnet))
+}
+
+// RunEpCollector frees the IPs of the DanmEps deleted by anyone else than DANM CNI, until stopCh is closed
+// Such DanmEps are kept by their finalizer until then, so their IPs are freed even if the deletion happened while the Webhook was not running
+func (validator *Validator) RunEpCollector(stopCh <-chan struct{}) {
+  epInformerFactory := danminformers.NewSharedInformerFactory(validator.Client, EpCollectorResync)
+  epController := epInformerFactory.Danm().V1().DanmEps().Informer()
+  epController.AddEventHandler(cache.ResourceEventHandlerFuncs{
+    AddFunc: func(obj interface{}) {
+      validator.collectDanmEp(obj)
+    },
+    UpdateFunc: func(oldObj, newObj interface{}) {
+      validator.collectDanmEp(newObj)
+    },
+  })
+  go epController.Run(stopCh)
+}
+
+func (validator *Validator) collectDanmEp(obj interface{}) {
+  ep, isEp := obj.(*danmtypes.DanmEp)
+  if !isEp {
+    return
+  }
+  err := validator.FreeDeletedDanmEp(ep)
+  if err != nil {
+    log.Println("ERROR: IPs of deleted DanmEp:" + ep.ObjectMeta.Namespace + "/" + ep.ObjectMeta.Name + " could not be freed, it is retried later. Reason:" + err.Error())
+  }
+}
+
+// FreeDeletedDanmEp frees the DANM IPAM allocated IPs of a DanmEp being deleted, then removes its finalizer
+// DANM CNI removes the finalizer itself after freeing the IPs, so only DanmEps deleted by anyone else are freed here
+// Freed IPs are recorded on the DanmEp first, so they are not freed again if removing the finalizer fails
+func (validator *Validator) FreeDeletedDanmEp(ep *danmtypes.DanmEp) error {
+  if ep.ObjectMeta.DeletionTimestamp == nil || !danmep.HasEpFinalizer(ep) {
+    return nil
+  }
+  if ep.ObjectMeta.Annotations[danmep.IpsFreedAnnotation] != "true" {
+    err := freeEpAddresses(validator.Client, ep)
+    if err != nil {
+      return err
+    }
+    ep, err = danmep.MarkEpIpsFreed(validator.Client, ep)
+    if err != nil {
+      return errors.New("freed IPs could not be recorded, because:" + err.Error())
+    }
+    if ep == nil {
+      return nil
+    }
+  }
+  err := danmep.RemoveEpFinalizer(validator.Client, ep)
+  if err != nil {
+    return errors.New("finalizer could not be removed, because:" + err.Error())
+  }
+  return nil
+}
+
+func freeEpAddresses(client danmclientset.Interface, ep *danmtypes.DanmEp) error {
+  if ep.Spec.Iface.Address == "" && ep.Spec.Iface.AddressIPv6 == "" {
+    return nil
+  }
+  dnet, err := netcontrol.GetNetworkFromEp(client, ep)
+  if err != nil {
+    //Networks cannot be deleted while DanmEps are connected to them, so there is nothing left to free
+    log.Println("WARNING: IPs of DanmEp:" + ep.ObjectMeta.Name + " in namespace:" + ep.ObjectMeta.Namespace + " are not freed, because its network cannot be read:" + err.Error())
+    return nil
+  }
+  if !ipam.WasIpAllocatedByDanm(ep.Spec.Iface.Address, dnet.Spec.Options.Cidr) && !ipam.WasIpAllocatedByDanm(ep.Spec.Iface.AddressIPv6, dnet.Spec.Options.Pool6.Cidr) {
+    return nil
+  }
+  err = ipam.GarbageCollectIps(client, dnet, ep.Spec.Iface.Address, ep.Spec.Iface.AddressIPv6)
+  if err != nil {
+    return errors.New("freeing the reserved IP addresses of DanmEp:" + ep.ObjectMeta.Name + " failed with error:" + err.Error())
+  }
+  return nil
+}
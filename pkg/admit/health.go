@@ -0,0 +1,38 @@
+package admit
+
+import (
+  "context"
+  "errors"
+  "log"
+  "net/http"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//Healthz reports the webhook alive as long as its HTTPS server answers
+func Healthz(responseWriter http.ResponseWriter, request *http.Request) {
+  responseWriter.WriteHeader(http.StatusOK)
+  responseWriter.Write([]byte("ok"))
+}
+
+//Readyz reports the webhook ready only when the DANM API can be reached with its client, as most validation rules depend on the existing API objects
+func (validator *Validator) Readyz(responseWriter http.ResponseWriter, request *http.Request) {
+  err := validator.checkApiConnectivity()
+  if err != nil {
+    log.Println("WARNING: DANM webhook is not ready, because:" + err.Error())
+    http.Error(responseWriter, err.Error(), http.StatusServiceUnavailable)
+    return
+  }
+  responseWriter.WriteHeader(http.StatusOK)
+  responseWriter.Write([]byte("ok"))
+}
+
+func (validator *Validator) checkApiConnectivity() error {
+  if validator.Client == nil {
+    return errors.New("DANM API client is not initialized")
+  }
+  _, err := validator.Client.DanmV1().TenantConfigs().List(context.TODO(), meta_v1.ListOptions{Limit: 1})
+  if err != nil {
+    return errors.New("DANM API cannot be reached, because:" + err.Error())
+  }
+  return nil
+}
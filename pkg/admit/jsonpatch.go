@@ -0,0 +1,111 @@
+package admit
+
+import (
+  "errors"
+  "reflect"
+  "sort"
+  "strconv"
+  "strings"
+  "encoding/json"
+)
+
+var (
+  jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+)
+
+//CreatePatchListFromObjectDiff returns the RFC 6902 operations transforming origObject into changedObject
+//The objects are compared in their JSON form, so patch paths always follow the JSON names of the API fields
+//rawObject is the object exactly as it was received in the review request. It decides where a change can be applied:
+//members missing from it are added together with their whole changed subtree, instead of patching their children
+func CreatePatchListFromObjectDiff(rawObject []byte, origObject, changedObject interface{}) ([]Patch, error) {
+  orig, err := toJsonValue(origObject)
+  if err != nil {
+    return nil, errors.New("original object could not be encoded, because:" + err.Error())
+  }
+  changed, err := toJsonValue(changedObject)
+  if err != nil {
+    return nil, errors.New("changed object could not be encoded, because:" + err.Error())
+  }
+  base := orig
+  if rawObject != nil {
+    err = json.Unmarshal(rawObject, &base)
+    if err != nil {
+      return nil, errors.New("reviewed object could not be decoded, because:" + err.Error())
+    }
+  }
+  return diffJsonValues("", base, orig, changed), nil
+}
+
+func toJsonValue(object interface{}) (interface{}, error) {
+  var value interface{}
+  rawObject, err := json.Marshal(object)
+  if err != nil {
+    return nil, err
+  }
+  err = json.Unmarshal(rawObject, &value)
+  return value, err
+}
+
+func diffJsonValues(path string, base, orig, changed interface{}) []Patch {
+  if reflect.DeepEqual(orig, changed) {
+    return nil
+  }
+  baseMap, isBaseMap := base.(map[string]interface{})
+  origMap, isOrigMap := orig.(map[string]interface{})
+  changedMap, isChangedMap := changed.(map[string]interface{})
+  if isBaseMap && isOrigMap && isChangedMap {
+    return diffJsonObjects(path, baseMap, origMap, changedMap)
+  }
+  baseList, isBaseList := base.([]interface{})
+  origList, isOrigList := orig.([]interface{})
+  changedList, isChangedList := changed.([]interface{})
+  //Elements are only patched one-by-one when no element was inserted or removed, otherwise the whole array is replaced
+  if isBaseList && isOrigList && isChangedList && len(baseList) == len(changedList) && len(origList) == len(changedList) {
+    patchList := make([]Patch, 0)
+    for index := range changedList {
+      patchList = append(patchList, diffJsonValues(path + "/" + strconv.Itoa(index), baseList[index], origList[index], changedList[index])...)
+    }
+    return patchList
+  }
+  return []Patch{Patch{Op: "replace", Path: path, Value: changed}}
+}
+
+func diffJsonObjects(path string, base, orig, changed map[string]interface{}) []Patch {
+  patchList := make([]Patch, 0)
+  for _, key := range getSortedKeys(orig, changed) {
+    memberPath := path + "/" + jsonPointerEscaper.Replace(key)
+    baseValue, isInBase := base[key]
+    origValue, isInOrig := orig[key]
+    changedValue, isInChanged := changed[key]
+    if !isInChanged {
+      if isInBase {
+        patchList = append(patchList, Patch{Op: "remove", Path: memberPath})
+      }
+      continue
+    }
+    if isInOrig && reflect.DeepEqual(origValue, changedValue) {
+      continue
+    }
+    if !isInOrig || !isInBase {
+      patchList = append(patchList, Patch{Op: "add", Path: memberPath, Value: changedValue})
+      continue
+    }
+    patchList = append(patchList, diffJsonValues(memberPath, baseValue, origValue, changedValue)...)
+  }
+  return patchList
+}
+
+func getSortedKeys(objects ...map[string]interface{}) []string {
+  keySet := make(map[string]bool)
+  for _, object := range objects {
+    for key := range object {
+      keySet[key] = true
+    }
+  }
+  keys := make([]string, 0, len(keySet))
+  for key := range keySet {
+    keys = append(keys, key)
+  }
+  sort.Strings(keys)
+  return keys
+}
@@ -0,0 +1,60 @@
+package admit
+
+import (
+  "context"
+  "errors"
+  "log"
+  "time"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+  "k8s.io/client-go/tools/leaderelection"
+  "k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+  //LeaseName is the name of the Lease the Webhook instances elect the one running the background controllers with, e.g. the VNI audit
+  LeaseName = "danm-webhook"
+  DefaultLeaseNamespace = "kube-system"
+  LeaseDuration = 15 * time.Second
+  LeaseRenewDeadline = 10 * time.Second
+  LeaseRetryPeriod = 2 * time.Second
+)
+
+// RunAsLeader runs the background controllers only while this Webhook instance holds the Lease, so they are never run by two replicas at the same time
+// The controllers shall return when their stop channel is closed, which happens when the Lease is lost. The election is then restarted until stopCh is closed
+func (validator *Validator) RunAsLeader(namespace, identity string, controllers func(leaderStopCh <-chan struct{}), stopCh <-chan struct{}) error {
+  if validator.KubeClient == nil {
+    return errors.New("leader election needs a K8s client")
+  }
+  lock := &resourcelock.LeaseLock{
+    LeaseMeta: meta_v1.ObjectMeta{Name: LeaseName, Namespace: namespace},
+    Client: validator.KubeClient.CoordinationV1(),
+    LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+  }
+  ctx, cancel := context.WithCancel(context.Background())
+  go func() {
+    <-stopCh
+    cancel()
+  }()
+  go func() {
+    for ctx.Err() == nil {
+      leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+        Lock: lock,
+        LeaseDuration: LeaseDuration,
+        RenewDeadline: LeaseRenewDeadline,
+        RetryPeriod: LeaseRetryPeriod,
+        ReleaseOnCancel: true,
+        Name: LeaseName,
+        Callbacks: leaderelection.LeaderCallbacks{
+          OnStartedLeading: func(leaderCtx context.Context) {
+            log.Println("INFO: Webhook instance:" + identity + " became the leader, starting background controllers")
+            controllers(leaderCtx.Done())
+          },
+          OnStoppedLeading: func() {
+            log.Println("INFO: Webhook instance:" + identity + " is not the leader anymore, background controllers are stopped")
+          },
+        },
+      })
+    }
+  }()
+  return nil
+}
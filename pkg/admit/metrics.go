@@ -0,0 +1,76 @@
+package admit
+
+import (
+  "fmt"
+  "net/http"
+  "reflect"
+  "runtime"
+  "sort"
+  "strings"
+  "sync"
+)
+
+var (
+  reviewCounter = newCounterVec("danm_webhook_admission_reviews_total", "Number of admission reviews answered by the DANM webhook.", "kind", "operation", "outcome")
+  validationCounter = newCounterVec("danm_webhook_validations_total", "Number of validation rules evaluated by the DANM webhook.", "validator", "outcome")
+  metricsEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+//counterVec is a set of monotonic counters with the same name, distinguished by their label values
+type counterVec struct {
+  name string
+  help string
+  labelNames []string
+  lock sync.Mutex
+  values map[string]uint64
+}
+
+func newCounterVec(name, help string, labelNames ...string) *counterVec {
+  return &counterVec{name: name, help: help, labelNames: labelNames, values: make(map[string]uint64)}
+}
+
+func (counter *counterVec) inc(labelValues ...string) {
+  labels := make([]string, len(counter.labelNames))
+  for index, labelName := range counter.labelNames {
+    labels[index] = labelName + "=\"" + metricsEscaper.Replace(labelValues[index]) + "\""
+  }
+  counter.lock.Lock()
+  defer counter.lock.Unlock()
+  counter.values["{" + strings.Join(labels, ",") + "}"]++
+}
+
+func (counter *counterVec) write(responseWriter http.ResponseWriter) {
+  counter.lock.Lock()
+  defer counter.lock.Unlock()
+  fmt.Fprintf(responseWriter, "# HELP %s %s\n# TYPE %s counter\n", counter.name, counter.help, counter.name)
+  labelSets := make([]string, 0, len(counter.values))
+  for labelSet := range counter.values {
+    labelSets = append(labelSets, labelSet)
+  }
+  sort.Strings(labelSets)
+  for _, labelSet := range labelSets {
+    fmt.Fprintf(responseWriter, "%s%s %d\n", counter.name, labelSet, counter.values[labelSet])
+  }
+}
+
+//ServeMetrics exposes the counters of the webhook in the Prometheus text format
+func ServeMetrics(responseWriter http.ResponseWriter, request *http.Request) {
+  responseWriter.Header().Set("Content-Type", "text/plain; version=0.0.4")
+  reviewCounter.write(responseWriter)
+  validationCounter.write(responseWriter)
+}
+
+//recordValidation counts the outcome of a validation rule, and returns its result unchanged
+func recordValidation(validator interface{}, err error) error {
+  outcome := "passed"
+  if err != nil {
+    outcome = "failed"
+  }
+  validationCounter.inc(getValidatorName(validator), outcome)
+  return err
+}
+
+func getValidatorName(validator interface{}) string {
+  fullName := runtime.FuncForPC(reflect.ValueOf(validator).Pointer()).Name()
+  return fullName[strings.LastIndex(fullName, ".")+1:]
+}
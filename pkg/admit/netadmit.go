@@ -2,85 +2,190 @@ package admit
 
 import (
   "bytes"
+  "context"
   "errors"
+  "log"
+  "net"
   "reflect"
   "strings"
-  "time"
   "encoding/json"
-  "math/rand"
   "net/http"
-  "k8s.io/api/admission/v1beta1"
+  admissionv1 "k8s.io/api/admission/v1"
   danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
   danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
   "github.com/nokia/danm/pkg/confman"
-  "github.com/nokia/danm/pkg/metacni"
-)
-
-var (
-  NetworkPatchPaths = map[string]string {
-    "NetworkType": "/spec/NetworkType",
-    "NetworkID": "/spec/NetworkID",
-    "Alloc": "/spec/Options/alloc",
-    "Alloc6": "/spec/Options/alloc6",
-    "Pool": "/spec/Options/allocation_pool",
-    "Pool6": "/spec/Options/allocation_pool_v6",
-    "Device": "/spec/Options/host_device",
-    "Vlan": "/spec/Options/vlan",
-    "Vxlan": "/spec/Options/vxlan",
-  }
+  "github.com/nokia/danm/pkg/netcontrol"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+  "k8s.io/client-go/kubernetes"
+  "k8s.io/client-go/tools/clientcmd"
 )
 
 type Validator struct {
   Client danmclientset.Interface
+  //KubeClient is used to read the labels of namespaces, when selecting their TenantConfig
+  KubeClient kubernetes.Interface
+  //CniUsers are the users DANM CNI authenticates with towards the API server. DefaultCniUser is used when empty
+  CniUsers []string
+  //WebhookUsers are the users DANM Webhook authenticates with towards the API server. DefaultWebhookUser is used when empty
+  WebhookUsers []string
+  //NetwatcherUsers are the users DANM netwatcher authenticates with towards the API server. DefaultNetwatcherUser is used when empty
+  NetwatcherUsers []string
 }
 
 func CreateNewValidator() (*Validator, error) {
   validator := Validator{}
-  danmClient, err := metacni.CreateDanmClient("")
+  config, err := clientcmd.BuildConfigFromFlags("", "")
+  if err != nil {
+    return nil, errors.New("Parsing kubeconfig failed with error:" + err.Error())
+  }
+  validator.Client, err = danmclientset.NewForConfig(config)
+  if err != nil {
+    return nil, errors.New("Creation of K8s Danm REST client failed with error:" + err.Error())
+  }
+  validator.KubeClient, err = kubernetes.NewForConfig(config)
   if err != nil {
-    return nil, err
+    return nil, errors.New("Creation of K8s REST client failed with error:" + err.Error())
   }
-  validator.Client = danmClient
   return &validator, nil
 }
 
 func (validator *Validator) ValidateNetwork(responseWriter http.ResponseWriter, request *http.Request) {
   admissionReview, err := DecodeAdmissionReview(request)
   if err != nil {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request, err)
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, err)
     return
   }
-  oldManifest, err := getNetworkManifest(admissionReview.Request.OldObject.Raw)
+  isIpamUser := validator.isIpamUser(admissionReview.Request.UserInfo.Username)
+  _, patchList, err := reviewNetwork(validator.Client, validator.KubeClient, admissionReview.Request.OldObject.Raw, admissionReview.Request.Object.Raw, admissionReview.Request.Operation, isIpamUser)
   if err != nil {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request, err)
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, err)
     return
   }
-  newManifest, err := getNetworkManifest(admissionReview.Request.Object.Raw)
+  SendAdmissionResponse(responseWriter, admissionReview, CreateReviewResponseFromPatches(patchList))
+}
+
+//reviewNetwork validates, and mutates a network manifest, returning the mutated manifest together with the patches leading to it
+func reviewNetwork(client danmclientset.Interface, kubeClient kubernetes.Interface, oldObject, newObject []byte, opType admissionv1.Operation, isIpamUser bool) (*danmtypes.DanmNet, []Patch, error) {
+  oldManifest, err := getNetworkManifest(oldObject)
   if err != nil {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request, err)
-    return
+    return nil, nil, err
+  }
+  newManifest, err := getNetworkManifest(newObject)
+  if err != nil {
+    return nil, nil, err
+  }
+  //DANM IPAM updates the allocations of the network every time an IP is reserved, or freed, so those updates are admitted as they are
+  //Allocation changes of any other user are validated as every other update
+  if opType == admissionv1.Update && isIpamUser && isIpamOnlyChange(oldManifest, newManifest) {
+    return newManifest, nil, nil
   }
-  origNewManifest := *newManifest
-  isManifestValid, err := validateNetworkByType(oldManifest, newManifest, admissionReview.Request.Operation, validator.Client)
+  origNewManifest := newManifest.DeepCopy()
+  isManifestValid, err := validateNetworkByType(oldManifest, newManifest, opType, client)
   if !isManifestValid {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request, err)
-    return
+    return nil, nil, err
+  }
+  err = mutateNetManifest(client, kubeClient, newManifest)
+  if err == nil {
+    err = postValidateManifest(client, newManifest)
+  }
+  var patchList []Patch
+  if err == nil {
+    patchList, err = CreatePatchListFromObjectDiff(newObject, origNewManifest, newManifest)
+  }
+  if err == nil && opType == admissionv1.Update {
+    err = releaseReplacedSubnet(client, kubeClient, oldManifest, newManifest)
   }
-  err = mutateNetManifest(validator.Client, newManifest)
   if err != nil {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request, err)
+    releaseTenantResources(client, kubeClient, origNewManifest, newManifest)
+    return nil, nil, err
+  }
+  return newManifest, patchList, nil
+}
+
+//isIpamOnlyChange returns true when the manifests differ at most in the attributes maintained by DANM IPAM
+func isIpamOnlyChange(oldManifest, newManifest *danmtypes.DanmNet) bool {
+  if !reflect.DeepEqual(oldManifest.ObjectMeta.Annotations, newManifest.ObjectMeta.Annotations) {
+    return false
+  }
+  oldSpec := oldManifest.Spec.DeepCopy()
+  newSpec := newManifest.Spec.DeepCopy()
+  for _, spec := range []*danmtypes.DanmNetSpec{oldSpec, newSpec} {
+    spec.Options.Alloc = ""
+    spec.Options.Alloc6 = ""
+    spec.Options.Pool.LastIp = ""
+    spec.Options.Pool6.LastIp = ""
+  }
+  return reflect.DeepEqual(oldSpec, newSpec)
+}
+
+//isIpamUser returns true for the users of the DANM components reserving, and freeing IPs through DANM IPAM: CNI, netwatcher, and the Webhook
+func (validator *Validator) isIpamUser(userName string) bool {
+  if validator.isCniUser(userName) || validator.isWebhookUser(userName) {
+    return true
+  }
+  netwatcherUsers := validator.NetwatcherUsers
+  if len(netwatcherUsers) == 0 {
+    netwatcherUsers = []string{DefaultNetwatcherUser}
+  }
+  for _, netwatcherUser := range netwatcherUsers {
+    if userName == netwatcherUser {
+      return true
+    }
+  }
+  return false
+}
+
+//releaseTenantResources frees the VNI, and the subnet reserved for a TenantNetwork during its mutation, when it cannot be admitted after all
+//Only the resources not present in the original manifest were reserved by the admission
+func releaseTenantResources(client danmclientset.Interface, kubeClient kubernetes.Interface, origManifest, newManifest *danmtypes.DanmNet) {
+  if newManifest.TypeMeta.Kind != "TenantNetwork" {
     return
   }
-  err = postValidateManifest(newManifest)
+  reservedManifest := newManifest.DeepCopy()
+  if origManifest.ObjectMeta.Annotations[confman.CarvedSubnetAnnotation] == reservedManifest.ObjectMeta.Annotations[confman.CarvedSubnetAnnotation] {
+    delete(reservedManifest.ObjectMeta.Annotations, confman.CarvedSubnetAnnotation)
+  }
+  if origManifest.Spec.Options.Vlan != 0 {
+    reservedManifest.Spec.Options.Vlan = 0
+  }
+  if origManifest.Spec.Options.Vxlan != 0 {
+    reservedManifest.Spec.Options.Vxlan = 0
+  }
+  carvedSubnet := reservedManifest.ObjectMeta.Annotations[confman.CarvedSubnetAnnotation]
+  if carvedSubnet == "" && reservedManifest.Spec.Options.Vlan == 0 && reservedManifest.Spec.Options.Vxlan == 0 {
+    return
+  }
+  tconf, err := getOwnerTenantConfig(client, kubeClient, newManifest)
   if err != nil {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request, err)
+    log.Println("WARNING: resources reserved for denied TenantNetwork:" + newManifest.ObjectMeta.Name + " are not released, because:" + err.Error())
     return
   }
-  responseAdmissionReview := v1beta1.AdmissionReview {
-    Response: CreateReviewResponseFromPatches(createPatchListFromNetChanges(origNewManifest,newManifest)),
+  err = confman.Free(client, tconf, reservedManifest)
+  if err != nil {
+    log.Println("WARNING: VNI reserved for denied TenantNetwork:" + newManifest.ObjectMeta.Name + " is not released, because:" + err.Error())
+  }
+  err = confman.FreeSubnet(client, tconf, reservedManifest)
+  if err != nil {
+    log.Println("WARNING: subnet:" + carvedSubnet + " reserved for denied TenantNetwork:" + newManifest.ObjectMeta.Name + " is not released, because:" + err.Error())
   }
-  responseAdmissionReview.Response.UID = admissionReview.Request.UID
-  SendAdmissionResponse(responseWriter, responseAdmissionReview)
+}
+
+//releaseReplacedSubnet frees the subnet carved out of a supernet for a TenantNetwork, when an admitted update does not use it anymore
+//This happens when the cidr is changed, or cleared to get a new subnet carved
+func releaseReplacedSubnet(client danmclientset.Interface, kubeClient kubernetes.Interface, oldManifest, newManifest *danmtypes.DanmNet) error {
+  carvedSubnet := oldManifest.ObjectMeta.Annotations[confman.CarvedSubnetAnnotation]
+  if newManifest.TypeMeta.Kind != "TenantNetwork" || carvedSubnet == "" || carvedSubnet == newManifest.ObjectMeta.Annotations[confman.CarvedSubnetAnnotation] {
+    return nil
+  }
+  tconf, err := getOwnerTenantConfig(client, kubeClient, oldManifest)
+  if err != nil {
+    return errors.New("replaced subnet:" + carvedSubnet + " cannot be released, because:" + err.Error())
+  }
+  err = confman.FreeSubnet(client, tconf, oldManifest)
+  if err != nil {
+    return errors.New("replaced subnet:" + carvedSubnet + " cannot be released, because:" + err.Error())
+  }
+  return nil
 }
 
 func getNetworkManifest(objectToReview []byte) (*danmtypes.DanmNet,error) {
@@ -98,13 +203,13 @@ func getNetworkManifest(objectToReview []byte) (*danmtypes.DanmNet,error) {
   return &networkManifest, nil
 }
 
-func validateNetworkByType(oldManifest, newManifest *danmtypes.DanmNet, opType v1beta1.Operation, client danmclientset.Interface) (bool,error) {
+func validateNetworkByType(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) (bool,error) {
   validatorMapping, isTypeHandled := danmValidationConfig[newManifest.TypeMeta.Kind]
   if !isTypeHandled {
     return false, errors.New("K8s API type:" + newManifest.TypeMeta.Kind + " is not handled by DANM webhook")
   }
   for _, validator := range validatorMapping {
-    err := validator(oldManifest,newManifest,opType,client)
+    err := recordValidation(validator, validator(oldManifest,newManifest,opType,client))
     if err != nil {
       return false, err
     }
@@ -112,14 +217,14 @@ func validateNetworkByType(oldManifest, newManifest *danmtypes.DanmNet, opType v
   return true, nil
 }
 
-func mutateNetManifest(danmClient danmclientset.Interface, dnet *danmtypes.DanmNet) error {
+func mutateNetManifest(danmClient danmclientset.Interface, kubeClient kubernetes.Interface, dnet *danmtypes.DanmNet) error {
   if dnet.Spec.NetworkType == "" {
     dnet.Spec.NetworkType = "ipvlan"
   }
   var err error
   //L3, freshly added network
   if dnet.TypeMeta.Kind == "TenantNetwork" {
-    err = addTenantSpecificDetails(danmClient, dnet)
+    err = addTenantSpecificDetails(danmClient, kubeClient, dnet)
   }
   return err
 }
@@ -128,19 +233,53 @@ func mutateNetManifest(danmClient danmclientset.Interface, dnet *danmtypes.DanmN
 //So we cannot validate those rules beforehand, but we also can't be sure they are satisfied by variable user configuration.
 //Example is NetworkID related validations for TenantNetworks
 //TODO: make this also fancy when more post validation needs surface
-func postValidateManifest(dnet *danmtypes.DanmNet) error {
-  return validateNetworkId(nil, dnet, "", nil)
+func postValidateManifest(danmClient danmclientset.Interface, dnet *danmtypes.DanmNet) error {
+  err := recordValidation(validateNetworkId, validateNetworkId(nil, dnet, "", nil))
+  if err != nil {
+    return err
+  }
+  if dnet.TypeMeta.Kind == "TenantNetwork" {
+    err = recordValidation(validateCidrOverlap, validateCidrOverlap(nil, dnet, "", danmClient))
+    if err != nil {
+      return err
+    }
+    return recordValidation(validateVniUniqueness, validateVniUniqueness(nil, dnet, "", danmClient))
+  }
+  return nil
 }
 
-//TODO: we could easily add CIDR + allocation pool overwrites as well for TenantNetworks, if needed
-//Open an issue with your use-case if you see the need!
-func addTenantSpecificDetails(danmClient danmclientset.Interface, tnet *danmtypes.DanmNet) error {
-  tconf, err := confman.GetTenantConfig(danmClient)
+//addTenantSpecificDetails fills the attributes of a TenantNetwork managed by the administrator through its TenantConfig
+//The subnet carved out of a supernet, and the VNI are released by reviewNetwork if the TenantNetwork cannot be admitted after all
+func addTenantSpecificDetails(danmClient danmclientset.Interface, kubeClient kubernetes.Interface, tnet *danmtypes.DanmNet) error {
+  tconf, err := getTenantConfig(danmClient, kubeClient, tnet.ObjectMeta.Namespace)
+  if err != nil {
+    return err
+  }
+  usage, err := getQuotaUsage(danmClient, tnet, tconf)
+  if err != nil {
+    return err
+  }
+  //The owner is recorded, so resources are always released to the TenantConfig they were reserved from, even if the namespace is selected by another one later
+  if tnet.ObjectMeta.Annotations[TenantConfigAnnotation] == "" {
+    if tnet.ObjectMeta.Annotations == nil {
+      tnet.ObjectMeta.Annotations = make(map[string]string)
+    }
+    tnet.ObjectMeta.Annotations[TenantConfigAnnotation] = tconf.ObjectMeta.Name
+  }
+  err = carveSubnetFromSupernet(danmClient, tnet, tconf)
+  if err != nil {
+    return err
+  }
+  return attachTenantResources(danmClient, tnet, tconf, usage)
+}
+
+func attachTenantResources(danmClient danmclientset.Interface, tnet *danmtypes.DanmNet, tconf *danmtypes.TenantConfig, usage *QuotaUsage) error {
+  err := validateNetworkQuota(tnet, usage)
   if err != nil {
     return err
   }
   if IsTypeDynamic(tnet.Spec.NetworkType) {
-    err = allocateDetailsForDynamicBackends(danmClient, tnet,tconf)
+    err = allocateDetailsForDynamicBackends(danmClient, tnet,tconf,usage)
     if err != nil {
       return err
     }
@@ -155,15 +294,80 @@ func addTenantSpecificDetails(danmClient danmclientset.Interface, tnet *danmtype
   return nil
 }
 
-func allocateDetailsForDynamicBackends(danmClient danmclientset.Interface, tnet *danmtypes.DanmNet,tconf *danmtypes.TenantConfig) error {
+//carveSubnetFromSupernet reserves the next free subnet of the matching supernet for TenantNetworks created without cidr
+//Subnets overlapping with any existing network are skipped
+func carveSubnetFromSupernet(danmClient danmclientset.Interface, tnet *danmtypes.DanmNet, tconf *danmtypes.TenantConfig) error {
+  //The previously carved subnet is released by reviewNetwork once the update not using it anymore is admitted
+  if carvedSubnet := tnet.ObjectMeta.Annotations[confman.CarvedSubnetAnnotation]; carvedSubnet != "" && carvedSubnet != tnet.Spec.Options.Cidr {
+    delete(tnet.ObjectMeta.Annotations, confman.CarvedSubnetAnnotation)
+  }
+  if tnet.Spec.Options.Cidr != "" {
+    return nil
+  }
+  supernetIndex := confman.GetSupernetIndex(tconf, tnet.ObjectMeta.Namespace, tnet.Spec.NetworkType)
+  if supernetIndex == -1 {
+    return nil
+  }
+  supernetCidr := tconf.Supernets[supernetIndex].Cidr
+  nets, err := netcontrol.ListAllNetworks(danmClient)
+  if err != nil {
+    return errors.New("cannot carve subnet out of supernet:" + supernetCidr + " , because existing networks cannot be listed:" + err.Error())
+  }
+  var usedSubnets []*net.IPNet
+  for _, dnet := range nets {
+    _, subnet, err := net.ParseCIDR(dnet.Spec.Options.Cidr)
+    if err == nil {
+      usedSubnets = append(usedSubnets, subnet)
+    }
+  }
+  cidr, err := confman.ReserveSubnet(danmClient, tconf, supernetCidr, usedSubnets...)
+  if err != nil {
+    return errors.New("cannot carve subnet out of supernet:" + supernetCidr + " , because:" + err.Error())
+  }
+  tnet.Spec.Options.Cidr = cidr
+  tnet.ObjectMeta.Annotations[confman.CarvedSubnetAnnotation] = cidr
+  //The allocation pool is initialized the same way as for networks created with cidr
+  return validateAllocV4(tnet)
+}
+
+//getTenantConfig returns the TenantConfig applying to a namespace
+//Namespaces can only be selected by their labels when a K8s client is available, which is not the case e.g. during dry-runs
+func getTenantConfig(danmClient danmclientset.Interface, kubeClient kubernetes.Interface, namespace string) (*danmtypes.TenantConfig, error) {
+  var namespaceLabels map[string]string
+  if kubeClient != nil {
+    ns, err := kubeClient.CoreV1().Namespaces().Get(context.TODO(), namespace, meta_v1.GetOptions{})
+    if err != nil {
+      return nil, errors.New("labels of namespace:" + namespace + " cannot be read to select its TenantConfig, because:" + err.Error())
+    }
+    namespaceLabels = ns.ObjectMeta.Labels
+  }
+  return confman.GetTenantConfig(danmClient, namespace, namespaceLabels)
+}
+
+//getOwnerTenantConfig returns the TenantConfig recorded as the owner of a TenantNetwork during its admission
+//TenantNetworks admitted before owners were recorded belong to the TenantConfig currently selecting their namespace
+func getOwnerTenantConfig(danmClient danmclientset.Interface, kubeClient kubernetes.Interface, tnet *danmtypes.DanmNet) (*danmtypes.TenantConfig, error) {
+  ownerName := tnet.ObjectMeta.Annotations[TenantConfigAnnotation]
+  if ownerName == "" {
+    return getTenantConfig(danmClient, kubeClient, tnet.ObjectMeta.Namespace)
+  }
+  tconf, err := danmClient.DanmV1().TenantConfigs().Get(context.TODO(), ownerName, meta_v1.GetOptions{})
+  if err != nil {
+    return nil, errors.New("owner TenantConfig:" + ownerName + " cannot be read, because:" + err.Error())
+  }
+  tconf.TypeMeta.Kind = confman.TenantConfigKind
+  return tconf, nil
+}
+
+func allocateDetailsForDynamicBackends(danmClient danmclientset.Interface, tnet *danmtypes.DanmNet,tconf *danmtypes.TenantConfig, usage *QuotaUsage) error {
   var pfProfiles []danmtypes.IfaceProfile
   for _, iface := range tconf.HostDevices {
     if tnet.Spec.Options.DevicePool != "" && tnet.Spec.Options.DevicePool == iface.Name {
       //This is the interface profile belonging to the network's DevicePool
-      return attachNetworkToIfaceProfile(danmClient, tnet,tconf,iface)
+      return attachNetworkToIfaceProfile(danmClient, tnet,tconf,iface,usage)
     } else if tnet.Spec.Options.Device == iface.Name && !strings.Contains(iface.Name,"/") {
       //This is the interface profile matching the requested host_device
-      return attachNetworkToIfaceProfile(danmClient, tnet,tconf,iface)
+      return attachNetworkToIfaceProfile(danmClient, tnet,tconf,iface,usage)
     }
     //DevicePools generally look like this: "xyz.abc.io/resource_name".
     //Here we separate "real" NICs from abstract K8s Devices
@@ -172,25 +376,57 @@ func allocateDetailsForDynamicBackends(danmClient danmclientset.Interface, tnet
     }
   }
   //Explicitly requested physical interfaces shall be also explicitly allowed by the administrator
-  if tnet.Spec.Options.DevicePool != "" || tnet.Spec.Options.Device != "" {
-    return errors.New("The provided physical interface is not allowed to be used by tenants!")
+  if tnet.Spec.Options.DevicePool != "" {
+    return forbiddenField(devicePoolField, "The provided physical interface is not allowed to be used by tenants!")
+  }
+  if tnet.Spec.Options.Device != "" {
+    return forbiddenField(deviceField, "The provided physical interface is not allowed to be used by tenants!")
   }
   if len(pfProfiles) == 0 {
     return errors.New("There are no suitable interface profiles configured for TenantNetworks!")
   }
-  rand.Seed(time.Now().UnixNano())
-  chosenProfile := pfProfiles[rand.Intn(len(pfProfiles))]
-  //Otherwise we randomly choose an interface profile and attach the TenantNetwork to it
-  return attachNetworkToIfaceProfile(danmClient, tnet,tconf,chosenProfile)
+  //Otherwise we choose an interface profile according to the placement policy, and fall back to the next one if the chosen one is exhausted
+  orderedProfiles, err := orderIfaceProfiles(danmClient, tnet, tconf, pfProfiles)
+  if err != nil {
+    return err
+  }
+  var attachErrors []string
+  for _, profile := range orderedProfiles {
+    err = attachNetworkToIfaceProfile(danmClient, tnet,tconf,profile,usage)
+    if err == nil {
+      if tconf.PlacementPolicy == RoundRobinPlacement {
+        err = confman.SetLastPlacement(danmClient, tconf, profile.Name)
+        if err != nil {
+          log.Println("WARNING: round-robin placement of TenantConfig:" + tconf.ObjectMeta.Name + " could not be advanced, because:" + err.Error())
+        }
+      }
+      return nil
+    }
+    tnet.Spec.Options.Device = ""
+    attachErrors = append(attachErrors, err.Error())
+  }
+  return errors.New("TenantNetwork cannot be attached to any of the interface profiles:" + strings.Join(attachErrors, "; "))
 }
 
-func attachNetworkToIfaceProfile(danmClient danmclientset.Interface, tnet *danmtypes.DanmNet, tconf *danmtypes.TenantConfig, iface danmtypes.IfaceProfile) error {
+func attachNetworkToIfaceProfile(danmClient danmclientset.Interface, tnet *danmtypes.DanmNet, tconf *danmtypes.TenantConfig, iface danmtypes.IfaceProfile, usage *QuotaUsage) error {
   if tnet.Spec.Options.Device == "" && tnet.Spec.Options.DevicePool == "" {
     tnet.Spec.Options.Device = iface.Name
   }
   if (iface.VniType == "vlan" && tnet.Spec.Options.Vlan == 0) ||
      (iface.VniType == "vxlan" && tnet.Spec.Options.Vxlan == 0) {
-    vni,err := confman.Reserve(danmClient, tconf, iface)
+    err := validateVniQuota(iface, usage)
+    if err != nil {
+      return err
+    }
+    var usedVnis []int
+    //VNIs of host interfaces already used by other networks cannot be handed out, even if they are free in the TenantConfig
+    if !strings.Contains(iface.Name, "/") {
+      usedVnis, err = getUsedVnis(danmClient, tnet, iface.Name, iface.VniType)
+      if err != nil {
+        return errors.New("cannot reserve VNI for interface:" + iface.Name + " , because its used VNIs cannot be listed:" + err.Error())
+      }
+    }
+    vni,err := confman.Reserve(danmClient, tconf, iface, usedVnis...)
     if err != nil {
       return errors.New("cannot reserve VNI for interface:" + iface.Name + " , because:" + err.Error())
     }
@@ -203,35 +439,3 @@ func attachNetworkToIfaceProfile(danmClient danmclientset.Interface, tnet *danmt
   return nil
 }
 
-func createPatchListFromNetChanges(origNetwork danmtypes.DanmNet, changedNetwork *danmtypes.DanmNet) []Patch {
-  patchList := make([]Patch, 0)
-  if origNetwork.Spec.Options.Alloc != changedNetwork.Spec.Options.Alloc {
-    //TODO: Could (?) use some reflecting here to determine name of the struct field
-    patchList = append(patchList, CreateGenericPatchFromChange(NetworkPatchPaths["Alloc"], changedNetwork.Spec.Options.Alloc))
-  }
-  if origNetwork.Spec.Options.Alloc6 != changedNetwork.Spec.Options.Alloc6 {
-    patchList = append(patchList, CreateGenericPatchFromChange(NetworkPatchPaths["Alloc6"], changedNetwork.Spec.Options.Alloc6))
-  }
-  if origNetwork.Spec.NetworkType != changedNetwork.Spec.NetworkType {
-    patchList = append(patchList, CreateGenericPatchFromChange(NetworkPatchPaths["NetworkType"], changedNetwork.Spec.NetworkType))
-  }
-  if origNetwork.Spec.NetworkID != changedNetwork.Spec.NetworkID {
-    patchList = append(patchList, CreateGenericPatchFromChange(NetworkPatchPaths["NetworkID"], changedNetwork.Spec.NetworkID))
-  }
-  if !reflect.DeepEqual(origNetwork.Spec.Options.Pool, changedNetwork.Spec.Options.Pool) {
-    patchList = append(patchList, CreateGenericPatchFromChange(NetworkPatchPaths["Pool"], changedNetwork.Spec.Options.Pool))
-  }
-  if !reflect.DeepEqual(origNetwork.Spec.Options.Pool6, changedNetwork.Spec.Options.Pool6) {
-    patchList = append(patchList, CreateGenericPatchFromChange(NetworkPatchPaths["Pool6"], changedNetwork.Spec.Options.Pool6))
-  }
-  if origNetwork.Spec.Options.Device != changedNetwork.Spec.Options.Device {
-    patchList = append(patchList, CreateGenericPatchFromChange(NetworkPatchPaths["Device"], changedNetwork.Spec.Options.Device))
-  }
-  if origNetwork.Spec.Options.Vlan != changedNetwork.Spec.Options.Vlan {
-    patchList = append(patchList, CreateGenericPatchFromChange(NetworkPatchPaths["Vlan"], changedNetwork.Spec.Options.Vlan))
-  }
-  if origNetwork.Spec.Options.Vxlan != changedNetwork.Spec.Options.Vxlan {
-    patchList = append(patchList, CreateGenericPatchFromChange(NetworkPatchPaths["Vxlan"], changedNetwork.Spec.Options.Vxlan))
-  }
-  return patchList
-}
@@ -2,10 +2,13 @@ package admit
 
 import (
   "errors"
+  "log"
   "net/http"
-  "k8s.io/api/admission/v1beta1"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
   "github.com/nokia/danm/pkg/confman"
   "github.com/nokia/danm/pkg/danmep"
+  "k8s.io/client-go/kubernetes"
 )
 
 //A GIGANTIC DISCLAIMER: THIS DOES NOT WORK BEFORE K8S 1.15!
@@ -14,42 +17,54 @@ import (
 func (validator *Validator) DeleteNetwork(responseWriter http.ResponseWriter, request *http.Request) {
   admissionReview, err := DecodeAdmissionReview(request)
   if err != nil {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request, err)
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, err)
     return
   }
   oldManifest, err := getNetworkManifest(admissionReview.Request.OldObject.Raw)
   if err != nil {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request, err)
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, err)
     return
   }
   isAnyPodConnectedToNetwork, connectedEp, err := danmep.ArePodsConnectedToNetwork(validator.Client, oldManifest)
   if err != nil {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request,
+    SendErroneousAdmissionResponse(responseWriter, admissionReview,
     errors.New("Network cannot be deleted because there is no way to tell if Pods are still using it due to:" + err.Error()))
     return  
   }
   if isAnyPodConnectedToNetwork {
-    SendErroneousAdmissionResponse(responseWriter, admissionReview.Request,
+    SendErroneousAdmissionResponse(responseWriter, admissionReview,
     errors.New("Network cannot be deleted because there are Pods still connected to it e.g. Pod:" + connectedEp.Spec.Pod + " in namespace:" + connectedEp.ObjectMeta.Namespace))
     return   
   }
-  if oldManifest.TypeMeta.Kind == "TenantNetwork" && IsTypeDynamic(oldManifest.Spec.NetworkType) {
-    tconf, err := confman.GetTenantConfig(validator.Client)
+  if oldManifest.TypeMeta.Kind == "TenantNetwork" {
+    err = freeTenantResources(validator.Client, validator.KubeClient, oldManifest)
     if err != nil {
-      SendErroneousAdmissionResponse(responseWriter, admissionReview.Request,
-      errors.New("The network's VNI could not be freed, because:" + err.Error()))
+      SendErroneousAdmissionResponse(responseWriter, admissionReview, err)
       return
     }
-    err = confman.Free(validator.Client, tconf, oldManifest)
+  }
+  SendAdmissionResponse(responseWriter, admissionReview, CreateReviewResponseFromPatches(nil))
+}
+//freeTenantResources releases the VNI, and the subnet carved out of a supernet of a TenantNetwork in the TenantConfig they were reserved from
+func freeTenantResources(client danmclientset.Interface, kubeClient kubernetes.Interface, tnet *danmtypes.DanmNet) error {
+  tconf, err := getOwnerTenantConfig(client, kubeClient, tnet)
+  if err != nil && !IsTypeDynamic(tnet.Spec.NetworkType) {
+    //Static TenantNetworks could have been created before any TenantConfig with supernets existed
+    log.Println("WARNING: subnet of TenantNetwork:" + tnet.ObjectMeta.Name + " in namespace:" + tnet.ObjectMeta.Namespace + " is not released, because:" + err.Error())
+    return nil
+  }
+  if err != nil {
+    return errors.New("The network's VNI could not be freed, because:" + err.Error())
+  }
+  if IsTypeDynamic(tnet.Spec.NetworkType) {
+    err = confman.Free(client, tconf, tnet)
     if err != nil {
-      SendErroneousAdmissionResponse(responseWriter, admissionReview.Request,
-      errors.New("The network's VNI could not be freed, because:" + err.Error()))
-      return
+      return errors.New("The network's VNI could not be freed, because:" + err.Error())
     }
   }
-  responseAdmissionReview := v1beta1.AdmissionReview {
-    Response: CreateReviewResponseFromPatches(nil),
+  err = confman.FreeSubnet(client, tconf, tnet)
+  if err != nil {
+    return errors.New("The network's subnet could not be released, because:" + err.Error())
   }
-  responseAdmissionReview.Response.UID = admissionReview.Request.UID
-  SendAdmissionResponse(responseWriter, responseAdmissionReview)
-}
\ No newline at end of file
+  return nil
+}
@@ -0,0 +1,136 @@
+package admit
+
+import (
+  "errors"
+  "math/rand"
+  "sort"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
+  "github.com/nokia/danm/pkg/netcontrol"
+)
+
+//Placement policies decide which interface profile of a TenantConfig a TenantNetwork without host_device is attached to
+const (
+  RandomPlacement = "random"
+  LeastUsedPlacement = "least-used"
+  RoundRobinPlacement = "round-robin"
+  WeightedPlacement = "weighted"
+  LabelAffinityPlacement = "label-affinity"
+)
+
+var (
+  supportedPlacementPolicies = []string{RandomPlacement, LeastUsedPlacement, RoundRobinPlacement, WeightedPlacement, LabelAffinityPlacement}
+)
+
+func isPlacementPolicySupported(policy string) bool {
+  if policy == "" {
+    return true
+  }
+  for _, supportedPolicy := range supportedPlacementPolicies {
+    if policy == supportedPolicy {
+      return true
+    }
+  }
+  return false
+}
+
+//orderIfaceProfiles returns the interface profiles in the order the TenantNetwork shall try to be attached to them, according to the placement policy of the TenantConfig
+//The profiles after the first one are only used when the ones before are exhausted
+func orderIfaceProfiles(danmClient danmclientset.Interface, tnet *danmtypes.DanmNet, tconf *danmtypes.TenantConfig, profiles []danmtypes.IfaceProfile) ([]danmtypes.IfaceProfile,error) {
+  switch tconf.PlacementPolicy {
+  case LeastUsedPlacement:
+    return orderByUsage(danmClient, profiles)
+  case RoundRobinPlacement:
+    return orderAfterLastPlacement(profiles, tconf.LastPlacement), nil
+  case WeightedPlacement:
+    return orderByWeight(profiles), nil
+  case LabelAffinityPlacement:
+    return orderByLabelAffinity(tnet, profiles)
+  }
+  orderedProfiles := append([]danmtypes.IfaceProfile{}, profiles...)
+  rand.Shuffle(len(orderedProfiles), func(i, j int) {
+    orderedProfiles[i], orderedProfiles[j] = orderedProfiles[j], orderedProfiles[i]
+  })
+  return orderedProfiles, nil
+}
+
+//Every network attached to a host device is counted, regardless of its API type
+func orderByUsage(danmClient danmclientset.Interface, profiles []danmtypes.IfaceProfile) ([]danmtypes.IfaceProfile,error) {
+  nets, err := netcontrol.ListAllNetworks(danmClient)
+  if err != nil {
+    return nil, errors.New("cannot list networks to find the least used interface profile, because:" + err.Error())
+  }
+  usage := make(map[string]int)
+  for _, dnet := range nets {
+    usage[dnet.Spec.Options.Device]++
+  }
+  orderedProfiles := append([]danmtypes.IfaceProfile{}, profiles...)
+  sort.SliceStable(orderedProfiles, func(i, j int) bool {
+    return usage[orderedProfiles[i].Name] < usage[orderedProfiles[j].Name]
+  })
+  return orderedProfiles, nil
+}
+
+func orderAfterLastPlacement(profiles []danmtypes.IfaceProfile, lastPlacement string) []danmtypes.IfaceProfile {
+  for index, profile := range profiles {
+    if profile.Name == lastPlacement {
+      return append(append([]danmtypes.IfaceProfile{}, profiles[index+1:]...), profiles[:index+1]...)
+    }
+  }
+  return profiles
+}
+
+//Profiles are drawn one after the other, every time with a probability proportional to their weight among the remaining ones
+func orderByWeight(profiles []danmtypes.IfaceProfile) []danmtypes.IfaceProfile {
+  remainingProfiles := append([]danmtypes.IfaceProfile{}, profiles...)
+  var orderedProfiles []danmtypes.IfaceProfile
+  for len(remainingProfiles) > 0 {
+    var totalWeight int
+    for _, profile := range remainingProfiles {
+      totalWeight += getWeight(profile)
+    }
+    draw := rand.Intn(totalWeight)
+    for index, profile := range remainingProfiles {
+      draw -= getWeight(profile)
+      if draw < 0 {
+        orderedProfiles = append(orderedProfiles, profile)
+        remainingProfiles = append(remainingProfiles[:index], remainingProfiles[index+1:]...)
+        break
+      }
+    }
+  }
+  return orderedProfiles
+}
+
+func getWeight(profile danmtypes.IfaceProfile) int {
+  if profile.Weight == 0 {
+    return 1
+  }
+  return profile.Weight
+}
+
+//Only profiles whose every label is also put on the TenantNetwork can be chosen, and the ones matching more labels are preferred
+func orderByLabelAffinity(tnet *danmtypes.DanmNet, profiles []danmtypes.IfaceProfile) ([]danmtypes.IfaceProfile,error) {
+  var orderedProfiles []danmtypes.IfaceProfile
+  for _, profile := range profiles {
+    if doLabelsMatch(profile.Labels, tnet.ObjectMeta.Labels) {
+      orderedProfiles = append(orderedProfiles, profile)
+    }
+  }
+  if len(orderedProfiles) == 0 {
+    return nil, errors.New("none of the interface profiles match the labels of the TenantNetwork")
+  }
+  sort.SliceStable(orderedProfiles, func(i, j int) bool {
+    return len(orderedProfiles[i].Labels) > len(orderedProfiles[j].Labels)
+  })
+  return orderedProfiles, nil
+}
+
+func doLabelsMatch(profileLabels, netLabels map[string]string) bool {
+  for key, value := range profileLabels {
+    if netValue, isLabelSet := netLabels[key]; !isLabelSet || netValue != value {
+      return false
+    }
+  }
+  return true
+}
@@ -0,0 +1,95 @@
+package admit
+
+import (
+  "errors"
+  "strconv"
+  "encoding/json"
+  "net/http"
+  corev1 "k8s.io/api/core/v1"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
+  "github.com/nokia/danm/pkg/cnidel"
+  "github.com/nokia/danm/pkg/datastructs"
+  "github.com/nokia/danm/pkg/ipam"
+  "github.com/nokia/danm/pkg/netcontrol"
+)
+
+// ValidatePod denies the creation of Pods whose network connections would surely fail during CNI ADD
+// It validates the syntax of the DANM annotation, the default route claims, the existence of the referenced networks, the AllowedTenants whitelists, and the requested static IPs
+func (validator *Validator) ValidatePod(responseWriter http.ResponseWriter, request *http.Request) {
+  admissionReview, err := DecodeAdmissionReview(request)
+  if err != nil {
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, err)
+    return
+  }
+  pod, err := decodePod(admissionReview.Request.Object.Raw)
+  if err != nil {
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, err)
+    return
+  }
+  //The namespace is not necessarily set in the manifest of a Pod being created, but is always present in the request
+  if pod.ObjectMeta.Namespace == "" {
+    pod.ObjectMeta.Namespace = admissionReview.Request.Namespace
+  }
+  ifaces, err := datastructs.GetPodInterfaces(pod)
+  if err != nil {
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, invalidField(podInterfacesField, err.Error()))
+    return
+  }
+  _, _, err = datastructs.GetDefaultRouteOwners(ifaces)
+  if err != nil {
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, invalidField(podInterfacesField, "default route cannot be selected for Pod:" + pod.ObjectMeta.Name + " , because:" + err.Error()))
+    return
+  }
+  err = recordValidation(validatePodInterfaces, validatePodInterfaces(validator.Client, ifaces, pod.ObjectMeta.Namespace))
+  if err != nil {
+    SendErroneousAdmissionResponse(responseWriter, admissionReview, invalidField(podInterfacesField, "Pod:" + pod.ObjectMeta.Name + " cannot be admitted, because:" + err.Error()))
+    return
+  }
+  SendAdmissionResponse(responseWriter, admissionReview, CreateReviewResponseFromPatches(nil))
+}
+
+func decodePod(objectToReview []byte) (*corev1.Pod,error) {
+  pod := corev1.Pod{}
+  if objectToReview == nil {
+    return nil, errors.New("Pod manifest is missing from the review request!")
+  }
+  //Pods are not DANM's objects, so unknown fields are not our business here
+  err := json.Unmarshal(objectToReview, &pod)
+  if err != nil {
+    return nil, errors.New("Pod manifest could not be decoded:" + err.Error())
+  }
+  return &pod, nil
+}
+
+func validatePodInterfaces(danmClient danmclientset.Interface, ifaces []datastructs.Interface, nameSpace string) error {
+  for ifaceId, iface := range ifaces {
+    netInfo, err := netcontrol.GetNetworkFromInterface(danmClient, iface, nameSpace)
+    if err != nil {
+      return errors.New("network connection no.:" + strconv.Itoa(ifaceId) + " is invalid, because:" + err.Error())
+    }
+    if !datastructs.IsTenantAllowed(nameSpace, netInfo) {
+      return errors.New("namespace:" + nameSpace + " is not in the AllowedTenants whitelist of network:" + netInfo.ObjectMeta.Name)
+    }
+    err = validateIpRequests(iface, netInfo)
+    if err != nil {
+      return errors.New("network connection no.:" + strconv.Itoa(ifaceId) + " to network:" + netInfo.ObjectMeta.Name + " is invalid, because:" + err.Error())
+    }
+  }
+  return nil
+}
+
+//IP requests are only evaluated by DANM IPAM if the network is managed by it, otherwise they are silently ignored during CNI ADD
+func validateIpRequests(iface datastructs.Interface, netInfo *danmtypes.DanmNet) error {
+  if !cnidel.IsDanmIpamNeededForDelegation(iface, netInfo) && netInfo.Spec.NetworkType != "ipvlan" {
+    return nil
+  }
+  err := ipam.ValidateIpRequest(iface.Ip, netInfo.Spec.Options.Cidr)
+  if err != nil {
+    return err
+  }
+  if ipam.IsIpv6Autoconfigured(netInfo) {
+    return nil
+  }
+  return ipam.ValidateIpRequest(iface.Ip6, netInfo.Spec.Options.Net6)
+}
@@ -0,0 +1,169 @@
+package admit
+
+import (
+  "context"
+  "errors"
+  "log"
+  "net"
+  "sort"
+  "strconv"
+  "encoding/json"
+  "net/http"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
+  "github.com/nokia/danm/pkg/ipam"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//QuotaUsage is the amount of resources the TenantNetworks of a namespace use, compared to the quota of the namespace's TenantConfig
+type QuotaUsage struct {
+  Namespace string `json:"namespace"`
+  TenantConfig string `json:"tenantConfig"`
+  Networks ResourceUsage `json:"networks"`
+  Ips ResourceUsage `json:"ips"`
+  Vnis []VniUsage `json:"vnis,omitempty"`
+}
+
+//ResourceUsage is the used amount, and the limit of one resource. Zero limit means unlimited
+type ResourceUsage struct {
+  Used int `json:"used"`
+  Limit int `json:"limit"`
+}
+
+//VniUsage is the number of VNIs reserved from one interface profile
+type VniUsage struct {
+  Profile string `json:"profile"`
+  VniType string `json:"vniType"`
+  ResourceUsage `json:",inline"`
+}
+
+func (usage ResourceUsage) isExceededBy(amount int) bool {
+  return usage.Limit > 0 && usage.Used + amount > usage.Limit
+}
+
+func (usage ResourceUsage) String() string {
+  return "used:" + strconv.Itoa(usage.Used) + ", limit:" + strconv.Itoa(usage.Limit)
+}
+
+//ServeQuotaUsage reports the quota usage of every namespace having TenantNetworks, whose TenantConfig defines a quota
+func (validator *Validator) ServeQuotaUsage(responseWriter http.ResponseWriter, request *http.Request) {
+  tnets, err := validator.Client.DanmV1().TenantNetworks("").List(context.TODO(), meta_v1.ListOptions{})
+  if err != nil {
+    http.Error(responseWriter, "TenantNetworks cannot be listed, because:" + err.Error(), http.StatusInternalServerError)
+    return
+  }
+  namespaces := make(map[string]bool)
+  for _, tnet := range tnets.Items {
+    namespaces[tnet.ObjectMeta.Namespace] = true
+  }
+  usages := make([]QuotaUsage, 0)
+  for namespace := range namespaces {
+    tconf, err := getTenantConfig(validator.Client, validator.KubeClient, namespace)
+    if err != nil {
+      log.Println("WARNING: quota usage of namespace:" + namespace + " is not reported, because its TenantConfig cannot be selected:" + err.Error())
+      continue
+    }
+    if tconf.Quota == nil {
+      continue
+    }
+    usages = append(usages, calculateQuotaUsage(tnets.Items, namespace, "", tconf))
+  }
+  sort.Slice(usages, func(i, j int) bool {
+    return usages[i].Namespace < usages[j].Namespace
+  })
+  responseWriter.Header().Set("Content-Type", "application/json")
+  json.NewEncoder(responseWriter).Encode(usages)
+}
+
+//getQuotaUsage returns the quota usage of a TenantNetwork's namespace, without counting the TenantNetwork itself
+//Returns nil if the TenantConfig of the namespace does not define a quota
+func getQuotaUsage(client danmclientset.Interface, tnet *danmtypes.DanmNet, tconf *danmtypes.TenantConfig) (*QuotaUsage, error) {
+  if tconf.Quota == nil {
+    return nil, nil
+  }
+  tnets, err := client.DanmV1().TenantNetworks(tnet.ObjectMeta.Namespace).List(context.TODO(), meta_v1.ListOptions{})
+  if err != nil {
+    return nil, errors.New("cannot list TenantNetworks to calculate the quota usage of namespace:" + tnet.ObjectMeta.Namespace + ", because:" + err.Error())
+  }
+  usage := calculateQuotaUsage(tnets.Items, tnet.ObjectMeta.Namespace, tnet.ObjectMeta.Name, tconf)
+  return &usage, nil
+}
+
+func calculateQuotaUsage(tnets []danmtypes.TenantNetwork, namespace, excludedNet string, tconf *danmtypes.TenantConfig) QuotaUsage {
+  usage := QuotaUsage {
+    Namespace: namespace,
+    TenantConfig: tconf.ObjectMeta.Name,
+    Networks: ResourceUsage{Limit: tconf.Quota.MaxNetworks},
+    Ips: ResourceUsage{Limit: tconf.Quota.MaxIps},
+  }
+  for _, iface := range tconf.HostDevices {
+    if iface.VniType != "" {
+      usage.Vnis = append(usage.Vnis, VniUsage{Profile: iface.Name, VniType: iface.VniType, ResourceUsage: ResourceUsage{Limit: tconf.Quota.MaxVnisPerProfile}})
+    }
+  }
+  for _, tnet := range tnets {
+    if tnet.ObjectMeta.Namespace != namespace || tnet.ObjectMeta.Name == excludedNet {
+      continue
+    }
+    dnet := danmtypes.DanmNet(tnet)
+    usage.Networks.Used++
+    usage.Ips.Used += getPoolSize(&dnet)
+    ifaceName, vniType := getUsedVni(&dnet)
+    for index, vniUsage := range usage.Vnis {
+      if vniUsage.Profile == ifaceName && vniUsage.VniType == vniType {
+        usage.Vnis[index].Used++
+      }
+    }
+  }
+  return usage
+}
+
+//Only IPv4 allocation pools are counted, as IPv6 pools are practically unlimited
+func getPoolSize(dnet *danmtypes.DanmNet) int {
+  start := net.ParseIP(dnet.Spec.Options.Pool.Start)
+  end := net.ParseIP(dnet.Spec.Options.Pool.End)
+  if start == nil || end == nil || start.To4() == nil || end.To4() == nil {
+    return 0
+  }
+  return int(ipam.Ip2int(end)) - int(ipam.Ip2int(start)) + 1
+}
+
+func getUsedVni(dnet *danmtypes.DanmNet) (string,string) {
+  ifaceName := dnet.Spec.Options.Device
+  if dnet.Spec.Options.DevicePool != "" {
+    ifaceName = dnet.Spec.Options.DevicePool
+  }
+  if dnet.Spec.Options.Vlan != 0 {
+    return ifaceName, "vlan"
+  }
+  if dnet.Spec.Options.Vxlan != 0 {
+    return ifaceName, "vxlan"
+  }
+  return "", ""
+}
+
+func validateNetworkQuota(tnet *danmtypes.DanmNet, usage *QuotaUsage) error {
+  if usage == nil {
+    return nil
+  }
+  if usage.Networks.isExceededBy(1) {
+    return errors.New("TenantNetwork cannot be created, because namespace:" + usage.Namespace + " reached its maximum number of networks set in TenantConfig:" + usage.TenantConfig + " (" + usage.Networks.String() + ")")
+  }
+  poolSize := getPoolSize(tnet)
+  if usage.Ips.isExceededBy(poolSize) {
+    return errors.New("TenantNetwork cannot be created, because its " + strconv.Itoa(poolSize) + " IPs would exceed the IP quota of namespace:" + usage.Namespace + " set in TenantConfig:" + usage.TenantConfig + " (" + usage.Ips.String() + ")")
+  }
+  return nil
+}
+
+func validateVniQuota(iface danmtypes.IfaceProfile, usage *QuotaUsage) error {
+  if usage == nil {
+    return nil
+  }
+  for _, vniUsage := range usage.Vnis {
+    if vniUsage.Profile == iface.Name && vniUsage.VniType == iface.VniType && vniUsage.isExceededBy(1) {
+      return errors.New("TenantNetwork cannot be created, because namespace:" + usage.Namespace + " reached its maximum number of " + iface.VniType + "s on interface profile:" + iface.Name + " set in TenantConfig:" + usage.TenantConfig + " (" + vniUsage.String() + ")")
+    }
+  }
+  return nil
+}
@@ -0,0 +1,67 @@
+package admit
+
+import (
+  "net/http"
+  admissionv1 "k8s.io/api/admission/v1"
+  metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+  //Same as the forbidden error type of K8s API field validation, metav1 does not define a constant for it
+  CauseTypeFieldValueForbidden metav1.CauseType = "FieldValueForbidden"
+)
+
+//FieldError is a denial reason pointing to the offending field of the reviewed object
+//Field is the JSON path of the field, e.g. spec.Options.cidr, Type tells what is wrong with it
+type FieldError struct {
+  Field string
+  Type metav1.CauseType
+  Message string
+}
+
+func (fieldError *FieldError) Error() string {
+  return fieldError.Message
+}
+
+func invalidField(field, message string) error {
+  return &FieldError{Field: field, Type: metav1.CauseTypeFieldValueInvalid, Message: message}
+}
+
+func requiredField(field, message string) error {
+  return &FieldError{Field: field, Type: metav1.CauseTypeFieldValueRequired, Message: message}
+}
+
+func forbiddenField(field, message string) error {
+  return &FieldError{Field: field, Type: CauseTypeFieldValueForbidden, Message: message}
+}
+
+func duplicateField(field, message string) error {
+  return &FieldError{Field: field, Type: metav1.CauseTypeFieldValueDuplicate, Message: message}
+}
+
+func notSupportedField(field, message string) error {
+  return &FieldError{Field: field, Type: metav1.CauseTypeFieldValueNotSupported, Message: message}
+}
+
+//createStatusFromError converts the reason of a denial into an API Status
+//FieldErrors are reported as Invalid objects with the offending field listed in the causes, everything else is reported as a BadRequest
+func createStatusFromError(reviewRequest admissionv1.AdmissionReview, err error) *metav1.Status {
+  status := &metav1.Status {
+    Status: metav1.StatusFailure,
+    Message: err.Error(),
+    Reason: metav1.StatusReasonBadRequest,
+    Code: http.StatusBadRequest,
+  }
+  if reviewRequest.Request != nil {
+    status.Details = &metav1.StatusDetails{Name: reviewRequest.Request.Name, Group: reviewRequest.Request.Kind.Group, Kind: reviewRequest.Request.Kind.Kind}
+  }
+  if fieldError, isFieldError := err.(*FieldError); isFieldError {
+    status.Reason = metav1.StatusReasonInvalid
+    status.Code = http.StatusUnprocessableEntity
+    if status.Details == nil {
+      status.Details = &metav1.StatusDetails{}
+    }
+    status.Details.Causes = []metav1.StatusCause{metav1.StatusCause{Type: fieldError.Type, Message: fieldError.Message, Field: fieldError.Field}}
+  }
+  return status
+}
@@ -7,7 +7,8 @@ import (
   "encoding/json"
   "io/ioutil"
   "net/http"
-  "k8s.io/api/admission/v1beta1"
+  admissionv1 "k8s.io/api/admission/v1"
+  admissionv1beta1 "k8s.io/api/admission/v1beta1"
   metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
   "k8s.io/apimachinery/pkg/runtime"
   "k8s.io/apimachinery/pkg/runtime/serializer"
@@ -20,9 +21,11 @@ type Patch struct {
   Value interface{}     `json:"value,omitempty"`
 }
 
-func DecodeAdmissionReview(httpRequest *http.Request) (v1beta1.AdmissionReview,error) {
+//DecodeAdmissionReview decodes both admission.k8s.io/v1 and admission.k8s.io/v1beta1 AdmissionReviews into the v1 structure
+//The two versions are field-compatible, the original apiVersion is kept in the TypeMeta of the returned object
+func DecodeAdmissionReview(httpRequest *http.Request) (admissionv1.AdmissionReview,error) {
   var payload []byte
-  reviewRequest := v1beta1.AdmissionReview{}
+  reviewRequest := admissionv1.AdmissionReview{}
   if httpRequest.Body == nil {
     return reviewRequest, errors.New("Received review request is empty!")
   }
@@ -33,27 +36,51 @@ func DecodeAdmissionReview(httpRequest *http.Request) (v1beta1.AdmissionReview,e
   codecs := serializer.NewCodecFactory(runtime.NewScheme())
   deserializer := codecs.UniversalDeserializer()
   _, _, err = deserializer.Decode(payload, nil, &reviewRequest)
-  return reviewRequest, err
+  if err != nil {
+    return reviewRequest, err
+  }
+  if reviewRequest.APIVersion != "" &&
+     reviewRequest.APIVersion != admissionv1.SchemeGroupVersion.String() &&
+     reviewRequest.APIVersion != admissionv1beta1.SchemeGroupVersion.String() {
+    return reviewRequest, errors.New("Received review request has unsupported apiVersion:" + reviewRequest.APIVersion)
+  }
+  if reviewRequest.Request == nil {
+    return reviewRequest, errors.New("Received review request does not contain an AdmissionRequest!")
+  }
+  return reviewRequest, nil
 }
 
-func SendErroneousAdmissionResponse(responseWriter http.ResponseWriter, request *v1beta1.AdmissionRequest, err error) {
+func SendErroneousAdmissionResponse(responseWriter http.ResponseWriter, reviewRequest admissionv1.AdmissionReview, err error) {
   log.Println("ERROR: Admitting resource failed with error:" + err.Error())
-  failedResponse := &v1beta1.AdmissionResponse {
-    Result: &metav1.Status {
-      Message: err.Error(),
-    },
+  failedResponse := &admissionv1.AdmissionResponse {
+    Result: createStatusFromError(reviewRequest, err),
     Allowed: false,
   }
-  if request != nil {
-    failedResponse.UID = request.UID
-  }
-  responseAdmissionReview := v1beta1.AdmissionReview {
-    Response: failedResponse,
-  }
-  SendAdmissionResponse(responseWriter, responseAdmissionReview)
+  SendAdmissionResponse(responseWriter, reviewRequest, failedResponse)
 }
 
-func SendAdmissionResponse(responseWriter http.ResponseWriter, reviewResponse v1beta1.AdmissionReview) {
+//SendAdmissionResponse answers a review request in the same admission API version it arrived in
+//Requests without an explicit apiVersion are answered with admission.k8s.io/v1
+func SendAdmissionResponse(responseWriter http.ResponseWriter, reviewRequest admissionv1.AdmissionReview, response *admissionv1.AdmissionResponse) {
+  kind, operation, outcome := "", "", "denied"
+  if reviewRequest.Request != nil {
+    response.UID = reviewRequest.Request.UID
+    kind, operation = reviewRequest.Request.Kind.Kind, string(reviewRequest.Request.Operation)
+  }
+  if response.Allowed {
+    outcome = "allowed"
+  }
+  reviewCounter.inc(kind, operation, outcome)
+  reviewResponse := admissionv1.AdmissionReview {
+    TypeMeta: metav1.TypeMeta {
+      APIVersion: admissionv1.SchemeGroupVersion.String(),
+      Kind: "AdmissionReview",
+    },
+    Response: response,
+  }
+  if reviewRequest.APIVersion == admissionv1beta1.SchemeGroupVersion.String() {
+    reviewResponse.APIVersion = reviewRequest.APIVersion
+  }
   respBytes, err := json.Marshal(reviewResponse)
   if err != nil {
     log.Println("ERROR: Failed to send AdmissionResponse for request:" + string(reviewResponse.Response.UID) + " because JSON marshalling failed with error:" + err.Error())
@@ -65,8 +92,8 @@ func SendAdmissionResponse(responseWriter http.ResponseWriter, reviewResponse v1
   }
 }
 
-func CreateReviewResponseFromPatches(patchList []Patch) *v1beta1.AdmissionResponse {
-  reviewResponse := v1beta1.AdmissionResponse{Allowed: true}
+func CreateReviewResponseFromPatches(patchList []Patch) *admissionv1.AdmissionResponse {
+  reviewResponse := admissionv1.AdmissionResponse{Allowed: true}
   var patches []byte
   var err error
   if len(patchList) > 0 {
@@ -79,21 +106,12 @@ func CreateReviewResponseFromPatches(patchList []Patch) *v1beta1.AdmissionRespon
   }
   if len(patches) > 0 {
     reviewResponse.Patch = patches
-    pt := v1beta1.PatchTypeJSONPatch
+    pt := admissionv1.PatchTypeJSONPatch
     reviewResponse.PatchType = &pt
   }
   return &reviewResponse
 }
 
-func CreateGenericPatchFromChange(path string, value interface{}) Patch {
-  patch := Patch {
-    Op:    "replace",
-    Path:  path,
-    Value: value,
-  }
-  return patch
-}
-
 func IsTypeDynamic(cniType string) bool {
   neType := strings.ToLower(cniType)
   if _, ok := cnidel.SupportedNativeCnis[neType]; ok || neType == "" || neType == "ipvlan" {
@@ -1,26 +1,82 @@
 package admit
 
 import (
+  "bytes"
+  "context"
   "errors"
   "net"
+  "sort"
   "strconv"
-  admissionv1 "k8s.io/api/admission/v1beta1"
+  "strings"
+  admissionv1 "k8s.io/api/admission/v1"
   danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
   danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
+  "github.com/nokia/danm/pkg/bitarray"
+  "github.com/nokia/danm/pkg/confman"
   "github.com/nokia/danm/pkg/datastructs"
   "github.com/nokia/danm/pkg/danmep"
   "github.com/nokia/danm/pkg/ipam"
+  "github.com/nokia/danm/pkg/netcontrol"
+  metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
   "k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
 )
 
 const (
   MaxNidLength = 10
+  MaxIfaceNameLength = 15
+  //The default, main, and local tables are reserved by the kernel
+  MaxVrfTableId = 252
+  MaxPort = 65535
+  MaxTtl = 255
+  MaxTos = 255
+  //Networks annotated with this key set to "true" are allowed to overlap with other networks of their L2 domain
+  AllowCidrOverlapAnnotation = "danm.k8s.io/allow-cidr-overlap"
+  //TenantNetworks are annotated with the name of the TenantConfig their VNI, and subnet were reserved from during admission
+  TenantConfigAnnotation = "danm.k8s.io/tenantconfig"
+)
+
+//JSON paths of the validated fields, reported in the causes of denials
+const (
+  nidField = "spec.NetworkID"
+  tenantConfigAnnotationField = "metadata.annotations." + TenantConfigAnnotation
+  carvedSubnetAnnotationField = "metadata.annotations." + confman.CarvedSubnetAnnotation
+  allowedTenantsField = "spec.AllowedTenants"
+  cidrField = "spec.Options.cidr"
+  routesField = "spec.Options.routes"
+  allocField = "spec.Options.alloc"
+  poolField = "spec.Options.allocation_pool"
+  net6Field = "spec.Options.net6"
+  routes6Field = "spec.Options.routes6"
+  alloc6Field = "spec.Options.alloc6"
+  pool6Field = "spec.Options.allocation_pool_v6"
+  pool6CidrField = "spec.Options.allocation_pool_v6.cidr"
+  deviceField = "spec.Options.host_device"
+  devicePoolField = "spec.Options.device_pool"
+  vlanField = "spec.Options.vlan"
+  vxlanField = "spec.Options.vxlan"
+  vxlanModeField = "spec.Options.vxlan_mode"
+  vxlanPortField = "spec.Options.vxlan_port"
+  vxlanTtlField = "spec.Options.vxlan_ttl"
+  vxlanTosField = "spec.Options.vxlan_tos"
+  vxlanSourceCidrField = "spec.Options.vxlan_source_cidr"
+  vxlanGroupField = "spec.Options.vxlan_group"
+  vrfField = "spec.Options.vrf"
+  rtTablesField = "spec.Options.rt_tables"
+  ipv6ModeField = "spec.Options.ipv6_mode"
+  hostDevicesField = "hostDevices"
+  networkIdsField = "networkIds"
+  namespaceSelectorField = "namespaceSelector"
+  namespacesField = "namespaces"
+  quotaField = "quota"
+  placementPolicyField = "placementPolicy"
+  supernetsField = "supernets"
+  podInterfacesField = "metadata.annotations[danm.k8s.io/interfaces]"
 )
 
 var (
-  DanmNetMapping = []ValidatorFunc{validateIpv4Fields,validateIpv6Fields,validateAllocationPools,validateVids,validateNetworkId,validateAbsenceOfAllowedTenants,validateNeType,validateVniChange}
-  ClusterNetMapping = []ValidatorFunc{validateIpv4Fields,validateIpv6Fields,validateAllocationPools,validateVids,validateNetworkId,validateNeType,validateVniChange}
-  TenantNetMapping = []ValidatorFunc{validateIpv4Fields,validateIpv6Fields,validateAllocationPools,validateAbsenceOfAllowedTenants,validateTenantNetRules,validateNeType}
+  DanmNetMapping = []ValidatorFunc{validateIpv4Fields,validateIpv6Fields,validateAllocationPools,validateAllocationChange,validateVids,validateNetworkId,validateAbsenceOfAllowedTenants,validateNeType,validateVniChange,validateVxlanParams,validateVrf,validateIpv6Mode,validateCidrOverlap,validateVniUniqueness}
+  ClusterNetMapping = []ValidatorFunc{validateIpv4Fields,validateIpv6Fields,validateAllocationPools,validateAllocationChange,validateVids,validateNetworkId,validateNeType,validateVniChange,validateVxlanParams,validateVrf,validateIpv6Mode,validateCidrOverlap,validateVniUniqueness}
+  TenantNetMapping = []ValidatorFunc{validateIpv4Fields,validateIpv6Fields,validateAllocationPools,validateAllocationChange,validateAbsenceOfAllowedTenants,validateTenantNetRules,validateNeType,validateVxlanParams,validateVrf,validateIpv6Mode}
   danmValidationConfig = map[string]ValidatorMapping {
     "DanmNet": DanmNetMapping,
     "ClusterNetwork": ClusterNetMapping,
@@ -32,36 +88,38 @@ type ValidatorFunc func(oldManifest, newManifest *danmtypes.DanmNet, opType admi
 type ValidatorMapping []ValidatorFunc
 
 func validateIpv4Fields(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
-  return validateIpFields(newManifest.Spec.Options.Cidr, newManifest.Spec.Options.Routes)
+  return validateIpFields(newManifest.Spec.Options.Cidr, newManifest.Spec.Options.Routes, cidrField, routesField)
 }
 
 func validateIpv6Fields(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
-  return validateIpFields(newManifest.Spec.Options.Net6, newManifest.Spec.Options.Routes6)
+  return validateIpFields(newManifest.Spec.Options.Net6, newManifest.Spec.Options.Routes6, net6Field, routes6Field)
 }
 
-func validateIpFields(cidr string, routes map[string]string) error {
+func validateIpFields(cidr string, routes map[string]string, cidrField, routesField string) error {
   if cidr == "" {
     if routes != nil  {
-      return errors.New("IP routes cannot be defined for a L2 network")
+      return forbiddenField(routesField, "IP routes cannot be defined for a L2 network")
     }
     return nil
   }
   _, ipnet, err := net.ParseCIDR(cidr)
   if err != nil {
-    return errors.New("Invalid CIDR: " + cidr)
+    return invalidField(cidrField, "Invalid CIDR: " + cidr)
   }
   for _, gw := range routes {
     if !ipnet.Contains(net.ParseIP(gw)) {
-      return errors.New("Specified GW address:" + gw + " is not part of CIDR:" + cidr)
+      return invalidField(routesField, "Specified GW address:" + gw + " is not part of CIDR:" + cidr)
     }
   }
   return nil
 }
 
 func validateAllocationPools(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
-  if opType == admissionv1.Create &&
-     (newManifest.Spec.Options.Alloc != "" || newManifest.Spec.Options.Alloc6 != "") {
-    return errors.New("Allocation bitmasks shall not be manually defined upon creation!")
+  if opType == admissionv1.Create && newManifest.Spec.Options.Alloc != "" {
+    return forbiddenField(allocField, "Allocation bitmasks shall not be manually defined upon creation!")
+  }
+  if opType == admissionv1.Create && newManifest.Spec.Options.Alloc6 != "" {
+    return forbiddenField(alloc6Field, "Allocation bitmasks shall not be manually defined upon creation!")
   }
   err := validateAllocV4(newManifest)
   if err != nil {
@@ -78,25 +136,25 @@ func validateAllocV4(newManifest *danmtypes.DanmNet) error {
   cidrV4 := newManifest.Spec.Options.Cidr
   if cidrV4 == "" {
     if newManifest.Spec.Options.Pool.Start != "" || newManifest.Spec.Options.Pool.End != "" {
-      return errors.New("V4 Allocation pool cannot be defined without CIDR!")
+      return forbiddenField(poolField, "V4 Allocation pool cannot be defined without CIDR!")
     }
     return nil
   }
   _, ipnet, _ := net.ParseCIDR(cidrV4)
   if ipnet.IP.To4() == nil {
-    return errors.New("Options.CIDR is not a valid V4 subnet!")
+    return invalidField(cidrField, "Options.CIDR is not a valid V4 subnet!")
   }
   netMaskSize, _ := ipnet.Mask.Size()
   if netMaskSize < datastructs.MaxV4MaskLength {
-    return errors.New("Netmask of the IPv4 CIDR is bigger than the maximum allowed /"+ strconv.Itoa(datastructs.MaxV4MaskLength))
+    return invalidField(cidrField, "Netmask of the IPv4 CIDR is bigger than the maximum allowed /"+ strconv.Itoa(datastructs.MaxV4MaskLength))
   }
   newManifest.Spec.Options.Pool.Start, newManifest.Spec.Options.Pool.End, newManifest.Spec.Options.Alloc =
     ipam.InitAllocPool(newManifest.Spec.Options.Cidr, newManifest.Spec.Options.Pool.Start, newManifest.Spec.Options.Pool.End, newManifest.Spec.Options.Alloc, newManifest.Spec.Options.Routes)
   if !ipnet.Contains(net.ParseIP(newManifest.Spec.Options.Pool.Start)) || !ipnet.Contains(net.ParseIP(newManifest.Spec.Options.Pool.End)) {
-    return errors.New("Allocation pool is outside of defined CIDR!")
+    return invalidField(poolField, "Allocation pool is outside of defined CIDR!")
   }
   if ipam.Ip2int(net.ParseIP(newManifest.Spec.Options.Pool.End)) <= ipam.Ip2int(net.ParseIP(newManifest.Spec.Options.Pool.Start)) {
-    return errors.New("Allocation pool start:" + newManifest.Spec.Options.Pool.Start + " is bigger than or equal to allocation pool end:" + newManifest.Spec.Options.Pool.End)
+    return invalidField(poolField, "Allocation pool start:" + newManifest.Spec.Options.Pool.Start + " is bigger than or equal to allocation pool end:" + newManifest.Spec.Options.Pool.End)
   }
   return nil
 }
@@ -107,13 +165,13 @@ func validateAllocV6(newManifest *danmtypes.DanmNet) error {
     if newManifest.Spec.Options.Pool6.Start != "" ||
        newManifest.Spec.Options.Pool6.End   != "" ||
        newManifest.Spec.Options.Pool6.Cidr  != "" {
-      return errors.New("IPv6 allocation pool cannot be defined without Net6!")
+      return forbiddenField(pool6Field, "IPv6 allocation pool cannot be defined without Net6!")
     }
     return nil
   }
   _, netCidr, _ := net.ParseCIDR(net6)
   if netCidr.IP.To4() != nil {
-    return errors.New("spec.Options.Net6 is not a valid V6 subnet!")
+    return invalidField(net6Field, "spec.Options.Net6 is not a valid V6 subnet!")
   }
   // The limit of the current storage algorithm and etcd 3.4.X is ~8M addresses per network.
   // This means that the summarized size of the IPv4, and IPv6 allocation pools shall not go over this threshold.
@@ -122,129 +180,463 @@ func validateAllocV6(newManifest *danmtypes.DanmNet) error {
   ipam.InitV6PoolCidr(newManifest)
   _, allocCidr, err := net.ParseCIDR(newManifest.Spec.Options.Pool6.Cidr)
   if err != nil {
-    return errors.New("spec.Options.Pool6.CIDR is invalid!")
+    return invalidField(pool6CidrField, "spec.Options.Pool6.CIDR is invalid!")
   }
   if allocCidr.IP.To4() != nil {
-    return errors.New("spec.Options.Allocation_Pool_V6.Cidr is not a valid V6 subnet!")
+    return invalidField(pool6CidrField, "spec.Options.Allocation_Pool_V6.Cidr is not a valid V6 subnet!")
   }
   netMaskSize, _ := allocCidr.Mask.Size()
   // We don't have enough storage space left for storing IPv6 allocations
   if netMaskSize < maxV6AllocPrefix || netMaskSize == datastructs.MinV6PrefixLength {
-    return errors.New("The defined IPv6 allocation pool exceeds the maximum - 8M-size(IPv4 allocation pool) - storage capacity!")
+    return invalidField(pool6CidrField, "The defined IPv6 allocation pool exceeds the maximum - 8M-size(IPv4 allocation pool) - storage capacity!")
   }
   if (newManifest.Spec.Options.Pool6.Start != "" && !allocCidr.Contains(net.ParseIP(newManifest.Spec.Options.Pool6.Start))) ||
      (newManifest.Spec.Options.Pool6.End   != "" && !allocCidr.Contains(net.ParseIP(newManifest.Spec.Options.Pool6.End)))   ||
      (!ipam.DoV6CidrsIntersect(netCidr, allocCidr)) {
-    return errors.New("IPv6 allocation pool is outside of the defined IPv6 subnet!")
+    return invalidField(pool6Field, "IPv6 allocation pool is outside of the defined IPv6 subnet!")
   }
   newManifest.Spec.Options.Pool6.Start, newManifest.Spec.Options.Pool6.End, newManifest.Spec.Options.Alloc6 =
     ipam.InitAllocPool(newManifest.Spec.Options.Pool6.Cidr, newManifest.Spec.Options.Pool6.Start, newManifest.Spec.Options.Pool6.End, newManifest.Spec.Options.Alloc6, newManifest.Spec.Options.Routes6)
   if ipam.Ip62int(net.ParseIP(newManifest.Spec.Options.Pool6.End)).Cmp(ipam.Ip62int(net.ParseIP(newManifest.Spec.Options.Pool6.Start))) <=0 {
-    return errors.New("Allocation pool start:" + newManifest.Spec.Options.Pool6.Start + " is bigger than or equal to allocation pool end:" + newManifest.Spec.Options.Pool6.End)
+    return invalidField(pool6Field, "Allocation pool start:" + newManifest.Spec.Options.Pool6.Start + " is bigger than or equal to allocation pool end:" + newManifest.Spec.Options.Pool6.End)
   }
   return nil
 }
 
+//Changing the CIDRs, or allocation pools of a network is only allowed if all its existing allocations fit into the new ranges
+//Allocation bitarrays are re-mapped to the new CIDRs, so already reserved IPs stay reserved
+func validateAllocationChange(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
+  if opType != admissionv1.Update {
+    return nil
+  }
+  oldOpts := oldManifest.Spec.Options
+  newOpts := &newManifest.Spec.Options
+  var err error
+  newOpts.Alloc, err = remapAllocation(oldOpts.Alloc, newOpts.Alloc, oldOpts.Cidr, newOpts.Cidr, oldOpts.Routes, newOpts.Routes)
+  if err != nil {
+    return invalidField(cidrField, "IPv4 subnet cannot be changed, because " + err.Error())
+  }
+  newOpts.Alloc6, err = remapAllocation(oldOpts.Alloc6, newOpts.Alloc6, oldOpts.Pool6.Cidr, newOpts.Pool6.Cidr, oldOpts.Routes6, newOpts.Routes6)
+  if err != nil {
+    return invalidField(pool6CidrField, "IPv6 allocation CIDR cannot be changed, because " + err.Error())
+  }
+  //Re-mapped bitarrays always fit their CIDRs, but bitarrays kept as-is might have been manually changed
+  if newOpts.Alloc != oldOpts.Alloc && !isAllocSizeValid(newOpts.Alloc, newOpts.Cidr) {
+    return invalidField(allocField, "IPv4 allocation bitarray does not match the size of CIDR:" + newOpts.Cidr)
+  }
+  if newOpts.Alloc6 != oldOpts.Alloc6 && !isAllocSizeValid(newOpts.Alloc6, newOpts.Pool6.Cidr) {
+    return invalidField(alloc6Field, "IPv6 allocation bitarray does not match the size of allocation CIDR:" + newOpts.Pool6.Cidr)
+  }
+  err = validatePoolChange(oldOpts.Alloc, oldOpts.Cidr, oldOpts.Routes, oldOpts.Pool, &newOpts.Pool)
+  if err != nil {
+    return invalidField(poolField, "IPv4 allocation pool cannot be changed, because " + err.Error())
+  }
+  err = validatePoolChange(oldOpts.Alloc6, oldOpts.Pool6.Cidr, oldOpts.Routes6, oldOpts.Pool6.IpPool, &newOpts.Pool6.IpPool)
+  if err != nil {
+    return invalidField(pool6Field, "IPv6 allocation pool cannot be changed, because " + err.Error())
+  }
+  if (oldOpts.Cidr == "" || oldOpts.Cidr == newOpts.Cidr) && (oldOpts.Net6 == "" || oldOpts.Net6 == newOpts.Net6) {
+    return nil
+  }
+  eps, err := danmep.FindByNetwork(client, oldManifest)
+  if err != nil {
+    return errors.New("subnets cannot be changed, because connected Pods cannot be listed:" + err.Error())
+  }
+  for _, ep := range eps {
+    if !isEpAddressKept(ep.Spec.Iface.Address, oldOpts.Cidr, newOpts.Cidr) {
+      return invalidField(cidrField, "subnets cannot be changed, because an address of DanmEp:" + ep.ObjectMeta.Name + " would fall outside the new subnets")
+    }
+    if !isEpAddressKept(ep.Spec.Iface.AddressIPv6, oldOpts.Net6, newOpts.Net6) {
+      return invalidField(net6Field, "subnets cannot be changed, because an address of DanmEp:" + ep.ObjectMeta.Name + " would fall outside the new subnets")
+    }
+  }
+  return nil
+}
+
+//isAllocSizeValid checks if an allocation bitarray holds exactly one bit for every address of its CIDR, rounded up to whole bytes
+func isAllocSizeValid(alloc, allocCidr string) bool {
+  if allocCidr == "" {
+    return alloc == ""
+  }
+  _, ipnet, err := net.ParseCIDR(allocCidr)
+  if err != nil {
+    return false
+  }
+  maskSize, addressSize := ipnet.Mask.Size()
+  hostBits := addressSize - maskSize
+  if hostBits > bitarray.MaxSupportedAllocLength {
+    return false
+  }
+  expectedLength := ((uint32(1) << uint(hostBits)) + 7) / 8 * 8
+  return bitarray.NewBitArrayFromBase64(alloc).Len() == expectedLength
+}
+
+func remapAllocation(oldAlloc, newAlloc, oldCidr, newCidr string, oldRoutes, newRoutes map[string]string) (string,error) {
+  //Fresh CIDRs were already initialized, and the bitarray of an unchanged CIDR can be still used as-is
+  if oldCidr == "" || oldCidr == newCidr {
+    return newAlloc, nil
+  }
+  if newCidr == "" {
+    if len(ipam.GetAllocatedIps(oldAlloc, oldCidr, oldRoutes)) > 0 {
+      return newAlloc, errors.New("IPs are still allocated from CIDR:" + oldCidr)
+    }
+    return "", nil
+  }
+  return ipam.RemapAllocationArray(oldAlloc, oldCidr, newCidr, oldRoutes, newRoutes)
+}
+
+func validatePoolChange(oldAlloc, oldCidr string, oldRoutes map[string]string, oldPool danmtypes.IpPool, newPool *danmtypes.IpPool) error {
+  if oldPool.Start == "" || oldPool.End == "" || newPool.Start == "" || newPool.End == "" ||
+     (oldPool.Start == newPool.Start && oldPool.End == newPool.End) {
+    return nil
+  }
+  //IPs outside of the old pool were statically allocated, so they can stay outside the new one as well
+  for _, ip := range ipam.GetAllocatedIps(oldAlloc, oldCidr, oldRoutes) {
+    if isIpInPool(ip, oldPool) && !isIpInPool(ip, *newPool) {
+      return errors.New("allocated IP:" + ip.String() + " would fall outside of it")
+    }
+  }
+  if newPool.LastIp != "" && !isIpInPool(net.ParseIP(newPool.LastIp), *newPool) {
+    newPool.LastIp = ""
+  }
+  return nil
+}
+
+func isIpInPool(ip net.IP, pool danmtypes.IpPool) bool {
+  return bytes.Compare(ip.To16(), net.ParseIP(pool.Start).To16()) >= 0 && bytes.Compare(ip.To16(), net.ParseIP(pool.End).To16()) <= 0
+}
+
+func isEpAddressKept(address, oldCidr, newCidr string) bool {
+  ip, _, err := net.ParseCIDR(address)
+  if err != nil || !ipam.WasIpAllocatedByDanm(ip.String(), oldCidr) {
+    return true
+  }
+  return ipam.WasIpAllocatedByDanm(ip.String(), newCidr)
+}
+
 func validateVids(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
   isVlanDefined  := (newManifest.Spec.Options.Vlan !=0)
   isVxlanDefined := (newManifest.Spec.Options.Vxlan!=0)
   if isVlanDefined && isVxlanDefined {
-    return errors.New("VLAN ID and VxLAN ID parameters are mutually exclusive")
+    return forbiddenField(vxlanField, "VLAN ID and VxLAN ID parameters are mutually exclusive")
   }
   return nil
 }
 
 func validateNetworkId(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
   if newManifest.Spec.NetworkID == "" {
-    return errors.New("Spec.NetworkID mandatory parameter is missing!")
+    return requiredField(nidField, "Spec.NetworkID mandatory parameter is missing!")
   }
   if len(newManifest.Spec.NetworkID) > MaxNidLength && IsTypeDynamic(newManifest.Spec.NetworkType) &&
     (newManifest.Spec.Options.Vxlan != 0 || newManifest.Spec.Options.Vlan != 0) {
-    return errors.New("Spec.NetworkID cannot be longer than " + strconv.Itoa(MaxNidLength) + " characters (otherwise VLAN and VxLAN host interface creation might fail)!")
+    return invalidField(nidField, "Spec.NetworkID cannot be longer than " + strconv.Itoa(MaxNidLength) + " characters (otherwise VLAN and VxLAN host interface creation might fail)!")
   }
   return nil
 }
 
 func validateAbsenceOfAllowedTenants(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
   if newManifest.Spec.AllowedTenants != nil {
-    return errors.New("AllowedTenants attribute is only valid for the ClusterNetwork API!")
+    return forbiddenField(allowedTenantsField, "AllowedTenants attribute is only valid for the ClusterNetwork API!")
   }
   return nil
 }
 
 func validateTenantNetRules(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
-  if opType == admissionv1.Create &&
-    (newManifest.Spec.Options.Vxlan  != 0  ||
-     newManifest.Spec.Options.Vlan   != 0) {
-    return errors.New("Manually configuring Spec.Options.vlan, or Spec.Options.vxlan attributes is not allowed for TenantNetworks!")
-  }
-  if opType == admissionv1.Update &&
-    (newManifest.Spec.Options.Device  != oldManifest.Spec.Options.Device  ||
-     newManifest.Spec.Options.DevicePool  != oldManifest.Spec.Options.DevicePool  ||
-     newManifest.Spec.Options.Vxlan   != oldManifest.Spec.Options.Vxlan   ||
-     newManifest.Spec.Options.Vlan    != oldManifest.Spec.Options.Vlan) {
-    return errors.New("Manually changing any one of Spec.Options. host_device, device_pool, vlan, or vxlan attributes is not allowed for TenantNetworks!")
+  if opType == admissionv1.Create && newManifest.Spec.Options.Vlan != 0 {
+    return forbiddenField(vlanField, "Manually configuring Spec.Options.vlan, or Spec.Options.vxlan attributes is not allowed for TenantNetworks!")
+  }
+  if opType == admissionv1.Create && newManifest.Spec.Options.Vxlan != 0 {
+    return forbiddenField(vxlanField, "Manually configuring Spec.Options.vlan, or Spec.Options.vxlan attributes is not allowed for TenantNetworks!")
+  }
+  if opType == admissionv1.Create && newManifest.ObjectMeta.Annotations[TenantConfigAnnotation] != "" {
+    return forbiddenField(tenantConfigAnnotationField, "The owner TenantConfig of a TenantNetwork is recorded by DANM, it cannot be configured manually!")
+  }
+  if opType == admissionv1.Create && newManifest.ObjectMeta.Annotations[confman.CarvedSubnetAnnotation] != "" {
+    return forbiddenField(carvedSubnetAnnotationField, "The subnet carved out of a supernet for a TenantNetwork is recorded by DANM, it cannot be configured manually!")
+  }
+  if opType != admissionv1.Update {
+    return nil
+  }
+  changedField := ""
+  switch {
+  case oldManifest.ObjectMeta.Annotations[TenantConfigAnnotation] != "" && newManifest.ObjectMeta.Annotations[TenantConfigAnnotation] != oldManifest.ObjectMeta.Annotations[TenantConfigAnnotation]:
+    changedField = tenantConfigAnnotationField
+  case newManifest.ObjectMeta.Annotations[confman.CarvedSubnetAnnotation] != oldManifest.ObjectMeta.Annotations[confman.CarvedSubnetAnnotation]:
+    changedField = carvedSubnetAnnotationField
+  case newManifest.Spec.Options.Device != oldManifest.Spec.Options.Device:
+    changedField = deviceField
+  case newManifest.Spec.Options.DevicePool != oldManifest.Spec.Options.DevicePool:
+    changedField = devicePoolField
+  case newManifest.Spec.Options.Vxlan != oldManifest.Spec.Options.Vxlan:
+    changedField = vxlanField
+  case newManifest.Spec.Options.Vlan != oldManifest.Spec.Options.Vlan:
+    changedField = vlanField
+  }
+  if changedField != "" {
+    return forbiddenField(changedField, "Manually changing any one of Spec.Options. host_device, device_pool, vlan, or vxlan attributes, the owner TenantConfig, or the carved subnet is not allowed for TenantNetworks!")
   }
   return nil
 }
 
 func validateTenantconfig(oldManifest, newManifest *danmtypes.TenantConfig, opType admissionv1.Operation) error {
   if len(newManifest.HostDevices) == 0 && len(newManifest.NetworkIds) == 0 {
-    return errors.New("Either hostDevices, or networkIds must be provided!")
+    return requiredField(hostDevicesField, "Either hostDevices, or networkIds must be provided!")
   }
   var err error
-  for _, ifaceConf := range newManifest.HostDevices {
-    err = validateIfaceConfig(ifaceConf, opType)
+  for ifaceIndex, ifaceConf := range newManifest.HostDevices {
+    err = validateIfaceConfig(ifaceConf, hostDevicesField + "[" + strconv.Itoa(ifaceIndex) + "]", opType)
     if err != nil {
       return err
     }
   }
   for nType, nId := range newManifest.NetworkIds {
     if nType == "" || nId == "" {
-      return errors.New("neither NetworkID, nor NetworkType can be empty in a NetworkID mapping!")
+      return invalidField(networkIdsField + "[" + nType + "]", "neither NetworkID, nor NetworkType can be empty in a NetworkID mapping!")
     }
     if len(nId) > MaxNidLength && IsTypeDynamic(nType) {
-      return errors.New("NetworkID:" + nId + " cannot be longer than " + strconv.Itoa(MaxNidLength) + " characters (otherwise VLAN and VxLAN host interface creation might fail)!")
+      return invalidField(networkIdsField + "[" + nType + "]", "NetworkID:" + nId + " cannot be longer than " + strconv.Itoa(MaxNidLength) + " characters (otherwise VLAN and VxLAN host interface creation might fail)!")
+    }
+  }
+  if newManifest.Quota != nil && (newManifest.Quota.MaxNetworks < 0 || newManifest.Quota.MaxVnisPerProfile < 0 || newManifest.Quota.MaxIps < 0) {
+    return invalidField(quotaField, "quota limits cannot be negative, use 0 for unlimited!")
+  }
+  for supernetIndex, supernet := range newManifest.Supernets {
+    err = validateSupernet(supernet, supernetsField + "[" + strconv.Itoa(supernetIndex) + "]", opType)
+    if err != nil {
+      return err
+    }
+  }
+  if opType == admissionv1.Update {
+    err = validateCarvedSupernets(oldManifest, newManifest)
+    if err != nil {
+      return err
+    }
+  }
+  if !isPlacementPolicySupported(newManifest.PlacementPolicy) {
+    return notSupportedField(placementPolicyField, newManifest.PlacementPolicy + " is not in allowed placementPolicy values: {" + strings.Join(supportedPlacementPolicies, ",") + "}")
+  }
+  return nil
+}
+
+//validateNamespaceSelection makes sure every namespace is unambiguously served by one TenantConfig
+//Two TenantConfigs cannot select the same namespace with the same precedence, i.e. by both listing it, by both matching its labels, or by both having no selection at all
+func validateNamespaceSelection(newManifest *danmtypes.TenantConfig, client danmclientset.Interface) error {
+  if newManifest.NamespaceSelector != nil {
+    _, err := metav1.LabelSelectorAsSelector(newManifest.NamespaceSelector)
+    if err != nil {
+      return invalidField(namespaceSelectorField, "namespaceSelector is invalid:" + err.Error())
+    }
+  }
+  for nsIndex, namespace := range newManifest.Namespaces {
+    if namespace == "" {
+      return requiredField(namespacesField + "[" + strconv.Itoa(nsIndex) + "]", "namespaces cannot contain empty names!")
+    }
+  }
+  tconfs, err := client.DanmV1().TenantConfigs().List(context.TODO(), metav1.ListOptions{})
+  if err != nil {
+    return errors.New("cannot list TenantConfigs to check the overlaps of their namespace selection, because:" + err.Error())
+  }
+  if tconfs == nil {
+    return nil
+  }
+  for _, tconf := range tconfs.Items {
+    if tconf.ObjectMeta.Name == newManifest.ObjectMeta.Name {
+      continue
+    }
+    overlapMatch, overlap := confman.GetOverlappingSelection(newManifest, &tconf)
+    if overlapMatch == confman.NoMatch {
+      continue
+    }
+    field := namespaceSelectorField
+    if overlapMatch == confman.NamespaceListMatch {
+      field = namespacesField
+    }
+    return duplicateField(field, "namespace selection overlaps with TenantConfig:" + tconf.ObjectMeta.Name + " in its " + overlap + ", so the TenantConfig of the affected namespaces would be ambiguous")
+  }
+  return nil
+}
+
+func validateSupernet(supernet danmtypes.TenantSupernet, supernetField string, opType admissionv1.Operation) error {
+  _, ipnet, err := net.ParseCIDR(supernet.Cidr)
+  if err != nil || ipnet.IP.To4() == nil {
+    return invalidField(supernetField + ".cidr", "supernet:" + supernet.Cidr + " is not a valid IPv4 CIDR!")
+  }
+  _, err = confman.GetNumberOfSubnets(supernet)
+  if err != nil {
+    return invalidField(supernetField + ".prefixLength", err.Error())
+  }
+  if opType == admissionv1.Create && supernet.Alloc != "" {
+    return forbiddenField(supernetField + ".alloc", "Allocation bitmask for supernet:" + supernet.Cidr + " shall not be manually defined upon creation!")
+  }
+  return nil
+}
+
+//Subnets already carved out of a supernet would be lost, if the supernet was removed, or divided differently
+func validateCarvedSupernets(oldManifest, newManifest *danmtypes.TenantConfig) error {
+  for _, oldSupernet := range oldManifest.Supernets {
+    carvedSubnets := confman.GetNumberOfCarvedSubnets(oldSupernet)
+    if carvedSubnets == 0 {
+      continue
+    }
+    var isSupernetKept bool
+    for _, newSupernet := range newManifest.Supernets {
+      if newSupernet.Cidr == oldSupernet.Cidr && newSupernet.PrefixLength == oldSupernet.PrefixLength {
+        isSupernetKept = true
+      }
+    }
+    if !isSupernetKept {
+      return forbiddenField(supernetsField, "supernet:" + oldSupernet.Cidr + " cannot be removed, or divided differently while " + strconv.Itoa(carvedSubnets) + " subnets are carved out of it!")
     }
   }
   return nil
 }
 
-func validateIfaceConfig(ifaceConf danmtypes.IfaceProfile, opType admissionv1.Operation) error {
+//VNIs reserved in an interface profile, and used by existing TenantNetworks cannot be removed from the TenantConfig, neither by removing the profile, nor by shrinking its vniRange
+//The allocation of every kept profile is carried over, so already reserved VNIs stay reserved whatever happens to the range
+//Only the Webhook itself is allowed to free VNIs used by existing TenantNetworks, as it does so right before the TenantNetwork is deleted
+func validateHostDeviceChange(oldManifest, newManifest *danmtypes.TenantConfig, opType admissionv1.Operation, client danmclientset.Interface, isWebhookUser bool) error {
+  if opType != admissionv1.Update {
+    return nil
+  }
+  var tnets []danmtypes.TenantNetwork
+  for _, oldIface := range oldManifest.HostDevices {
+    if oldIface.VniType == "" || oldIface.Alloc == "" {
+      continue
+    }
+    if tnets == nil {
+      tnetList, err := client.DanmV1().TenantNetworks("").List(context.TODO(), metav1.ListOptions{})
+      if err != nil {
+        return errors.New("cannot list TenantNetworks to check the VNIs used from interface profile:" + oldIface.Name + ", because:" + err.Error())
+      }
+      tnets = append([]danmtypes.TenantNetwork{}, tnetList.Items...)
+    }
+    usedVnis := getUsedVnisOfProfile(oldIface, tnets)
+    newIndex := -1
+    for ifaceIndex, newIface := range newManifest.HostDevices {
+      if newIface.Name == oldIface.Name && newIface.VniType == oldIface.VniType {
+        newIndex = ifaceIndex
+        break
+      }
+    }
+    if newIndex == -1 {
+      if len(usedVnis) > 0 {
+        vni := getSortedVnis(usedVnis)[0]
+        return forbiddenField(hostDevicesField, "interface profile:" + oldIface.Name + " with vniType:" + oldIface.VniType + " cannot be removed while its VNI:" + strconv.Itoa(vni) + " is used by TenantNetwork:" + usedVnis[vni])
+      }
+      continue
+    }
+    ifaceField := hostDevicesField + "[" + strconv.Itoa(newIndex) + "]"
+    vniSet, err := cpuset.Parse(newManifest.HostDevices[newIndex].VniRange)
+    if err != nil {
+      return invalidField(ifaceField + ".vniRange", "vniRange for interface:" + oldIface.Name + " must be improperly formatted because its parsing fails with:" + err.Error())
+    }
+    for _, vni := range getSortedVnis(usedVnis) {
+      if !vniSet.Contains(vni) {
+        return forbiddenField(ifaceField + ".vniRange", "VNI:" + strconv.Itoa(vni) + " cannot be removed from the vniRange of interface:" + oldIface.Name + " while it is used by TenantNetwork:" + usedVnis[vni])
+      }
+    }
+    var protectedVnis map[int]string
+    if !isWebhookUser {
+      protectedVnis = usedVnis
+    }
+    newManifest.HostDevices[newIndex].Alloc = carryOverVniAlloc(oldIface.Alloc, newManifest.HostDevices[newIndex].Alloc, vniSet, protectedVnis)
+  }
+  return nil
+}
+
+//getUsedVnisOfProfile returns the VNIs reserved in an interface profile, which are used by TenantNetworks, together with the name of one of the TenantNetworks using them
+func getUsedVnisOfProfile(iface danmtypes.IfaceProfile, tnets []danmtypes.TenantNetwork) map[int]string {
+  usedVnis := make(map[int]string)
+  allocs := bitarray.NewBitArrayFromBase64(iface.Alloc)
+  for _, tnet := range tnets {
+    if tnet.Spec.Options.Device != iface.Name && tnet.Spec.Options.DevicePool != iface.Name {
+      continue
+    }
+    vni := tnet.Spec.Options.Vlan
+    if iface.VniType == "vxlan" {
+      vni = tnet.Spec.Options.Vxlan
+    }
+    if vni == 0 || uint32(vni) >= allocs.Len() || !allocs.Get(uint32(vni)) {
+      continue
+    }
+    usedVnis[vni] = tnet.ObjectMeta.Namespace + "/" + tnet.ObjectMeta.Name
+  }
+  return usedVnis
+}
+
+func getSortedVnis(usedVnis map[int]string) []int {
+  vnis := make([]int, 0, len(usedVnis))
+  for vni := range usedVnis {
+    vnis = append(vnis, vni)
+  }
+  sort.Ints(vnis)
+  return vnis
+}
+
+//carryOverVniAlloc keeps the existing allocation of a profile when the update does not contain it, and frees the reservations falling outside of the new vniRange
+//Reservations outside of the range cannot belong to existing TenantNetworks at this point, but would block the VNIs if the range was extended again
+//Protected VNIs stay reserved even if the allocation provided in the update freed them
+func carryOverVniAlloc(oldAlloc, newAlloc string, vniSet cpuset.CPUSet, protectedVnis map[int]string) string {
+  if newAlloc == "" {
+    newAlloc = oldAlloc
+  }
+  allocs := bitarray.NewBitArrayFromBase64(newAlloc)
+  for vni := range protectedVnis {
+    if uint32(vni) < allocs.Len() {
+      allocs.Set(uint32(vni))
+    }
+  }
+  for vni := uint32(1); vni < allocs.Len(); vni++ {
+    if allocs.Get(vni) && !vniSet.Contains(int(vni)) {
+      allocs.Reset(vni)
+    }
+  }
+  return allocs.Encode()
+}
+
+func validateIfaceConfig(ifaceConf danmtypes.IfaceProfile, ifaceField string, opType admissionv1.Operation) error {
   if ifaceConf.Name == "" {
-    return errors.New("name attribute of a hostDevice must not be empty!")
+    return requiredField(ifaceField + ".name", "name attribute of a hostDevice must not be empty!")
   }
-  if (ifaceConf.VniType == "" && ifaceConf.VniRange != "") ||
-     (ifaceConf.VniRange == "" && ifaceConf.VniType != "") {
-    return errors.New("vniRange and vniType attributes must be provided together for interface:" + ifaceConf.Name)
+  if ifaceConf.VniType == "" && ifaceConf.VniRange != "" {
+    return requiredField(ifaceField + ".vniType", "vniRange and vniType attributes must be provided together for interface:" + ifaceConf.Name)
+  }
+  if ifaceConf.VniRange == "" && ifaceConf.VniType != "" {
+    return requiredField(ifaceField + ".vniRange", "vniRange and vniType attributes must be provided together for interface:" + ifaceConf.Name)
   }
   if ifaceConf.VniType != "" && ifaceConf.VniType != "vlan" && ifaceConf.VniType != "vxlan" {
-    return errors.New(ifaceConf.VniType + " is not in allowed vniType values: {vlan,vxlan} for interface:" + ifaceConf.Name)
+    return notSupportedField(ifaceField + ".vniType", ifaceConf.VniType + " is not in allowed vniType values: {vlan,vxlan} for interface:" + ifaceConf.Name)
   }
   if opType == admissionv1.Create && ifaceConf.Alloc != "" {
-    return errors.New("Allocation bitmask for interface: " + ifaceConf.Name + " shall not be manually defined upon creation!")
+    return forbiddenField(ifaceField + ".alloc", "Allocation bitmask for interface: " + ifaceConf.Name + " shall not be manually defined upon creation!")
+  }
+  if ifaceConf.Weight < 0 {
+    return invalidField(ifaceField + ".weight", "weight of interface:" + ifaceConf.Name + " cannot be negative!")
   }
   //I know this type is for CPU sets, but isn't it just perfect for handling arbitrarily defined integer ranges?
   vniSet, err := cpuset.Parse(ifaceConf.VniRange)
   if err != nil {
-    return errors.New("vniRange for interface:" + ifaceConf.Name + " must be improperly formatted because its parsing fails with:" + err.Error())
+    return invalidField(ifaceField + ".vniRange", "vniRange for interface:" + ifaceConf.Name + " must be improperly formatted because its parsing fails with:" + err.Error())
   }
   filteredSet := vniSet.Filter(func(vni int) bool {
     return vni > MaxAllowedVni
   })
   if filteredSet.Size() > 0 {
-    return errors.New("vniRange for interface:" + ifaceConf.Name + " is invalid, because it cannot contain VNIs over the maximum supported number that is:" + strconv.Itoa(MaxAllowedVni))
+    return invalidField(ifaceField + ".vniRange", "vniRange for interface:" + ifaceConf.Name + " is invalid, because it cannot contain VNIs over the maximum supported number that is:" + strconv.Itoa(MaxAllowedVni))
   }
   return nil
 }
 
 func validateNeType(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
   if newManifest.Spec.NetworkType == "sriov" {
-    if newManifest.Spec.Options.DevicePool == "" || newManifest.Spec.Options.Device != "" {
-      return errors.New("Spec.Options.device_pool must, and Spec.Options.host_device cannot be provided for SR-IOV networks!")
+    if newManifest.Spec.Options.DevicePool == "" {
+      return requiredField(devicePoolField, "Spec.Options.device_pool must, and Spec.Options.host_device cannot be provided for SR-IOV networks!")
+    }
+    if newManifest.Spec.Options.Device != "" {
+      return forbiddenField(deviceField, "Spec.Options.device_pool must, and Spec.Options.host_device cannot be provided for SR-IOV networks!")
     }
   } else if newManifest.Spec.Options.Device != "" && newManifest.Spec.Options.DevicePool != "" {
-    return errors.New("Spec.Options.device_pool and Spec.Options.host_device cannot be provided together!")
+    return forbiddenField(devicePoolField, "Spec.Options.device_pool and Spec.Options.host_device cannot be provided together!")
   }
   return nil
 }
@@ -260,9 +652,203 @@ func validateVniChange(oldManifest, newManifest *danmtypes.DanmNet, opType admis
   if !isAnyPodConnectedToNetwork {
     return nil
   }
+  changedField := deviceField
+  if oldManifest.Spec.Options.Device == newManifest.Spec.Options.Device {
+    changedField = vlanField
+    if oldManifest.Spec.Options.Vxlan != 0 {
+      changedField = vxlanField
+    }
+  }
   if (oldManifest.Spec.Options.Vlan  != 0 && (oldManifest.Spec.Options.Vlan  != newManifest.Spec.Options.Vlan  || oldManifest.Spec.Options.Device != newManifest.Spec.Options.Device)) ||
      (oldManifest.Spec.Options.Vxlan != 0 && (oldManifest.Spec.Options.Vxlan != newManifest.Spec.Options.Vxlan || oldManifest.Spec.Options.Device != newManifest.Spec.Options.Device)) {
-    return errors.New("cannot change VNI/host_device of a network which having any Pods connected to it e.g. Pod:" + connectedEp.Spec.Pod + " in namespace:" + connectedEp.ObjectMeta.Namespace)
+    return forbiddenField(changedField, "cannot change VNI/host_device of a network which having any Pods connected to it e.g. Pod:" + connectedEp.Spec.Pod + " in namespace:" + connectedEp.ObjectMeta.Namespace)
+  }
+  return nil
+}
+
+func validateVxlanParams(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
+  options := newManifest.Spec.Options
+  if options.VxlanMode != "" && options.VxlanMode != netcontrol.VxlanModeMulticast && options.VxlanMode != netcontrol.VxlanModeUnicast {
+    return notSupportedField(vxlanModeField, options.VxlanMode + " is not in allowed vxlan_mode values: {" + netcontrol.VxlanModeMulticast + "," + netcontrol.VxlanModeUnicast + "}")
+  }
+  if options.VxlanPort < 0 || options.VxlanPort > MaxPort {
+    return invalidField(vxlanPortField, "Spec.Options.vxlan_port must be between 1 and " + strconv.Itoa(MaxPort))
+  }
+  if options.VxlanTtl < 0 || options.VxlanTtl > MaxTtl {
+    return invalidField(vxlanTtlField, "Spec.Options.vxlan_ttl must be between 0 and " + strconv.Itoa(MaxTtl))
+  }
+  if options.VxlanTos < 0 || options.VxlanTos > MaxTos {
+    return invalidField(vxlanTosField, "Spec.Options.vxlan_tos must be between 0 and " + strconv.Itoa(MaxTos))
+  }
+  if options.VxlanSourceCidr != "" {
+    if _, _, err := net.ParseCIDR(options.VxlanSourceCidr); err != nil {
+      return invalidField(vxlanSourceCidrField, "Invalid CIDR: " + options.VxlanSourceCidr)
+    }
+  }
+  if options.VxlanGroup != "" {
+    if group := net.ParseIP(options.VxlanGroup); group == nil || !group.IsMulticast() {
+      return invalidField(vxlanGroupField, options.VxlanGroup + " is not a multicast IP address")
+    }
+    if options.VxlanMode == netcontrol.VxlanModeUnicast {
+      return forbiddenField(vxlanGroupField, "Spec.Options.vxlan_group cannot be set for unicast VxLAN networks")
+    }
+  }
+  //VNIs of TenantNetworks are only allocated after validation
+  if isAnyVxlanParamSet(newManifest) && options.Vxlan == 0 && newManifest.TypeMeta.Kind != "TenantNetwork" {
+    return forbiddenField(vxlanField, "Spec.Options.vxlan_* parameters can only be set for VxLAN networks")
+  }
+  if opType != admissionv1.Update || oldManifest.Spec.Options.Vxlan == 0 || netcontrol.AreVxlanParamsEqual(oldManifest, newManifest) {
+    return nil
+  }
+  isAnyPodConnectedToNetwork, connectedEp, err := danmep.ArePodsConnectedToNetwork(client, oldManifest)
+  if err != nil {
+    return errors.New("no way to tell if Pods are still using the network due to:" + err.Error())
+  }
+  if isAnyPodConnectedToNetwork {
+    return forbiddenField(vxlanField, "cannot change VxLAN parameters of a network which having any Pods connected to it e.g. Pod:" + connectedEp.Spec.Pod + " in namespace:" + connectedEp.ObjectMeta.Namespace)
+  }
+  return nil
+}
+
+func isAnyVxlanParamSet(dnet *danmtypes.DanmNet) bool {
+  options := dnet.Spec.Options
+  return options.VxlanMode != "" || options.VxlanPort != 0 || options.VxlanTtl != 0 || options.VxlanTos != 0 || options.VxlanSourceCidr != "" ||
+    options.VxlanLearning != nil || options.VxlanGroup != ""
+}
+
+func validateVrf(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
+  vrf := newManifest.Spec.Options.Vrf
+  if vrf == "" {
+    return nil
+  }
+  if len(vrf) > MaxIfaceNameLength {
+    return invalidField(vrfField, "Spec.Options.vrf cannot be longer than " + strconv.Itoa(MaxIfaceNameLength) + " characters, as it is used as the name of the VRF device!")
+  }
+  if newManifest.Spec.Options.RTables == 0 || newManifest.Spec.Options.RTables > MaxVrfTableId {
+    return invalidField(rtTablesField, "Spec.Options.rt_tables must be between 1 and " + strconv.Itoa(MaxVrfTableId) + " when Spec.Options.vrf is defined, as it is used as the routing table of the VRF!")
+  }
+  return nil
+}
+
+func validateIpv6Mode(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
+  switch newManifest.Spec.Options.Ipv6Mode {
+  case "", ipam.Ipv6ModeStatic, ipam.Ipv6ModeSlaac, ipam.Ipv6ModeDhcpv6Passthrough:
+    return nil
+  }
+  return notSupportedField(ipv6ModeField, "Spec.Options.ipv6_mode must be one of: " + ipam.Ipv6ModeStatic + ", " + ipam.Ipv6ModeSlaac + ", " + ipam.Ipv6ModeDhcpv6Passthrough + "!")
+}
+
+//TenantNetworks only get their L2 domain during mutation, so this rule is enforced for them in the post-validation phase
+func validateCidrOverlap(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
+  if newManifest.ObjectMeta.Annotations[AllowCidrOverlapAnnotation] == "true" {
+    return nil
+  }
+  l2Domain := getL2Domain(newManifest)
+  if l2Domain == "" || (newManifest.Spec.Options.Cidr == "" && newManifest.Spec.Options.Net6 == "") {
+    return nil
+  }
+  nets, err := netcontrol.ListAllNetworks(client)
+  if err != nil {
+    return errors.New("no way to tell if the subnets of the network overlap with other networks due to:" + err.Error())
+  }
+  for _, otherNet := range nets {
+    if isSameNetwork(&otherNet, newManifest) || getL2Domain(&otherNet) != l2Domain {
+      continue
+    }
+    overlappingField := cidrField
+    if !doSubnetsOverlap(newManifest.Spec.Options.Cidr, otherNet.Spec.Options.Cidr) {
+      overlappingField = net6Field
+    }
+    if doSubnetsOverlap(newManifest.Spec.Options.Cidr, otherNet.Spec.Options.Cidr) || doSubnetsOverlap(newManifest.Spec.Options.Net6, otherNet.Spec.Options.Net6) {
+      return duplicateField(overlappingField, "the subnets of the network overlap with the subnets of " + otherNet.TypeMeta.Kind + ":" + otherNet.ObjectMeta.Name + " in namespace:" + otherNet.ObjectMeta.Namespace +
+        " which is in the same L2 domain:" + l2Domain + ". Annotate the network with " + AllowCidrOverlapAnnotation + ":\"true\" if the overlap is intentional!")
+    }
+  }
+  return nil
+}
+
+//Networks with different NetworkIDs would need their own VLAN, or VxLAN host interface with the same VNI on the same host_device, which is impossible
+//VxLAN host interfaces are not bound to their host_device though: the same VxLAN ID cannot be used with the same UDP port on any host_device
+//TenantNetworks only get their VNI during mutation, so this rule is enforced for them in the post-validation phase
+func validateVniUniqueness(oldManifest, newManifest *danmtypes.DanmNet, opType admissionv1.Operation, client danmclientset.Interface) error {
+  if newManifest.Spec.Options.Device == "" || (newManifest.Spec.Options.Vlan == 0 && newManifest.Spec.Options.Vxlan == 0) {
+    return nil
+  }
+  nets, err := netcontrol.ListAllNetworks(client)
+  if err != nil {
+    return errors.New("no way to tell if the VNI of the network is already used by other networks due to:" + err.Error())
+  }
+  for _, otherNet := range nets {
+    if isSameNetwork(&otherNet, newManifest) || otherNet.Spec.Options.Device == "" || otherNet.Spec.NetworkID == newManifest.Spec.NetworkID {
+      continue
+    }
+    if newManifest.Spec.Options.Vlan != 0 && otherNet.Spec.Options.Device == newManifest.Spec.Options.Device && otherNet.Spec.Options.Vlan == newManifest.Spec.Options.Vlan {
+      return duplicateField(vlanField, "VLAN ID:" + strconv.Itoa(newManifest.Spec.Options.Vlan) + " is already used on host_device:" + newManifest.Spec.Options.Device + " by " + otherNet.TypeMeta.Kind + ":" +
+        otherNet.ObjectMeta.Name + " in namespace:" + otherNet.ObjectMeta.Namespace + " with a different NetworkID:" + otherNet.Spec.NetworkID)
+    }
+    if newManifest.Spec.Options.Vxlan != 0 && getVxlanKey(&otherNet) == getVxlanKey(newManifest) {
+      return duplicateField(vxlanField, "VxLAN ID:" + strconv.Itoa(newManifest.Spec.Options.Vxlan) + " with UDP port:" + strconv.Itoa(netcontrol.GetVxlanPort(newManifest)) + " is already used on host_device:" + otherNet.Spec.Options.Device + " by " + otherNet.TypeMeta.Kind + ":" +
+        otherNet.ObjectMeta.Name + " in namespace:" + otherNet.ObjectMeta.Namespace + " with a different NetworkID:" + otherNet.Spec.NetworkID)
+    }
   }
   return nil
 }
+
+//getUsedVnis returns the VLAN IDs already used by any network on the given host_device,
+//or the VxLAN IDs already used with the UDP port of the network by any network on any host_device
+func getUsedVnis(client danmclientset.Interface, dnet *danmtypes.DanmNet, device, vniType string) ([]int, error) {
+  nets, err := netcontrol.ListAllNetworks(client)
+  if err != nil {
+    return nil, err
+  }
+  vxlanPort := netcontrol.GetVxlanPort(dnet)
+  var usedVnis []int
+  for _, otherNet := range nets {
+    if vniType == "vlan" && otherNet.Spec.Options.Device == device && otherNet.Spec.Options.Vlan != 0 {
+      usedVnis = append(usedVnis, otherNet.Spec.Options.Vlan)
+    } else if vniType == "vxlan" && otherNet.Spec.Options.Device != "" && otherNet.Spec.Options.Vxlan != 0 && netcontrol.GetVxlanPort(&otherNet) == vxlanPort {
+      usedVnis = append(usedVnis, otherNet.Spec.Options.Vxlan)
+    }
+  }
+  return usedVnis, nil
+}
+
+//getVxlanKey identifies the VxLAN host interface of a network on a node: its VxLAN ID together with its destination UDP port
+func getVxlanKey(dnet *danmtypes.DanmNet) string {
+  if dnet.Spec.Options.Vxlan == 0 {
+    return ""
+  }
+  return strconv.Itoa(dnet.Spec.Options.Vxlan) + "/" + strconv.Itoa(netcontrol.GetVxlanPort(dnet))
+}
+
+func isSameNetwork(dnet1, dnet2 *danmtypes.DanmNet) bool {
+  return dnet1.TypeMeta.Kind == dnet2.TypeMeta.Kind && dnet1.ObjectMeta.Namespace == dnet2.ObjectMeta.Namespace && dnet1.ObjectMeta.Name == dnet2.ObjectMeta.Name
+}
+
+//Networks share an L2 domain when they are attached to the same host_device, or device_pool with the same VLAN or VxLAN ID
+//An empty string is returned for networks not attached to any physical device, e.g. overlay or routed networks
+func getL2Domain(dnet *danmtypes.DanmNet) string {
+  device := dnet.Spec.Options.Device
+  if device == "" {
+    device = dnet.Spec.Options.DevicePool
+  }
+  if device == "" {
+    return ""
+  }
+  if dnet.Spec.Options.Vxlan != 0 {
+    return device + "/vxlan:" + strconv.Itoa(dnet.Spec.Options.Vxlan)
+  }
+  if dnet.Spec.Options.Vlan != 0 {
+    return device + "/vlan:" + strconv.Itoa(dnet.Spec.Options.Vlan)
+  }
+  return device
+}
+
+func doSubnetsOverlap(cidr1, cidr2 string) bool {
+  _, subnet1, err1 := net.ParseCIDR(cidr1)
+  _, subnet2, err2 := net.ParseCIDR(cidr2)
+  if err1 != nil || err2 != nil {
+    return false
+  }
+  return ipam.DoCidrsOverlap(subnet1, subnet2)
+}
@@ -0,0 +1,104 @@
+package certwatcher
+
+import (
+  "errors"
+  "log"
+  "os"
+  "sync"
+  "time"
+  "crypto/tls"
+)
+
+//CertWatcher serves a TLS key pair, and reloads it whenever its files change on disk
+//This way rotated certificates, e.g. renewed by cert-manager, are picked up without restarting the server
+type CertWatcher struct {
+  certFile string
+  keyFile string
+  lock sync.RWMutex
+  cert *tls.Certificate
+  certModTime time.Time
+  keyModTime time.Time
+}
+
+//NewCertWatcher loads the key pair from the given files. Returns error if the initial loading fails
+func NewCertWatcher(certFile, keyFile string) (*CertWatcher, error) {
+  watcher := CertWatcher{certFile: certFile, keyFile: keyFile}
+  err := watcher.Reload()
+  if err != nil {
+    return nil, err
+  }
+  return &watcher, nil
+}
+
+//GetCertificate returns the currently loaded key pair. It is meant to be used as the GetCertificate callback of a tls.Config
+func (watcher *CertWatcher) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+  watcher.lock.RLock()
+  defer watcher.lock.RUnlock()
+  return watcher.cert, nil
+}
+
+//Reload unconditionally loads the key pair from the files. The previous key pair is kept if loading fails
+func (watcher *CertWatcher) Reload() error {
+  certModTime, keyModTime, err := watcher.getModTimes()
+  if err != nil {
+    return err
+  }
+  cert, err := tls.LoadX509KeyPair(watcher.certFile, watcher.keyFile)
+  if err != nil {
+    return errors.New("TLS key pair could not be loaded, because:" + err.Error())
+  }
+  watcher.lock.Lock()
+  defer watcher.lock.Unlock()
+  watcher.cert = &cert
+  watcher.certModTime, watcher.keyModTime = certModTime, keyModTime
+  return nil
+}
+
+//ReloadIfChanged reloads the key pair if any of its files were modified since the last successful loading
+//Returns true if a new key pair was loaded
+func (watcher *CertWatcher) ReloadIfChanged() (bool, error) {
+  certModTime, keyModTime, err := watcher.getModTimes()
+  if err != nil {
+    return false, err
+  }
+  watcher.lock.RLock()
+  isChanged := !certModTime.Equal(watcher.certModTime) || !keyModTime.Equal(watcher.keyModTime)
+  watcher.lock.RUnlock()
+  if !isChanged {
+    return false, nil
+  }
+  err = watcher.Reload()
+  return err == nil, err
+}
+
+//Watch periodically checks the files of the key pair, and reloads it when they change, until the stop channel is closed
+//Files are polled instead of subscribing to file system events, because Secrets are updated by swapping symlinks in their volumes
+func (watcher *CertWatcher) Watch(interval time.Duration, stopCh <-chan struct{}) {
+  ticker := time.NewTicker(interval)
+  defer ticker.Stop()
+  for {
+    select {
+    case <-stopCh:
+      return
+    case <-ticker.C:
+      isReloaded, err := watcher.ReloadIfChanged()
+      if err != nil {
+        log.Println("ERROR: TLS certificate could not be reloaded, the previous one is still served. Reason:" + err.Error())
+      } else if isReloaded {
+        log.Println("INFO: TLS certificate was reloaded from:" + watcher.certFile)
+      }
+    }
+  }
+}
+
+func (watcher *CertWatcher) getModTimes() (time.Time, time.Time, error) {
+  certInfo, err := os.Stat(watcher.certFile)
+  if err != nil {
+    return time.Time{}, time.Time{}, errors.New("TLS certificate file cannot be read, because:" + err.Error())
+  }
+  keyInfo, err := os.Stat(watcher.keyFile)
+  if err != nil {
+    return time.Time{}, time.Time{}, errors.New("TLS private key file cannot be read, because:" + err.Error())
+  }
+  return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
@@ -0,0 +1,125 @@
+package confman
+
+import (
+  "reflect"
+  "sort"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
+  "github.com/nokia/danm/pkg/bitarray"
+  metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//AuditTenantConfig compares the VNI allocations of every virtual interface profile of a TenantConfig with the VNIs of the TenantNetworks it serves
+//When repair is set, VNIs used by TenantNetworks are reserved, and leaked VNIs are freed in the TenantConfig
+//As the webhook reserves VNIs before their TenantNetwork is persisted, leaked VNIs are only freed if the previous audit found them leaked as well
+func AuditTenantConfig(tconf *danmtypes.TenantConfig, tnets []danmtypes.TenantNetwork, repair bool) danmtypes.TenantConfigStatus {
+  status := danmtypes.TenantConfigStatus{LastAuditTime: metav1.Now()}
+  for index, iface := range tconf.HostDevices {
+    if iface.VniType == "" || iface.Alloc == "" {
+      continue
+    }
+    profileStatus, newAlloc := auditIfaceProfile(iface, getUsedVniCounts(iface, tnets), getPreviouslyLeakedVnis(tconf.Status, iface), repair)
+    tconf.HostDevices[index].Alloc = newAlloc
+    status.Profiles = append(status.Profiles, profileStatus)
+  }
+  return status
+}
+
+//Audit audits the VNI allocations of a TenantConfig, and persists the result, together with the repaired allocations in the TenantConfig
+//The TenantConfig is only updated when the result of the audit changed, so periodic audits do not race with VNI reservations for nothing
+func Audit(danmClient danmclientset.Interface, tconf *danmtypes.TenantConfig, tnets []danmtypes.TenantNetwork, repair bool) (*danmtypes.TenantConfigStatus,error) {
+  for {
+    auditedConf := tconf.DeepCopy()
+    status := AuditTenantConfig(auditedConf, tnets, repair)
+    if !isAuditResultChanged(tconf, auditedConf, status) {
+      return &status, nil
+    }
+    auditedConf.Status = &status
+    newConf, wasRefreshed, err := updateTenantConf(danmClient, auditedConf)
+    if err != nil {
+      return nil, err
+    }
+    if wasRefreshed {
+      tconf = newConf
+      continue
+    }
+    return &status, nil
+  }
+}
+
+func isAuditResultChanged(oldConf, auditedConf *danmtypes.TenantConfig, status danmtypes.TenantConfigStatus) bool {
+  if oldConf.Status == nil || !reflect.DeepEqual(oldConf.Status.Profiles, status.Profiles) {
+    return true
+  }
+  for index, iface := range oldConf.HostDevices {
+    if iface.Alloc != auditedConf.HostDevices[index].Alloc {
+      return true
+    }
+  }
+  return false
+}
+
+func getUsedVniCounts(iface danmtypes.IfaceProfile, tnets []danmtypes.TenantNetwork) map[int]int {
+  usedVnis := make(map[int]int)
+  for _, tnet := range tnets {
+    if tnet.Spec.Options.Device != iface.Name && tnet.Spec.Options.DevicePool != iface.Name {
+      continue
+    }
+    if iface.VniType == "vlan" && tnet.Spec.Options.Vlan != 0 {
+      usedVnis[tnet.Spec.Options.Vlan]++
+    } else if iface.VniType == "vxlan" && tnet.Spec.Options.Vxlan != 0 {
+      usedVnis[tnet.Spec.Options.Vxlan]++
+    }
+  }
+  return usedVnis
+}
+
+func getPreviouslyLeakedVnis(status *danmtypes.TenantConfigStatus, iface danmtypes.IfaceProfile) map[int]bool {
+  leakedVnis := make(map[int]bool)
+  if status == nil {
+    return leakedVnis
+  }
+  for _, profileStatus := range status.Profiles {
+    if profileStatus.Name == iface.Name && profileStatus.VniType == iface.VniType {
+      for _, vni := range profileStatus.LeakedVnis {
+        leakedVnis[vni] = true
+      }
+    }
+  }
+  return leakedVnis
+}
+
+func auditIfaceProfile(iface danmtypes.IfaceProfile, usedVnis map[int]int, previouslyLeakedVnis map[int]bool, repair bool) (danmtypes.IfaceProfileStatus,string) {
+  status := danmtypes.IfaceProfileStatus{Name: iface.Name, VniType: iface.VniType, UsedVnis: len(usedVnis)}
+  allocs := bitarray.NewBitArrayFromBase64(iface.Alloc)
+  for vni, count := range usedVnis {
+    if count > 1 {
+      status.DuplicateVnis = append(status.DuplicateVnis, vni)
+    }
+    if uint32(vni) >= allocs.Len() || allocs.Get(uint32(vni)) {
+      continue
+    }
+    if repair {
+      allocs.Set(uint32(vni))
+      status.RepairedVnis = append(status.RepairedVnis, vni)
+    } else {
+      status.UnreservedVnis = append(status.UnreservedVnis, vni)
+    }
+  }
+  //VNI 0 is never handed out, its bit is set when the allocation is created
+  for vni := 1; uint32(vni) < allocs.Len(); vni++ {
+    if !allocs.Get(uint32(vni)) || usedVnis[vni] > 0 {
+      continue
+    }
+    if repair && previouslyLeakedVnis[vni] {
+      allocs.Reset(uint32(vni))
+      status.RepairedVnis = append(status.RepairedVnis, vni)
+    } else {
+      status.LeakedVnis = append(status.LeakedVnis, vni)
+    }
+  }
+  sort.Ints(status.DuplicateVnis)
+  sort.Ints(status.UnreservedVnis)
+  sort.Ints(status.RepairedVnis)
+  return status, allocs.Encode()
+}
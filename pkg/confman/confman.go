@@ -17,7 +17,10 @@ const (
   TenantConfigKind = "TenantConfig"
 )
 
-func GetTenantConfig(danmClient danmclientset.Interface) (*danmtypes.TenantConfig, error) {
+//GetTenantConfig returns the TenantConfig applying to the given namespace
+//TenantConfigs explicitly listing the namespace take precedence over the ones selecting it with their namespaceSelector,
+//which take precedence over the ones without any namespace selection
+func GetTenantConfig(danmClient danmclientset.Interface, namespace string, namespaceLabels map[string]string) (*danmtypes.TenantConfig, error) {
   reply, err := danmClient.DanmV1().TenantConfigs().List(context.TODO(), metav1.ListOptions{})
   if err != nil {
     return nil, err
@@ -25,17 +28,22 @@ func GetTenantConfig(danmClient danmclientset.Interface) (*danmtypes.TenantConfi
   if reply == nil || len(reply.Items) == 0 {
     return nil, errors.New("no TenantConfigs exist int the cluster")
   }
-  //TODO: do a namespace based selection later if one generic config does not suffice
-  return &reply.Items[0], nil
+  tconf := SelectTenantConfig(reply.Items, namespace, namespaceLabels)
+  if tconf == nil {
+    return nil, errors.New("none of the TenantConfigs apply to namespace:" + namespace)
+  }
+  return tconf, nil
 }
 
-func Reserve(danmClient danmclientset.Interface, tconf *danmtypes.TenantConfig, iface danmtypes.IfaceProfile) (int,error) {
+//Reserve allocates the first free VNI of an interface profile
+//VNIs listed in excludedVnis are skipped without being reserved, e.g. because networks outside of the TenantConfig already use them
+func Reserve(danmClient danmclientset.Interface, tconf *danmtypes.TenantConfig, iface danmtypes.IfaceProfile, excludedVnis ...int) (int,error) {
   for {
     index := getIfaceIndex(tconf, iface.Name, iface.VniType)
     if index == -1 {
       return 0, errors.New("VNI cannot be reserved because selected interface does not exist. You should call for a tech priest, and start praying to the Omnissiah immediately.")
     }
-    chosenVni, newAlloc, err := reserveVni(tconf.HostDevices[index])
+    chosenVni, newAlloc, err := reserveVni(tconf.HostDevices[index], excludedVnis)
     if err != nil {
       return 0, err
     }
@@ -52,7 +60,23 @@ func Reserve(danmClient danmclientset.Interface, tconf *danmtypes.TenantConfig,
   }
 }
 
-func reserveVni(iface danmtypes.IfaceProfile) (int,string,error) {
+//SetLastPlacement records the interface profile the last TenantNetwork was attached to, so round-robin placement can continue with the next one
+func SetLastPlacement(danmClient danmclientset.Interface, tconf *danmtypes.TenantConfig, ifaceName string) error {
+  for {
+    tconf.LastPlacement = ifaceName
+    newConf, wasRefreshed, err := updateTenantConf(danmClient, tconf)
+    if err != nil {
+      return err
+    }
+    if wasRefreshed {
+      tconf = newConf
+      continue
+    }
+    return nil
+  }
+}
+
+func reserveVni(iface danmtypes.IfaceProfile, excludedVnis []int) (int,string,error) {
   allocs := bitarray.NewBitArrayFromBase64(iface.Alloc)
   if allocs.Len() == 0 {
     return 0, "", errors.New("VNI allocations for interface:" + iface.Name + " is corrupt! Are you running without webhook?")
@@ -61,10 +85,11 @@ func reserveVni(iface danmtypes.IfaceProfile) (int,string,error) {
   if err != nil {
     return 0, "", errors.New("vniRange for interface:" + iface.Name + " cannot be parsed because:" + err.Error())
   }
+  excludedSet := cpuset.NewCPUSet(excludedVnis...)
   chosenVni := -1
   vniSet := vnis.ToSlice()
   for _, vni := range vniSet {
-    if allocs.Get(uint32(vni)) {
+    if allocs.Get(uint32(vni)) || excludedSet.Contains(vni) {
       continue
     }
     allocs.Set(uint32(vni))
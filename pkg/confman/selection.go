@@ -0,0 +1,148 @@
+package confman
+
+import (
+  "log"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+  "k8s.io/apimachinery/pkg/labels"
+)
+
+//The precedence of the ways a TenantConfig can apply to a namespace, from the weakest to the strongest
+const (
+  NoMatch = iota
+  //TenantConfigs without any namespace selection apply to every namespace not selected by any other TenantConfig
+  DefaultMatch
+  LabelMatch
+  NamespaceListMatch
+)
+
+//MatchNamespace tells how strongly a TenantConfig applies to a namespace with the given labels
+func MatchNamespace(tconf *danmtypes.TenantConfig, namespace string, namespaceLabels map[string]string) int {
+  for _, selectedNamespace := range tconf.Namespaces {
+    if selectedNamespace == namespace {
+      return NamespaceListMatch
+    }
+  }
+  if tconf.NamespaceSelector != nil {
+    selector, err := metav1.LabelSelectorAsSelector(tconf.NamespaceSelector)
+    if err != nil {
+      log.Println("WARNING: namespaceSelector of TenantConfig:" + tconf.ObjectMeta.Name + " is ignored, because it is invalid:" + err.Error())
+    } else if selector.Matches(labels.Set(namespaceLabels)) {
+      return LabelMatch
+    }
+  }
+  if len(tconf.Namespaces) == 0 && tconf.NamespaceSelector == nil {
+    return DefaultMatch
+  }
+  return NoMatch
+}
+
+//SelectTenantConfig returns the TenantConfig applying the strongest to a namespace
+//Ties are broken by the name of the TenantConfigs, so the result never depends on the order they were listed in
+func SelectTenantConfig(tconfs []danmtypes.TenantConfig, namespace string, namespaceLabels map[string]string) *danmtypes.TenantConfig {
+  var chosenConf *danmtypes.TenantConfig
+  chosenMatch := NoMatch
+  for index := range tconfs {
+    match := MatchNamespace(&tconfs[index], namespace, namespaceLabels)
+    if match == NoMatch {
+      continue
+    }
+    if match > chosenMatch || (match == chosenMatch && tconfs[index].ObjectMeta.Name < chosenConf.ObjectMeta.Name) {
+      chosenConf = &tconfs[index]
+      chosenMatch = match
+    }
+  }
+  return chosenConf
+}
+
+//GetOverlappingSelection returns the precedence, and the description of the namespace selection two TenantConfigs share, or NoMatch if they cannot select the same namespace with the same precedence
+//Label selectors are considered overlapping unless their requirements are provably contradictory, regardless of the namespaces currently existing in the cluster
+func GetOverlappingSelection(tconf1, tconf2 *danmtypes.TenantConfig) (int,string) {
+  for _, namespace1 := range tconf1.Namespaces {
+    for _, namespace2 := range tconf2.Namespaces {
+      if namespace1 == namespace2 {
+        return NamespaceListMatch, "namespace:" + namespace1
+      }
+    }
+  }
+  if tconf1.NamespaceSelector != nil && tconf2.NamespaceSelector != nil && !areSelectorsDisjoint(tconf1.NamespaceSelector, tconf2.NamespaceSelector) {
+    return LabelMatch, "namespaceSelector"
+  }
+  if len(tconf1.Namespaces) == 0 && tconf1.NamespaceSelector == nil && len(tconf2.Namespaces) == 0 && tconf2.NamespaceSelector == nil {
+    return DefaultMatch, "lack of namespace selection"
+  }
+  return NoMatch, ""
+}
+
+//labelConstraint collects what the requirements of label selectors demand from one label key
+type labelConstraint struct {
+  mustExist bool
+  mustNotExist bool
+  //nil means any value is allowed
+  allowedValues map[string]bool
+  forbiddenValues map[string]bool
+}
+
+func (constraint *labelConstraint) allowOnly(values ...string) {
+  constraint.mustExist = true
+  newAllowedValues := make(map[string]bool)
+  for _, value := range values {
+    if constraint.allowedValues == nil || constraint.allowedValues[value] {
+      newAllowedValues[value] = true
+    }
+  }
+  constraint.allowedValues = newAllowedValues
+}
+
+func (constraint *labelConstraint) isSatisfiable() bool {
+  if constraint.mustNotExist && (constraint.mustExist || constraint.allowedValues != nil) {
+    return false
+  }
+  if constraint.allowedValues == nil {
+    return true
+  }
+  for value := range constraint.allowedValues {
+    if !constraint.forbiddenValues[value] {
+      return true
+    }
+  }
+  return false
+}
+
+//areSelectorsDisjoint tells if no label set can match all the given selectors at the same time
+//As label keys are independent from each other, this is the case exactly when the requirements of any one key contradict each other
+func areSelectorsDisjoint(selectors ...*metav1.LabelSelector) bool {
+  constraints := make(map[string]*labelConstraint)
+  getConstraint := func(key string) *labelConstraint {
+    if _, isKeyConstrained := constraints[key]; !isKeyConstrained {
+      constraints[key] = &labelConstraint{forbiddenValues: make(map[string]bool)}
+    }
+    return constraints[key]
+  }
+  for _, selector := range selectors {
+    for key, value := range selector.MatchLabels {
+      getConstraint(key).allowOnly(value)
+    }
+    for _, requirement := range selector.MatchExpressions {
+      constraint := getConstraint(requirement.Key)
+      switch requirement.Operator {
+      case metav1.LabelSelectorOpIn:
+        constraint.allowOnly(requirement.Values...)
+      case metav1.LabelSelectorOpNotIn:
+        for _, value := range requirement.Values {
+          constraint.forbiddenValues[value] = true
+        }
+      case metav1.LabelSelectorOpExists:
+        constraint.mustExist = true
+      case metav1.LabelSelectorOpDoesNotExist:
+        constraint.mustNotExist = true
+      }
+    }
+  }
+  for _, constraint := range constraints {
+    if !constraint.isSatisfiable() {
+      return true
+    }
+  }
+  return false
+}
@@ -0,0 +1,195 @@
+package confman
+
+import (
+  "errors"
+  "net"
+  "strconv"
+  "strings"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
+  "github.com/nokia/danm/pkg/bitarray"
+  "github.com/nokia/danm/pkg/datastructs"
+  "github.com/nokia/danm/pkg/ipam"
+)
+
+const (
+  //MaxSubnetsPerSupernet is a dimensioning decision to avoid reserving unnecessarily big bitarrays in TenantConfig
+  MaxSubnetsPerSupernet = 4096
+  //CarvedSubnetAnnotation records the subnet carved out of a supernet for a TenantNetwork, so only subnets really reserved for the network are released
+  CarvedSubnetAnnotation = "danm.k8s.io/carved-subnet"
+)
+
+//GetSupernetIndex returns the supernet of a TenantConfig TenantNetworks of the given namespace, and NetworkType get their subnet carved out of, or -1 if there is none
+//Supernets dedicated to the namespace are preferred over the ones dedicated to the NetworkType, which are preferred over the generic ones
+func GetSupernetIndex(tconf *danmtypes.TenantConfig, namespace, networkType string) int {
+  chosenIndex := -1
+  chosenScore := -1
+  for index, supernet := range tconf.Supernets {
+    if (supernet.Namespace != "" && supernet.Namespace != namespace) ||
+       (supernet.NetworkType != "" && !strings.EqualFold(supernet.NetworkType, networkType)) {
+      continue
+    }
+    score := 0
+    if supernet.Namespace != "" {
+      score += 2
+    }
+    if supernet.NetworkType != "" {
+      score++
+    }
+    if score > chosenScore {
+      chosenIndex = index
+      chosenScore = score
+    }
+  }
+  return chosenIndex
+}
+
+//GetNumberOfSubnets returns how many subnets of the configured prefix length fit into a supernet
+func GetNumberOfSubnets(supernet danmtypes.TenantSupernet) (int,error) {
+  _, ipnet, err := net.ParseCIDR(supernet.Cidr)
+  if err != nil || ipnet.IP.To4() == nil {
+    return 0, errors.New("cidr:" + supernet.Cidr + " of supernet is not a valid IPv4 CIDR")
+  }
+  supernetMaskSize, _ := ipnet.Mask.Size()
+  if supernet.PrefixLength < supernetMaskSize || supernet.PrefixLength < datastructs.MaxV4MaskLength || supernet.PrefixLength > 30 {
+    return 0, errors.New("prefixLength:" + strconv.Itoa(supernet.PrefixLength) + " must be between the prefix length of supernet:" + supernet.Cidr + " (but at least " + strconv.Itoa(datastructs.MaxV4MaskLength) + "), and 30")
+  }
+  numberOfSubnets := 1 << uint(supernet.PrefixLength - supernetMaskSize)
+  if numberOfSubnets > MaxSubnetsPerSupernet {
+    return 0, errors.New("supernet:" + supernet.Cidr + " cannot be divided into more than " + strconv.Itoa(MaxSubnetsPerSupernet) + " subnets")
+  }
+  return numberOfSubnets, nil
+}
+
+//GetNumberOfCarvedSubnets returns how many subnets are currently reserved from a supernet
+func GetNumberOfCarvedSubnets(supernet danmtypes.TenantSupernet) int {
+  allocs := bitarray.NewBitArrayFromBase64(supernet.Alloc)
+  var carvedSubnets int
+  for subnetIndex := uint32(0); subnetIndex < allocs.Len(); subnetIndex++ {
+    if allocs.Get(subnetIndex) {
+      carvedSubnets++
+    }
+  }
+  return carvedSubnets
+}
+
+//ReserveSubnet carves the first free subnet out of a supernet of a TenantConfig
+//Subnets overlapping with any of the excludedSubnets are skipped without being reserved, e.g. because other networks already use them
+func ReserveSubnet(danmClient danmclientset.Interface, tconf *danmtypes.TenantConfig, supernetCidr string, excludedSubnets ...*net.IPNet) (string,error) {
+  for {
+    index := getSupernetIndexByCidr(tconf, supernetCidr)
+    if index == -1 {
+      return "", errors.New("subnet cannot be reserved because supernet:" + supernetCidr + " does not exist in TenantConfig:" + tconf.ObjectMeta.Name)
+    }
+    chosenSubnet, newAlloc, err := carveSubnet(tconf.Supernets[index], excludedSubnets)
+    if err != nil {
+      return "", err
+    }
+    tconf.Supernets[index].Alloc = newAlloc
+    newConf, wasRefreshed, err := updateTenantConf(danmClient, tconf)
+    if err != nil {
+      return "", err
+    }
+    if wasRefreshed {
+      tconf = newConf
+      continue
+    }
+    return chosenSubnet, nil
+  }
+}
+
+func carveSubnet(supernet danmtypes.TenantSupernet, excludedSubnets []*net.IPNet) (string,string,error) {
+  allocs := bitarray.NewBitArrayFromBase64(supernet.Alloc)
+  if allocs.Len() == 0 {
+    return "", "", errors.New("subnet allocations for supernet:" + supernet.Cidr + " is corrupt! Are you running without webhook?")
+  }
+  numberOfSubnets, err := GetNumberOfSubnets(supernet)
+  if err != nil {
+    return "", "", err
+  }
+  for subnetIndex := 0; subnetIndex < numberOfSubnets && uint32(subnetIndex) < allocs.Len(); subnetIndex++ {
+    if allocs.Get(uint32(subnetIndex)) {
+      continue
+    }
+    subnet := getSubnet(supernet, subnetIndex)
+    if isSubnetExcluded(subnet, excludedSubnets) {
+      continue
+    }
+    allocs.Set(uint32(subnetIndex))
+    return subnet.String(), allocs.Encode(), nil
+  }
+  return "", "", errors.New("subnet cannot be carved out of supernet:" + supernet.Cidr + " because all of its /" + strconv.Itoa(supernet.PrefixLength) + " subnets are already reserved")
+}
+
+func getSubnet(supernet danmtypes.TenantSupernet, subnetIndex int) *net.IPNet {
+  _, ipnet, _ := net.ParseCIDR(supernet.Cidr)
+  subnetSize := uint32(1) << uint(32 - supernet.PrefixLength)
+  firstIp := ipam.Ip2int(ipnet.IP) + uint32(subnetIndex) * subnetSize
+  return &net.IPNet{IP: ipam.Int2ip(firstIp), Mask: net.CIDRMask(supernet.PrefixLength, 32)}
+}
+
+func isSubnetExcluded(subnet *net.IPNet, excludedSubnets []*net.IPNet) bool {
+  for _, excludedSubnet := range excludedSubnets {
+    if excludedSubnet.Contains(subnet.IP) || subnet.Contains(excludedSubnet.IP) {
+      return true
+    }
+  }
+  return false
+}
+
+func getSupernetIndexByCidr(tconf *danmtypes.TenantConfig, supernetCidr string) int {
+  for index, supernet := range tconf.Supernets {
+    if supernet.Cidr == supernetCidr {
+      return index
+    }
+  }
+  return -1
+}
+
+//FreeSubnet releases the subnet carved out of a supernet of the TenantConfig for a network, as recorded in its CarvedSubnetAnnotation
+//Subnets of networks created with an explicit cidr are never released, even if they fall into a supernet
+func FreeSubnet(danmClient danmclientset.Interface, tconf *danmtypes.TenantConfig, dnet *danmtypes.DanmNet) error {
+  carvedSubnet := dnet.ObjectMeta.Annotations[CarvedSubnetAnnotation]
+  if carvedSubnet == "" {
+    return nil
+  }
+  for {
+    index, subnetIndex := getCarvedSubnetIndex(tconf, carvedSubnet)
+    if index == -1 {
+      return nil
+    }
+    allocs := bitarray.NewBitArrayFromBase64(tconf.Supernets[index].Alloc)
+    if uint32(subnetIndex) >= allocs.Len() || !allocs.Get(uint32(subnetIndex)) {
+      return nil
+    }
+    allocs.Reset(uint32(subnetIndex))
+    tconf.Supernets[index].Alloc = allocs.Encode()
+    newConf, wasRefreshed, err := updateTenantConf(danmClient, tconf)
+    if err != nil {
+      return err
+    }
+    if wasRefreshed {
+      tconf = newConf
+      continue
+    }
+    return nil
+  }
+}
+
+//getCarvedSubnetIndex returns the supernet a CIDR could have been carved out of, and the index of the CIDR among the subnets of the supernet
+func getCarvedSubnetIndex(tconf *danmtypes.TenantConfig, cidr string) (int,int) {
+  _, subnet, err := net.ParseCIDR(cidr)
+  if err != nil {
+    return -1, -1
+  }
+  subnetMaskSize, _ := subnet.Mask.Size()
+  for index, supernet := range tconf.Supernets {
+    _, supernetIpnet, err := net.ParseCIDR(supernet.Cidr)
+    if err != nil || supernet.PrefixLength != subnetMaskSize || !supernetIpnet.Contains(subnet.IP) {
+      continue
+    }
+    subnetIndex := (ipam.Ip2int(subnet.IP) - ipam.Ip2int(supernetIpnet.IP)) >> uint(32 - supernet.PrefixLength)
+    return index, int(subnetIndex)
+  }
+  return -1, -1
+}
@@ -12,6 +12,7 @@ import (
   "time"
   "github.com/containernetworking/plugins/pkg/ns"
   "github.com/containernetworking/plugins/pkg/utils/sysctl"
+  apierrors "k8s.io/apimachinery/pkg/api/errors"
   meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
   sriov_utils "github.com/intel/sriov-cni/pkg/utils"
   danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
@@ -23,7 +24,7 @@ import (
   "github.com/vishvananda/netlink"
 )
 
-type sysctlFunction func(*danmtypes.DanmEp) bool
+type sysctlFunction func(*danmtypes.DanmEp, *danmtypes.DanmNet) bool
 type sysctlObject struct {
   sysctlName  string
   sysctlValue string
@@ -43,6 +44,24 @@ var sysctls = []sysctlTask {
       {"net.ipv6.conf.%s.ndisc_notify", "1"},
     },
   },
+  {
+    sysctlFunc: isSlaacNeeded,
+    sysctlData: []sysctlObject {
+      {"net.ipv6.conf.%s.disable_ipv6", "0"},
+      {"net.ipv6.conf.%s.autoconf", "1"},
+      {"net.ipv6.conf.%s.accept_ra", "2"},
+      {"net.ipv6.conf.%s.ndisc_notify", "1"},
+    },
+  },
+  {
+    sysctlFunc: isDhcpv6PassthroughNeeded,
+    sysctlData: []sysctlObject {
+      {"net.ipv6.conf.%s.disable_ipv6", "0"},
+      {"net.ipv6.conf.%s.autoconf", "0"},
+      {"net.ipv6.conf.%s.accept_ra", "2"},
+      {"net.ipv6.conf.%s.ndisc_notify", "1"},
+    },
+  },
   {
     sysctlFunc: isIPv6NotNeeded,
     sysctlData: []sysctlObject {
@@ -54,6 +73,13 @@ var sysctls = []sysctlTask {
 const (
   MaxRetryCount = 10
   RetryInterval = 100
+  Ipv6AutoconfTimeout = 10
+  DefaultAnnounceCount = 1
+  AnnounceInterval = 100
+  //EpFinalizer keeps a DanmEp holding DANM IPAM allocated IPs until they are freed. DANM CNI removes it itself after freeing them, otherwise DANM Webhook frees them
+  EpFinalizer = "danm.k8s.io/ip-release"
+  //IpsFreedAnnotation records on a deleted DanmEp that its IPs were already freed, so they are never freed twice, even if its finalizer could not be removed at first
+  IpsFreedAnnotation = "danm.k8s.io/ips-freed"
 )
 
 // DeleteIpvlanInterface deletes a Pod's IPVLAN network interface based on the related DanmEp
@@ -174,7 +200,9 @@ func DetermineHostDeviceName(dnet *danmtypes.DanmNet) string {
   return device
 }
 
-func PostProcessInterface(ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet) error {
+// PostProcessInterface finalizes the configuration of a Pod's interface independently from which CNI backend created it:
+// sets the kernel parameters of the link, enslaves it to its VRF, provisions its IP routes, and finally announces its addresses to the network announceCount times
+func PostProcessInterface(ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet, announceCount int) error {
   runtime.LockOSThread()
   defer runtime.UnlockOSThread()
   origNs, err := ns.GetCurrentNS()
@@ -208,7 +236,7 @@ func PostProcessInterface(ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet) error {
     log.Println("WARNING: Interface post-processing was skipped for Pod:" + ep.Spec.Pod + " and link:" + ep.Spec.Iface.Name + " because it does not exist in the kernel. If it is not a user space interface, you should investigate!!!")
     return nil
   }
-  err = setDanmEpSysctls(ep)
+  err = setDanmEpSysctls(ep, dnet)
   if err != nil {
     return errors.New("failed to set kernel configs for interface" + ep.Spec.Iface.Name + " because:" + err.Error())
   }
@@ -216,13 +244,124 @@ func PostProcessInterface(ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet) error {
   if err != nil {
     return errors.New("failed to disable DAD for address" + ep.Spec.Iface.AddressIPv6 + " because:" + err.Error())
   }
-  return addIpRoutes(link, ep, dnet)
+  if !isVfAttachedToDpdkDriver {
+    err = learnAutoconfiguredIpv6(link, ep, dnet)
+    if err != nil {
+      return errors.New("failed to acquire IPv6 address for interface:" + ep.Spec.Iface.Name + " because:" + err.Error())
+    }
+  }
+  err = addLinkToVrf(link, ep, dnet)
+  if err != nil {
+    return errors.New("failed to enslave interface:" + ep.Spec.Iface.Name + " to VRF:" + dnet.Spec.Options.Vrf + " because:" + err.Error())
+  }
+  err = addIpRoutes(link, ep, dnet)
+  if err != nil {
+    return err
+  }
+  if !isVfAttachedToDpdkDriver {
+    announceAddresses(link, getEpAddresses(ep), announceCount)
+  }
+  return nil
+}
+
+// DeleteVrf removes the VRF device of the network from the Pod's network namespace, once no more interfaces are enslaved to it
+func DeleteVrf(ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet) error {
+  if dnet.Spec.Options.Vrf == "" {
+    return nil
+  }
+  if ns.IsNSorErr(ep.Spec.Netns) != nil {
+    return nil
+  }
+  return execInEpNetns(ep, func() error {
+    return deleteVrfIfUnused(dnet.Spec.Options.Vrf)
+  })
+}
+
+// AddVipToEp provisions the virtual IP addresses of a DanmVip as secondary addresses of the DanmEp's interface
+// The new location of the virtual addresses is announced to the network with a gratuitous ARP, or an unsolicited Neighbor Advertisement
+func AddVipToEp(ep *danmtypes.DanmEp, vip *danmtypes.DanmVip) error {
+  return execInEpNetns(ep, func() error {
+    link, err := netlink.LinkByName(ep.Spec.Iface.Name)
+    if err != nil {
+      return errors.New("cannot find interface:" + ep.Spec.Iface.Name + " because:" + err.Error())
+    }
+    vipAddresses := getVipAddresses(vip)
+    for _, vipAddress := range vipAddresses {
+      err = addVipToLink(vipAddress, link)
+      if err != nil {
+        return err
+      }
+    }
+    announceAddresses(link, vipAddresses, DefaultAnnounceCount)
+    return nil
+  })
+}
+
+// RemoveVipFromEp removes the virtual IP addresses of a DanmVip from the DanmEp's interface
+// Nothing needs to be removed when the network namespace, or the interface of the DanmEp is already gone
+func RemoveVipFromEp(ep *danmtypes.DanmEp, vip *danmtypes.DanmVip) error {
+  if ns.IsNSorErr(ep.Spec.Netns) != nil {
+    return nil
+  }
+  return execInEpNetns(ep, func() error {
+    link, err := netlink.LinkByName(ep.Spec.Iface.Name)
+    if err != nil {
+      return nil
+    }
+    for _, vipAddress := range getVipAddresses(vip) {
+      err = removeVipFromLink(vipAddress, link)
+      if err != nil {
+        return err
+      }
+    }
+    return nil
+  })
+}
+
+func getVipAddresses(vip *danmtypes.DanmVip) []string {
+  return getAssignedAddresses(vip.Status.Address, vip.Status.AddressIPv6)
+}
+
+func getEpAddresses(ep *danmtypes.DanmEp) []string {
+  return getAssignedAddresses(ep.Spec.Iface.Address, ep.Spec.Iface.AddressIPv6)
+}
+
+func getAssignedAddresses(ips ...string) []string {
+  var assignedAddresses []string
+  for _, ip := range ips {
+    if ip != "" && ip != ipam.NoneAllocType {
+      assignedAddresses = append(assignedAddresses, ip)
+    }
+  }
+  return assignedAddresses
+}
+
+func execInEpNetns(ep *danmtypes.DanmEp, task func() error) error {
+  runtime.LockOSThread()
+  defer runtime.UnlockOSThread()
+  origNs, err := ns.GetCurrentNS()
+  if err != nil {
+    return errors.New("getting current namespace failed")
+  }
+  hns, err := ns.GetNS(ep.Spec.Netns)
+  if err != nil {
+    return errors.New("cannot open network namespace:" + ep.Spec.Netns)
+  }
+  defer func() {
+    hns.Close()
+    origNs.Set()
+  }()
+  err = hns.Set()
+  if err != nil {
+    return errors.New("failed to enter network namespace of CID:" + ep.Spec.Netns + " with error:" + err.Error())
+  }
+  return task()
 }
 
-func setDanmEpSysctls(ep *danmtypes.DanmEp) error {
+func setDanmEpSysctls(ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet) error {
   var err error
   for _, s := range sysctls {
-    if s.sysctlFunc(ep) {
+    if s.sysctlFunc(ep, dnet) {
       for _, ss := range s.sysctlData {
         sss := fmt.Sprintf(ss.sysctlName, ep.Spec.Iface.Name)
         _, err = sysctl.Sysctl(sss, ss.sysctlValue)
@@ -235,15 +374,23 @@ func setDanmEpSysctls(ep *danmtypes.DanmEp) error {
   return nil
 }
 
-func isIPv6Needed(ep *danmtypes.DanmEp) bool {
-  if ep.Spec.Iface.AddressIPv6 != "" {
+func isIPv6Needed(ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet) bool {
+  if ep.Spec.Iface.AddressIPv6 != "" && !ipam.IsIpv6Autoconfigured(dnet) {
     return true
   }
   return false
 }
 
-func isIPv6NotNeeded(ep *danmtypes.DanmEp) bool {
-  if ep.Spec.Iface.AddressIPv6 == "" {
+func isSlaacNeeded(ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet) bool {
+  return dnet.Spec.Options.Ipv6Mode == ipam.Ipv6ModeSlaac
+}
+
+func isDhcpv6PassthroughNeeded(ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet) bool {
+  return dnet.Spec.Options.Ipv6Mode == ipam.Ipv6ModeDhcpv6Passthrough
+}
+
+func isIPv6NotNeeded(ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet) bool {
+  if ep.Spec.Iface.AddressIPv6 == "" && !ipam.IsIpv6Autoconfigured(dnet) {
     return true
   }
   return false
@@ -252,21 +399,30 @@ func isIPv6NotNeeded(ep *danmtypes.DanmEp) bool {
 // ArePodsConnectedToNetwork checks if there are any Pods currently in the system using the particular network.
 // If there is at least, it returns true, and the spec of the first matching DanmEp.
 func ArePodsConnectedToNetwork(client danmclientset.Interface, dnet *danmtypes.DanmNet)(bool, danmtypes.DanmEp, error) {
+  eps, err := FindByNetwork(client, dnet)
+  if err != nil || len(eps) == 0 {
+    return false, danmtypes.DanmEp{}, err
+  }
+  return true, eps[0], nil
+}
+
+//FindByNetwork returns all the DanmEps connecting Pods to the given network
+func FindByNetwork(client danmclientset.Interface, dnet *danmtypes.DanmNet) ([]danmtypes.DanmEp, error) {
+  var eps []danmtypes.DanmEp
   result, err := client.DanmV1().DanmEps("").List(context.TODO(), meta_v1.ListOptions{})
   if err != nil {
-    return false, danmtypes.DanmEp{}, errors.New("cannot list DanmEps because:" + err.Error())
+    return nil, errors.New("cannot list DanmEps because:" + err.Error())
   }
   if result == nil {
-    return false, danmtypes.DanmEp{}, nil
+    return eps, nil
   }
-  eplist := result.Items
-  for _, ep := range eplist {
+  for _, ep := range result.Items {
     if (ep.Spec.ApiType == dnet.TypeMeta.Kind && ep.Spec.NetworkName == dnet.ObjectMeta.Name) &&
        (dnet.TypeMeta.Kind == "ClusterNetwork" || ep.ObjectMeta.Namespace == dnet.ObjectMeta.Namespace ) {
-      return true, ep, nil
+      eps = append(eps, ep)
     }
   }
-  return false, danmtypes.DanmEp{}, nil
+  return eps, nil
 }
 
 //CreateDanmEp is a RAII-like API to automatically reserve IP allocations whenever an object holding these allocations is created
@@ -299,7 +455,8 @@ func CreateDanmEp(danmClient danmclientset.Interface, namingScheme string, isIpR
       epSpec.MacAddress = hwAddress.String()
     }
   }
-  ep, err := createDanmEp(danmClient, epSpec, netInfo, args)
+  hasDanmIps := isIpReservationNeeded && (ipam.WasIpAllocatedByDanm(ip4, netInfo.Spec.Options.Cidr) || ipam.WasIpAllocatedByDanm(ip6, netInfo.Spec.Options.Pool6.Cidr))
+  ep, err := createDanmEp(danmClient, epSpec, netInfo, args, hasDanmIps)
   if err != nil {
     return nil, netInfo, errors.New("DanmEp object could not be created due to error:" + err.Error())
   }
@@ -330,7 +487,7 @@ func calculateIfaceName(namingScheme, chosenName, defaultName string, sequenceId
   return defaultName + strconv.Itoa(sequenceId)
 }
 
-func createDanmEp(danmClient danmclientset.Interface, epInput danmtypes.DanmEpIface, netInfo *danmtypes.DanmNet, args *datastructs.CniArgs) (*danmtypes.DanmEp, error) {
+func createDanmEp(danmClient danmclientset.Interface, epInput danmtypes.DanmEpIface, netInfo *danmtypes.DanmNet, args *datastructs.CniArgs, hasDanmIps bool) (*danmtypes.DanmEp, error) {
   epidInt, err := uuid.NewV4()
   if err != nil {
     return nil, errors.New("uuid.NewV4 returned error during EP creation:" + err.Error())
@@ -358,6 +515,10 @@ func createDanmEp(danmClient danmclientset.Interface, epInput danmtypes.DanmEpIf
     ResourceVersion: "",
     Labels: args.Pod.Labels,
   }
+  //The IPs are freed even if the DanmEp is deleted by someone else than DANM CNI
+  if hasDanmIps {
+    meta.Finalizers = []string{EpFinalizer}
+  }
   typeMeta := meta_v1.TypeMeta {
       APIVersion: danmtypes.SchemeGroupVersion.String(),
       Kind: "DanmEp",
@@ -401,9 +562,81 @@ func DeleteDanmEp(danmClient danmclientset.Interface, ep *danmtypes.DanmEp, dnet
       return errors.New("DanmEp:" + ep.ObjectMeta.Name + " cannot be safely deleted because freeing its reserved IP addresses failed with error:" + err.Error())
     }
   }
+  //The finalizer is removed before the deletion, so DANM Webhook never frees the same IPs again
+  err = RemoveEpFinalizer(danmClient, ep)
+  if err != nil {
+    return errors.New("DanmEp:" + ep.ObjectMeta.Name + " cannot be safely deleted because its finalizer could not be removed:" + err.Error())
+  }
   return danmClient.DanmV1().DanmEps(ep.ObjectMeta.Namespace).Delete(context.TODO(), ep.ObjectMeta.Name, meta_v1.DeleteOptions{})
 }
 
+// MarkEpIpsFreed records on the DanmEp that its IPs were freed
+func MarkEpIpsFreed(danmClient danmclientset.Interface, ep *danmtypes.DanmEp) (*danmtypes.DanmEp,error) {
+  return updateEpMetadata(danmClient, ep, func(freshEp *danmtypes.DanmEp) bool {
+    if freshEp.ObjectMeta.Annotations[IpsFreedAnnotation] == "true" {
+      return false
+    }
+    if freshEp.ObjectMeta.Annotations == nil {
+      freshEp.ObjectMeta.Annotations = make(map[string]string)
+    }
+    freshEp.ObjectMeta.Annotations[IpsFreedAnnotation] = "true"
+    return true
+  })
+}
+
+// RemoveEpFinalizer lets the DanmEp go after its IPs were freed
+func RemoveEpFinalizer(danmClient danmclientset.Interface, ep *danmtypes.DanmEp) error {
+  _, err := updateEpMetadata(danmClient, ep, func(freshEp *danmtypes.DanmEp) bool {
+    if !HasEpFinalizer(freshEp) {
+      return false
+    }
+    var finalizers []string
+    for _, finalizer := range freshEp.ObjectMeta.Finalizers {
+      if finalizer != EpFinalizer {
+        finalizers = append(finalizers, finalizer)
+      }
+    }
+    freshEp.ObjectMeta.Finalizers = finalizers
+    return true
+  })
+  return err
+}
+
+func HasEpFinalizer(ep *danmtypes.DanmEp) bool {
+  for _, finalizer := range ep.ObjectMeta.Finalizers {
+    if finalizer == EpFinalizer {
+      return true
+    }
+  }
+  return false
+}
+
+//updateEpMetadata applies the change to the latest version of the DanmEp, and retries when the DanmEp was modified meanwhile
+//The change returns false when there is nothing to update. DanmEps already gone are not updated
+func updateEpMetadata(danmClient danmclientset.Interface, ep *danmtypes.DanmEp, change func(*danmtypes.DanmEp) bool) (*danmtypes.DanmEp,error) {
+  freshEp := ep.DeepCopy()
+  for i := 0; ; i++ {
+    if !change(freshEp) {
+      return freshEp, nil
+    }
+    updatedEp, err := danmClient.DanmV1().DanmEps(ep.ObjectMeta.Namespace).Update(context.TODO(), freshEp, meta_v1.UpdateOptions{})
+    if err == nil {
+      return updatedEp, nil
+    }
+    if i == MaxRetryCount - 1 {
+      return nil, err
+    }
+    time.Sleep(RetryInterval * time.Millisecond)
+    freshEp, err = danmClient.DanmV1().DanmEps(ep.ObjectMeta.Namespace).Get(context.TODO(), ep.ObjectMeta.Name, meta_v1.GetOptions{})
+    if apierrors.IsNotFound(err) {
+      return nil, nil
+    }
+    if err != nil {
+      return nil, err
+    }
+  }
+}
+
 func getVfMac(pciId string) net.HardwareAddr {
   pfName,_ := sriov_utils.GetPfName(pciId)
   vfId, err := sriov_utils.GetVfid(pciId, pfName)
@@ -2,23 +2,34 @@ package danmep
 
 import (
   "errors"
+  "fmt"
   "log"
   "net"
   "os"
   "runtime"
   "strconv"
   "syscall"
+  "time"
   "github.com/vishvananda/netlink"
   "github.com/containernetworking/plugins/pkg/ns"
+  "github.com/containernetworking/plugins/pkg/utils/sysctl"
   danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
   "github.com/nokia/danm/pkg/ipam"
   "github.com/j-keck/arping"
+  "golang.org/x/net/icmp"
+  "golang.org/x/net/ipv6"
 )
 
 const (
   InvalidMacAddress = "00:00:00:00:00:00"
 )
 
+var (
+  //The senders of the address announcements are variables, so they can be replaced in unit tests
+  sendGratuitousArp = arping.GratuitousArpOverIfaceByName
+  sendNeighborAdvertisement = sendUnsolicitedNa
+)
+
 func createIpvlanInterface(dnet *danmtypes.DanmNet, ep *danmtypes.DanmEp) error {
   host, err := os.Hostname()
   if err != nil {
@@ -88,20 +99,68 @@ func createContainerIface(ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet, device
   if err != nil {
     return errors.New("cannot find IPVLAN interface in network namespace:" + err.Error())
   }
-  err = configureLink(iface, ep)
+  return configureLink(iface, ep)
+}
+
+// announceAddresses tells the neighbours of the link that the IP addresses are now reachable through it
+// Gratuitous ARPs are sent for IPv4, and unsolicited Neighbor Advertisements for IPv6 addresses, count times each
+// Failing to announce an address is not fatal, as the neighbours will eventually learn it anyway
+func announceAddresses(link netlink.Link, ips []string, count int) {
+  for i := 0; i < count; i++ {
+    if i > 0 {
+      time.Sleep(AnnounceInterval * time.Millisecond)
+    }
+    for _, ip := range ips {
+      addr,_,_ := net.ParseCIDR(ip)
+      if addr == nil {
+        continue
+      }
+      var err error
+      if addr.To4() != nil {
+        err = sendGratuitousArp(addr, link.Attrs().Name)
+      } else {
+        err = sendNeighborAdvertisement(addr, link)
+      }
+      if err != nil {
+        log.Println("WARNING: announcing address:" + ip + " on link:" + link.Attrs().Name + " failed with error:" + err.Error(), ", but we will ignore that for now!")
+      }
+    }
+  }
+}
+
+// sendUnsolicitedNa sends an unsolicited Neighbor Advertisement with the override flag set to the all-nodes multicast address, as described in RFC4861 7.2.6
+func sendUnsolicitedNa(addr net.IP, link netlink.Link) error {
+  conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
   if err != nil {
-    return err
+    return errors.New("cannot open ICMPv6 socket because:" + err.Error())
   }
-  if ep.Spec.Iface.Address != "" && ep.Spec.Iface.Address != ipam.NoneAllocType {
-    addr,_,_ := net.ParseCIDR(ep.Spec.Iface.Address)
-    err = arping.GratuitousArpOverIfaceByName(addr, ep.Spec.Iface.Name)
-    if err != nil {
-      log.Println("WARNING: sending gARP failed with error:" + err.Error(), ", but we will ignore that for now!")
-    }
+  defer conn.Close()
+  msg := icmp.Message{Type: ipv6.ICMPTypeNeighborAdvertisement, Code: 0, Body: &icmp.RawBody{Data: createNaBody(addr, link.Attrs().HardwareAddr)}}
+  packet, err := msg.Marshal(nil)
+  if err != nil {
+    return errors.New("cannot marshal Neighbor Advertisement because:" + err.Error())
+  }
+  //Neighbor Discovery messages are dropped by the receivers unless their hop limit is 255
+  cm := &ipv6.ControlMessage{HopLimit: 255, Src: addr, IfIndex: link.Attrs().Index}
+  _, err = conn.IPv6PacketConn().WriteTo(packet, cm, &net.IPAddr{IP: net.IPv6linklocalallnodes})
+  if err != nil {
+    return errors.New("cannot send Neighbor Advertisement because:" + err.Error())
   }
   return nil
 }
 
+//createNaBody returns the body of a Neighbor Advertisement with the flags: Router=0, Solicited=0, Override=1, followed by the target address
+//The target link-layer address option is only added for Ethernet MACs, as its length is counted in units of 8 octets. Interfaces without such MAC are announced without it
+func createNaBody(addr net.IP, hwAddr net.HardwareAddr) []byte {
+  body := []byte{0x20, 0, 0, 0}
+  body = append(body, addr.To16()...)
+  if len(hwAddr) == 6 {
+    body = append(body, 2, 1)
+    body = append(body, hwAddr...)
+  }
+  return body
+}
+
 func configureLink(iface netlink.Link, ep *danmtypes.DanmEp) error {
   var err error
   if ep.Spec.Iface.Address != "" && ep.Spec.Iface.Address != ipam.NoneAllocType {
@@ -145,20 +204,26 @@ func addIpToLink(ip string, iface netlink.Link) error {
 }
 
 func addIpRoutes(link netlink.Link, ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet) error {
-  defaultRoutingTable := 0
-  err := addRouteForLink(dnet.Spec.Options.Routes, ep.Spec.Iface.Address, defaultRoutingTable, link)
+  routingTable := 0
+  isRuleNeeded := true
+  //Interfaces enslaved to a VRF only use the VRF's own routing table, which is already selected by the kernel's l3mdev rule
+  if dnet.Spec.Options.Vrf != "" {
+    routingTable = dnet.Spec.Options.RTables
+    isRuleNeeded = false
+  }
+  err := addRouteForLink(dnet.Spec.Options.Routes, ep.Spec.Iface.Address, routingTable, link)
   if err != nil {
     return err
   }
-  err = addRouteForLink(dnet.Spec.Options.Routes6, ep.Spec.Iface.AddressIPv6, defaultRoutingTable, link)
+  err = addRouteForLink(dnet.Spec.Options.Routes6, ep.Spec.Iface.AddressIPv6, routingTable, link)
   if err != nil {
     return err
   }
-  err = addPolicyRouteForLink(dnet.Spec.Options.RTables, ep.Spec.Iface.Address, ep.Spec.Iface.Proutes, link)
+  err = addPolicyRouteForLink(dnet.Spec.Options.RTables, ep.Spec.Iface.Address, ep.Spec.Iface.Proutes, isRuleNeeded, link)
   if err != nil {
     return err
   }
-  err = addPolicyRouteForLink(dnet.Spec.Options.RTables, ep.Spec.Iface.AddressIPv6, ep.Spec.Iface.Proutes6, link)
+  err = addPolicyRouteForLink(dnet.Spec.Options.RTables, ep.Spec.Iface.AddressIPv6, ep.Spec.Iface.Proutes6, isRuleNeeded, link)
   if err != nil {
     return err
   }
@@ -198,23 +263,111 @@ func addRouteForLink(routes map[string]string, allocatedIp string, rtable int, l
   return nil
 }
 
-func addPolicyRouteForLink(rtable int, cidr string, proutes map[string]string, link netlink.Link) error {
+func addPolicyRouteForLink(rtable int, cidr string, proutes map[string]string, isRuleNeeded bool, link netlink.Link) error {
   if rtable == 0 || cidr == "" || cidr == ipam.NoneAllocType || proutes == nil {
     return nil
   }
-  srcIp, srcNet, _ := net.ParseCIDR(cidr)
-  srcPref := &net.IPNet{IP: srcIp, Mask: srcNet.Mask}
-  rule := netlink.NewRule()
-  rule.Src = srcPref
-  rule.Table = rtable
-  err := netlink.RuleAdd(rule)
+  if isRuleNeeded {
+    srcIp, srcNet, _ := net.ParseCIDR(cidr)
+    srcPref := &net.IPNet{IP: srcIp, Mask: srcNet.Mask}
+    rule := netlink.NewRule()
+    rule.Src = srcPref
+    rule.Table = rtable
+    err := netlink.RuleAdd(rule)
+    if err != nil {
+      return errors.New("cannot add rule for policy-based IP routes because:" + err.Error())
+    }
+  }
+  err := addRouteForLink(proutes, cidr, rtable, link)
   if err != nil {
-    return errors.New("cannot add rule for policy-based IP routes because:" + err.Error())
+    return err
+  }
+  return nil
+}
+
+// addLinkToVrf enslaves the link to the VRF device configured in the network
+// The VRF device is created on-demand, and is shared between all interfaces of the Pod connecting to networks with the same VRF
+func addLinkToVrf(link netlink.Link, ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet) error {
+  if dnet.Spec.Options.Vrf == "" {
+    return nil
   }
-  err = addRouteForLink(proutes, cidr, rtable, link)
+  vrf, err := getOrCreateVrf(dnet.Spec.Options.Vrf, uint32(dnet.Spec.Options.RTables))
   if err != nil {
     return err
   }
+  //Enslavement cycles the link, which would flush all IPv6 addresses of the interface without this
+  if ep.Spec.Iface.AddressIPv6 != "" && ep.Spec.Iface.AddressIPv6 != ipam.NoneAllocType {
+    _, err = sysctl.Sysctl(fmt.Sprintf("net.ipv6.conf.%s.keep_addr_on_down", ep.Spec.Iface.Name), "1")
+    if err != nil {
+      return errors.New("failed to set sysctl due to:" + err.Error())
+    }
+  }
+  err = netlink.LinkSetMasterByIndex(link, vrf.Attrs().Index)
+  if err != nil {
+    return errors.New("cannot set VRF as master of the link because:" + err.Error())
+  }
+  return nil
+}
+
+func getOrCreateVrf(vrfName string, rtable uint32) (netlink.Link, error) {
+  link, err := netlink.LinkByName(vrfName)
+  if err == nil {
+    return validateVrf(link, vrfName, rtable)
+  }
+  vrf := &netlink.Vrf {
+    LinkAttrs: netlink.LinkAttrs {
+      Name: vrfName,
+    },
+    Table: rtable,
+  }
+  err = netlink.LinkAdd(vrf)
+  //Another Pod of the same VRF could have created it in the meantime
+  if errors.Is(err, syscall.EEXIST) {
+    link, err = netlink.LinkByName(vrfName)
+    if err != nil {
+      return nil, errors.New("cannot get concurrently created VRF:" + vrfName + " because:" + err.Error())
+    }
+    return validateVrf(link, vrfName, rtable)
+  }
+  if err != nil {
+    return nil, errors.New("cannot create VRF:" + vrfName + " because:" + err.Error())
+  }
+  err = netlink.LinkSetUp(vrf)
+  if err != nil {
+    return nil, errors.New("cannot set VRF:" + vrfName + " UP because:" + err.Error())
+  }
+  return vrf, nil
+}
+
+func validateVrf(link netlink.Link, vrfName string, rtable uint32) (netlink.Link, error) {
+  vrf, isVrf := link.(*netlink.Vrf)
+  if !isVrf {
+    return nil, errors.New("a non-VRF interface named:" + vrfName + " already exists")
+  }
+  if vrf.Table != rtable {
+    return nil, errors.New("VRF:" + vrfName + " already exists with routing table:" + strconv.Itoa(int(vrf.Table)))
+  }
+  return vrf, nil
+}
+
+func deleteVrfIfUnused(vrfName string) error {
+  vrf, err := netlink.LinkByName(vrfName)
+  if err != nil {
+    return nil
+  }
+  links, err := netlink.LinkList()
+  if err != nil {
+    return errors.New("cannot list links because:" + err.Error())
+  }
+  for _, link := range links {
+    if link.Attrs().MasterIndex == vrf.Attrs().Index {
+      return nil
+    }
+  }
+  err = netlink.LinkDel(vrf)
+  if err != nil {
+    return errors.New("cannot delete VRF:" + vrfName + " because:" + err.Error())
+  }
   return nil
 }
 
@@ -306,7 +459,7 @@ func createDummyInterface(ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet) error {
     //Sysctl setting during post-process phase are only applied on the VLAN interface in this case, so need to call this manually for the underlying dummy
     dummyEp := ep.DeepCopy()
     dummyEp.Spec.Iface.Name = ep.ObjectMeta.Name[0:14]
-    err = setDanmEpSysctls(dummyEp)
+    err = setDanmEpSysctls(dummyEp, dnet)
     iface, err := netlink.LinkByName(origDummyName)
     if err != nil {
       return errors.New("cannot find freshly created dummy interface because:" + err.Error())
@@ -344,4 +497,95 @@ func disableDadOnIface(link netlink.Link, ep *danmtypes.DanmEp) error {
   addr, pref, _ := net.ParseCIDR(ep.Spec.Iface.AddressIPv6)
   dadlessAddress := &netlink.Addr{IPNet: &net.IPNet{IP: addr, Mask: pref.Mask}, Flags: syscall.IFA_F_NODAD,}
   return netlink.AddrReplace(link, dadlessAddress)
-}
\ No newline at end of file
+}
+// learnAutoconfiguredIpv6 waits until the link of an autoconfigured IPv6 network acquires a global IPv6 address, and records it into the DanmEp
+// A router solicitation is sent first, so routers advertise the prefix without the Pod waiting for their next unsolicited advertisement
+// DHCPv6 leases are acquired by a client running inside the Pod, so interface creation does not wait for them in DHCPv6 passthrough mode
+func learnAutoconfiguredIpv6(link netlink.Link, ep *danmtypes.DanmEp, dnet *danmtypes.DanmNet) error {
+  if !ipam.IsIpv6Autoconfigured(dnet) {
+    return nil
+  }
+  err := sendRouterSolicitation(link)
+  if err != nil {
+    log.Println("WARNING: router solicitation could not be sent on interface:" + ep.Spec.Iface.Name + " of Pod:" + ep.Spec.Pod + " because:" + err.Error() + ", routers will advertise themselves eventually anyway")
+  }
+  if dnet.Spec.Options.Ipv6Mode != ipam.Ipv6ModeSlaac {
+    return nil
+  }
+  for i := 0; i < Ipv6AutoconfTimeout*1000/RetryInterval; i++ {
+    addr, err := getGlobalIpv6Address(link)
+    if err != nil {
+      return err
+    }
+    if addr != "" {
+      ep.Spec.Iface.AddressIPv6 = addr
+      return nil
+    }
+    time.Sleep(RetryInterval * time.Millisecond)
+  }
+  return errors.New("no IPv6 address was autoconfigured within " + strconv.Itoa(Ipv6AutoconfTimeout) + " seconds")
+}
+
+// sendRouterSolicitation sends a Router Solicitation to the all-routers multicast address, as described in RFC4861 6.3.7
+// The source link-layer address option is omitted, as the message might be sent from the unspecified address while the link-local address is still tentative
+func sendRouterSolicitation(link netlink.Link) error {
+  conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+  if err != nil {
+    return errors.New("cannot open ICMPv6 socket because:" + err.Error())
+  }
+  defer conn.Close()
+  msg := icmp.Message{Type: ipv6.ICMPTypeRouterSolicitation, Code: 0, Body: &icmp.RawBody{Data: []byte{0, 0, 0, 0}}}
+  packet, err := msg.Marshal(nil)
+  if err != nil {
+    return errors.New("cannot marshal Router Solicitation because:" + err.Error())
+  }
+  //Neighbor Discovery messages are dropped by the receivers unless their hop limit is 255
+  cm := &ipv6.ControlMessage{HopLimit: 255, IfIndex: link.Attrs().Index}
+  _, err = conn.IPv6PacketConn().WriteTo(packet, cm, &net.IPAddr{IP: net.IPv6linklocalallrouters})
+  if err != nil {
+    return errors.New("cannot send Router Solicitation because:" + err.Error())
+  }
+  return nil
+}
+
+func getGlobalIpv6Address(link netlink.Link) (string,error) {
+  addrs, err := netlink.AddrList(link, netlink.FAMILY_V6)
+  if err != nil {
+    return "", errors.New("cannot list IPv6 addresses of link because:" + err.Error())
+  }
+  for _, addr := range addrs {
+    if addr.Scope == int(netlink.SCOPE_UNIVERSE) && addr.Flags & syscall.IFA_F_TENTATIVE == 0 {
+      return addr.IPNet.String(), nil
+    }
+  }
+  return "", nil
+}
+
+func addVipToLink(ip string, link netlink.Link) error {
+  addr, pref, err := net.ParseCIDR(ip)
+  if err != nil {
+    return errors.New("cannot parse virtual IP address because:" + err.Error())
+  }
+  vipAddr := &netlink.Addr{IPNet: &net.IPNet{IP: addr, Mask: pref.Mask}}
+  if addr.To4() == nil {
+    vipAddr.Flags = syscall.IFA_F_NODAD
+  }
+  //Replace makes re-assigning the same virtual IP to the same interface idempotent
+  err = netlink.AddrReplace(link, vipAddr)
+  if err != nil {
+    return errors.New("cannot add virtual IP address:" + ip + " to link because:" + err.Error())
+  }
+  return nil
+}
+
+func removeVipFromLink(ip string, link netlink.Link) error {
+  addr, pref, err := net.ParseCIDR(ip)
+  if err != nil {
+    return errors.New("cannot parse virtual IP address because:" + err.Error())
+  }
+  err = netlink.AddrDel(link, &netlink.Addr{IPNet: &net.IPNet{IP: addr, Mask: pref.Mask}})
+  if err != nil && err != syscall.EADDRNOTAVAIL {
+    return errors.New("cannot remove virtual IP address:" + ip + " from link because:" + err.Error())
+  }
+  return nil
+}
@@ -0,0 +1,92 @@
+package danmep
+
+import (
+  "bytes"
+  "errors"
+  "net"
+  "testing"
+  "github.com/vishvananda/netlink"
+)
+
+var announceTcs = []struct {
+  tcName string
+  ips []string
+  count int
+  isSendFailing bool
+  expectedArps []string
+  expectedNas []string
+}{
+  {"noAnnouncementWithZeroCount", []string{"10.0.0.5/24", "2a00::5/64"}, 0, false, nil, nil},
+  {"noAnnouncementWithNegativeCount", []string{"10.0.0.5/24", "2a00::5/64"}, -1, false, nil, nil},
+  {"singleAnnouncement", []string{"10.0.0.5/24", "2a00::5/64"}, 1, false, []string{"10.0.0.5"}, []string{"2a00::5"}},
+  {"repeatedAnnouncements", []string{"10.0.0.5/24", "2a00::5/64"}, 2, false, []string{"10.0.0.5", "10.0.0.5"}, []string{"2a00::5", "2a00::5"}},
+  {"invalidAddressSkipped", []string{"", "none", "10.0.0.5"}, 1, false, nil, nil},
+  {"failureDoesNotStopOthers", []string{"10.0.0.5/24", "10.0.0.6/24", "2a00::5/64"}, 1, true, []string{"10.0.0.5", "10.0.0.6"}, []string{"2a00::5"}},
+}
+
+func TestAnnounceAddresses(t *testing.T) {
+  origArpSender, origNaSender := sendGratuitousArp, sendNeighborAdvertisement
+  defer func() {
+    sendGratuitousArp, sendNeighborAdvertisement = origArpSender, origNaSender
+  }()
+  link := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "eth0", Index: 2}}
+  for _, tc := range announceTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      var sentArps, sentNas []string
+      sendGratuitousArp = func(addr net.IP, ifaceName string) error {
+        sentArps = append(sentArps, addr.String())
+        if tc.isSendFailing {
+          return errors.New("sending failed")
+        }
+        return nil
+      }
+      sendNeighborAdvertisement = func(addr net.IP, announcingLink netlink.Link) error {
+        sentNas = append(sentNas, addr.String())
+        if tc.isSendFailing {
+          return errors.New("sending failed")
+        }
+        return nil
+      }
+      announceAddresses(link, tc.ips, tc.count)
+      if !areAddressesEqual(sentArps, tc.expectedArps) {
+        t.Errorf("Sent gratuitous ARPs:%v do not match with expectation:%v", sentArps, tc.expectedArps)
+      }
+      if !areAddressesEqual(sentNas, tc.expectedNas) {
+        t.Errorf("Sent Neighbor Advertisements:%v do not match with expectation:%v", sentNas, tc.expectedNas)
+      }
+    })
+  }
+}
+
+func TestCreateNaBody(t *testing.T) {
+  addr := net.ParseIP("2a00::5")
+  mac, _ := net.ParseMAC("02:42:ac:11:00:02")
+  body := createNaBody(addr, mac)
+  if len(body) != 28 || body[0] != 0x20 || !bytes.Equal(body[4:20], addr.To16()) {
+    t.Errorf("Neighbor Advertisement:%v does not contain the expected flags, and target address", body)
+  }
+  if body[20] != 2 || body[21] != 1 || !bytes.Equal(body[22:], mac) {
+    t.Errorf("Neighbor Advertisement:%v does not contain the expected target link-layer address option", body)
+  }
+  body = createNaBody(addr, nil)
+  if len(body) != 20 {
+    t.Errorf("Neighbor Advertisement:%v of an interface without MAC should not contain the target link-layer address option", body)
+  }
+  infinibandMac, _ := net.ParseMAC("00:00:00:00:fe:80:00:00:00:00:00:00:02:00:5e:10:00:00:00:01")
+  body = createNaBody(addr, infinibandMac)
+  if len(body) != 20 {
+    t.Errorf("Neighbor Advertisement:%v of an interface with non-Ethernet MAC should not contain the target link-layer address option", body)
+  }
+}
+
+func areAddressesEqual(received, expected []string) bool {
+  if len(received) != len(expected) {
+    return false
+  }
+  for index := range received {
+    if received[index] != expected[index] {
+      return false
+    }
+  }
+  return true
+}
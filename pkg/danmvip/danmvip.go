@@ -0,0 +1,269 @@
+package danmvip
+
+import (
+  "context"
+  "errors"
+  "log"
+  "time"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
+  danminformers "github.com/nokia/danm/crd/client/informers/externalversions"
+  "github.com/nokia/danm/pkg/danmep"
+  "github.com/nokia/danm/pkg/ipam"
+  "github.com/nokia/danm/pkg/netcontrol"
+  apierrors "k8s.io/apimachinery/pkg/api/errors"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+  "k8s.io/client-go/tools/cache"
+)
+
+const (
+  DanmVipKind = "DanmVip"
+  //VipFinalizer keeps a DanmVip until its addresses are removed from their holder, and freed in the network
+  VipFinalizer = "danm.k8s.io/vip"
+  //Periodic resync re-evaluates the DanmVips waiting for their holder DanmEp to appear, or to be released
+  VipResyncPeriod = time.Minute
+)
+
+// VipController reconciles the DanmVips of the cluster
+// The allocator instance reserves, and frees the virtual IPs in the networks, it runs in the Webhook
+// The plumber instances move the virtual IPs between the Pods of their own node, they run in netwatcher
+type VipController struct {
+  Client danmclientset.Interface
+  Controller cache.Controller
+}
+
+// NewVipAllocator returns a VipController reserving the addresses of new DanmVips, and freeing the addresses of deleted ones
+func NewVipAllocator(danmClient danmclientset.Interface) *VipController {
+  reconcile := func(obj interface{}) {
+    vip, isVip := obj.(*danmtypes.DanmVip)
+    if !isVip {
+      return
+    }
+    err := AllocateDanmVip(danmClient, vip)
+    if err != nil {
+      log.Println("ERROR: Addresses of DanmVip:" + vip.ObjectMeta.Namespace + "/" + vip.ObjectMeta.Name + " could not be managed, because:" + err.Error())
+    }
+  }
+  return newVipController(danmClient, reconcile)
+}
+
+// NewVipPlumber returns a VipController moving the addresses of the DanmVips between the Pods running on the node
+// The node is identified by its hostname, the same way as in the Host of DanmEps
+func NewVipPlumber(danmClient danmclientset.Interface, hostName string) *VipController {
+  reconcile := func(obj interface{}) {
+    vip, isVip := obj.(*danmtypes.DanmVip)
+    if !isVip {
+      return
+    }
+    err := PlumbDanmVip(danmClient, vip, hostName)
+    if err != nil {
+      log.Println("ERROR: Addresses of DanmVip:" + vip.ObjectMeta.Namespace + "/" + vip.ObjectMeta.Name + " could not be moved, because:" + err.Error())
+    }
+  }
+  return newVipController(danmClient, reconcile)
+}
+
+func newVipController(danmClient danmclientset.Interface, reconcile func(obj interface{})) *VipController {
+  vipInformerFactory := danminformers.NewSharedInformerFactory(danmClient, VipResyncPeriod)
+  vipController := vipInformerFactory.Danm().V1().DanmVips().Informer()
+  vipController.AddEventHandler(cache.ResourceEventHandlerFuncs{
+    AddFunc: reconcile,
+    UpdateFunc: func(oldObj, newObj interface{}) {reconcile(newObj)},
+  })
+  return &VipController{Client: danmClient, Controller: vipController}
+}
+
+// Run starts reconciling the DanmVips if the API is installed in the cluster, until stopCh is closed
+func (vipController *VipController) Run(stopCh <-chan struct{}) {
+  _, err := vipController.Client.DanmV1().DanmVips("").List(context.TODO(), meta_v1.ListOptions{})
+  if err != nil {
+    log.Println("INFO: DanmVip API is not available, virtual IPs are not managed, because:" + err.Error())
+    return
+  }
+  go vipController.Controller.Run(stopCh)
+}
+
+// AllocateDanmVip reserves the requested virtual IPv4 and/or IPv6 addresses from the allocation pools of the network, and records them in the status of the DanmVip
+// The requested addresses can be either "dynamic", or static IPs, the same way as for Pod interfaces
+// Deleted DanmVips are freed only after their addresses were removed from their holder
+func AllocateDanmVip(danmClient danmclientset.Interface, vip *danmtypes.DanmVip) error {
+  if vip.ObjectMeta.DeletionTimestamp != nil {
+    return FreeDanmVip(danmClient, vip)
+  }
+  if isVipReserved(vip) {
+    return nil
+  }
+  if vip.Spec.Address == "" && vip.Spec.AddressIPv6 == "" {
+    return errors.New("at least one virtual IP address shall be requested")
+  }
+  dnet, err := netcontrol.GetNetworkFromVip(danmClient, vip)
+  if err != nil {
+    return errors.New("network:" + vip.Spec.NetworkName + " cannot be read, because:" + err.Error())
+  }
+  ip4, ip6, err := ipam.Reserve(danmClient, *dnet, vip.Spec.Address, vip.Spec.AddressIPv6)
+  if err != nil {
+    return errors.New("virtual IP address reservation failed for network:" + dnet.ObjectMeta.Name + " with error:" + err.Error())
+  }
+  newVip := vip.DeepCopy()
+  newVip.Status.Address, newVip.Status.AddressIPv6 = ip4, ip6
+  newVip.ObjectMeta.Finalizers = append(newVip.ObjectMeta.Finalizers, VipFinalizer)
+  _, err = danmClient.DanmV1().DanmVips(vip.ObjectMeta.Namespace).Update(context.TODO(), newVip, meta_v1.UpdateOptions{})
+  if err != nil {
+    refreshedNet, refreshErr := netcontrol.RefreshNetwork(danmClient, *dnet)
+    if refreshErr == nil {
+      ipam.GarbageCollectIps(danmClient, refreshedNet, ip4, ip6)
+    }
+    return errors.New("reserved virtual IPs could not be recorded, because:" + err.Error())
+  }
+  return nil
+}
+
+// FreeDanmVip frees the virtual IPs of a deleted DanmVip in the network, and lets the DanmVip go
+// Addresses still held by a Pod are not freed, the netwatcher of the holder's node removes them first
+// Freed addresses are recorded on the DanmVip first, so they are not freed again if removing the finalizer fails
+func FreeDanmVip(danmClient danmclientset.Interface, vip *danmtypes.DanmVip) error {
+  if !hasFinalizer(vip) {
+    return nil
+  }
+  if vip.Status.Endpoint != "" {
+    holder, err := getDanmEp(danmClient, vip.ObjectMeta.Namespace, vip.Status.Endpoint)
+    if err != nil || holder != nil {
+      return err
+    }
+  }
+  if vip.ObjectMeta.Annotations[danmep.IpsFreedAnnotation] != "true" {
+    dnet, err := netcontrol.GetNetworkFromVip(danmClient, vip)
+    if err != nil {
+      return errors.New("network:" + vip.Spec.NetworkName + " cannot be read, because:" + err.Error())
+    }
+    err = ipam.GarbageCollectIps(danmClient, dnet, vip.Status.Address, vip.Status.AddressIPv6)
+    if err != nil {
+      return errors.New("freeing the virtual IPs failed with error:" + err.Error())
+    }
+    freedVip := vip.DeepCopy()
+    if freedVip.ObjectMeta.Annotations == nil {
+      freedVip.ObjectMeta.Annotations = make(map[string]string)
+    }
+    freedVip.ObjectMeta.Annotations[danmep.IpsFreedAnnotation] = "true"
+    vip, err = danmClient.DanmV1().DanmVips(vip.ObjectMeta.Namespace).Update(context.TODO(), freedVip, meta_v1.UpdateOptions{})
+    if err != nil {
+      return errors.New("freed virtual IPs could not be recorded, because:" + err.Error())
+    }
+  }
+  newVip := vip.DeepCopy()
+  newVip.ObjectMeta.Finalizers = nil
+  for _, finalizer := range vip.ObjectMeta.Finalizers {
+    if finalizer != VipFinalizer {
+      newVip.ObjectMeta.Finalizers = append(newVip.ObjectMeta.Finalizers, finalizer)
+    }
+  }
+  _, err := danmClient.DanmV1().DanmVips(vip.ObjectMeta.Namespace).Update(context.TODO(), newVip, meta_v1.UpdateOptions{})
+  if err != nil {
+    return errors.New("finalizer could not be removed, because:" + err.Error())
+  }
+  return nil
+}
+
+// PlumbDanmVip moves the virtual IP addresses of the DanmVip to the interface of the DanmEp set in its spec, when any of the involved Pods runs on the node
+// The addresses are always removed from their current holder first, and only assigned to the new one afterwards, so they are never live in two Pods
+// The new location of the addresses is announced to the network with a gratuitous ARP, or an unsolicited Neighbor Advertisement
+func PlumbDanmVip(danmClient danmclientset.Interface, vip *danmtypes.DanmVip, hostName string) error {
+  if !isVipReserved(vip) {
+    return nil
+  }
+  desiredHolder := vip.Spec.Endpoint
+  if vip.ObjectMeta.DeletionTimestamp != nil {
+    desiredHolder = ""
+  }
+  if vip.Status.Endpoint != "" && vip.Status.Endpoint != desiredHolder {
+    return releaseDanmVip(danmClient, vip, hostName)
+  }
+  if vip.Status.Endpoint == "" && desiredHolder != "" {
+    return assignDanmVip(danmClient, vip, desiredHolder, hostName)
+  }
+  return nil
+}
+
+// releaseDanmVip removes the addresses from their current holder if it runs on the node
+// Holders which are already gone took the addresses with them, so they are released by any node
+func releaseDanmVip(danmClient danmclientset.Interface, vip *danmtypes.DanmVip, hostName string) error {
+  holder, err := getDanmEp(danmClient, vip.ObjectMeta.Namespace, vip.Status.Endpoint)
+  if err != nil {
+    return err
+  }
+  if holder != nil {
+    if holder.Spec.Host != hostName {
+      return nil
+    }
+    err = danmep.RemoveVipFromEp(holder, vip)
+    if err != nil {
+      return errors.New("virtual IPs could not be removed from DanmEp:" + holder.ObjectMeta.Name + " because:" + err.Error())
+    }
+  }
+  newVip := vip.DeepCopy()
+  newVip.Status.Endpoint, newVip.Status.Host = "", ""
+  _, err = danmClient.DanmV1().DanmVips(vip.ObjectMeta.Namespace).Update(context.TODO(), newVip, meta_v1.UpdateOptions{})
+  if err != nil {
+    return errors.New("DanmVip could not be updated after releasing it because:" + err.Error())
+  }
+  return nil
+}
+
+// assignDanmVip adds the addresses to the new holder if it runs on the node
+func assignDanmVip(danmClient danmclientset.Interface, vip *danmtypes.DanmVip, holderName, hostName string) error {
+  holder, err := getDanmEp(danmClient, vip.ObjectMeta.Namespace, holderName)
+  if err != nil || holder == nil || holder.Spec.Host != hostName {
+    return err
+  }
+  err = validateEp(vip, holder)
+  if err != nil {
+    return err
+  }
+  err = danmep.AddVipToEp(holder, vip)
+  if err != nil {
+    return errors.New("virtual IPs could not be added to DanmEp:" + holder.ObjectMeta.Name + " because:" + err.Error())
+  }
+  newVip := vip.DeepCopy()
+  newVip.Status.Endpoint, newVip.Status.Host = holder.ObjectMeta.Name, hostName
+  _, err = danmClient.DanmV1().DanmVips(vip.ObjectMeta.Namespace).Update(context.TODO(), newVip, meta_v1.UpdateOptions{})
+  if err != nil {
+    danmep.RemoveVipFromEp(holder, vip)
+    return errors.New("DanmVip could not be updated with its new holder because:" + err.Error())
+  }
+  return nil
+}
+
+func validateEp(vip *danmtypes.DanmVip, ep *danmtypes.DanmEp) error {
+  if ep.Spec.NetworkName != vip.Spec.NetworkName || ep.Spec.ApiType != vip.Spec.ApiType {
+    return errors.New("DanmEp:" + ep.ObjectMeta.Name + " is not connected to the network:" + vip.Spec.NetworkName + " of DanmVip:" + vip.ObjectMeta.Name)
+  }
+  if ep.ObjectMeta.Namespace != vip.ObjectMeta.Namespace {
+    return errors.New("DanmEp:" + ep.ObjectMeta.Name + " is not in the namespace:" + vip.ObjectMeta.Namespace + " of DanmVip:" + vip.ObjectMeta.Name)
+  }
+  return nil
+}
+
+//getDanmEp returns nil without an error when the DanmEp does not exist
+func getDanmEp(danmClient danmclientset.Interface, namespace, name string) (*danmtypes.DanmEp,error) {
+  ep, err := danmClient.DanmV1().DanmEps(namespace).Get(context.TODO(), name, meta_v1.GetOptions{})
+  if err != nil {
+    if apierrors.IsNotFound(err) {
+      return nil, nil
+    }
+    return nil, errors.New("cannot get DanmEp:" + name + " because:" + err.Error())
+  }
+  return ep, nil
+}
+
+func isVipReserved(vip *danmtypes.DanmVip) bool {
+  return vip.Status.Address != "" || vip.Status.AddressIPv6 != ""
+}
+
+func hasFinalizer(vip *danmtypes.DanmVip) bool {
+  for _, finalizer := range vip.ObjectMeta.Finalizers {
+    if finalizer == VipFinalizer {
+      return true
+    }
+  }
+  return false
+}
@@ -25,6 +25,7 @@ type NetConf struct {
   Kubeconfig          string `json:"kubeconfig"`
   CniConfigDir        string `json:"cniDir"`
   NamingScheme        string `json:"namingScheme"`
+  AnnounceCount       int    `json:"announceCount"`
 }
 
 type CniConfigReader func(netInfo *danmtypes.DanmNet, ipam IpamConfig, ep *danmtypes.DanmEp, cniVersion string) ([]byte, error)
@@ -39,7 +40,8 @@ type CniBackendConfig struct {
 // Interface represents a request coming from the Pod to connect it to one DanmNet during CNI_ADD operation
 // It contains the name of the network object the Pod should be connected to, and other optional requests
 // Pods can influence the scheme of IP allocation (dynamic, static, none),
-// and can ask for the provisioning of policy-based IP routes
+// can ask for the provisioning of policy-based IP routes,
+// and can explicitly select which interface shall own the default route of the Pod
 type Interface struct {
   Network        string `json:"network,omitempty"`
   TenantNetwork  string `json:"tenantNetwork,omitempty"`
@@ -48,9 +50,13 @@ type Interface struct {
   Ip6 string `json:"ip6,omitempty"`
   Proutes  map[string]string `json:"proutes,omitempty"`
   Proutes6 map[string]string `json:"proutes6,omitempty"`
+  DefaultRoute bool `json:"defaultRoute,omitempty"`
   DefaultIfaceName string
   Device string
   SequenceId int
+  //Set by DANM CNI from the defaultRoute claims of all the connections, so they cannot be decoded from the Pod annotation
+  IsDefaultRouteOwnedByOther  bool `json:"-"`
+  IsDefaultRoute6OwnedByOther bool `json:"-"`
 }
 
 type IpamConfig struct {
@@ -0,0 +1,102 @@
+package datastructs
+
+import (
+  "bytes"
+  "errors"
+  "strconv"
+  "strings"
+  "encoding/json"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  core_v1 "k8s.io/api/core/v1"
+)
+
+const (
+  DanmIfDefinitionSyntax = "danm.k8s.io/interfaces"
+  NoneAllocType = "none"
+  NoDefaultRouteOwner = -1
+)
+
+// GetPodInterfaces decodes, and validates the network connections requested by a Pod in its DANM annotation
+func GetPodInterfaces(pod *core_v1.Pod) ([]Interface,error) {
+  var ifaces []Interface
+  for key, val := range pod.Annotations {
+    if strings.Contains(key, DanmIfDefinitionSyntax) {
+      decoder := json.NewDecoder(bytes.NewReader([]byte(val)))
+      //We are using Decoder interface, because it can notify us if any unknown fields were put into the object
+      decoder.DisallowUnknownFields()
+      err := decoder.Decode(&ifaces)
+      if err != nil {
+        return nil, errors.New("Can't create network interfaces for Pod: " + pod.ObjectMeta.Name + " due to badly formatted " + DanmIfDefinitionSyntax + " definition in Pod annotation:" + err.Error())
+      }
+      break
+    }
+  }
+  if err := validateAnnotation(ifaces); err!=nil {
+    return nil, errors.New("DANM annotation is invalid for Pod: " + pod.ObjectMeta.Name + ", because:" + err.Error())
+  }
+  return ifaces, nil
+}
+
+func validateAnnotation(ifaces []Interface) error {
+  for ifaceId, iface := range ifaces {
+    var definedNetworks int
+    if iface.Network        != "" {definedNetworks++}
+    if iface.TenantNetwork  != "" {definedNetworks++}
+    if iface.ClusterNetwork != "" {definedNetworks++}
+    if definedNetworks != 1 {
+      return errors.New("network connection no.:" + strconv.Itoa(ifaceId)+ " contains invalid number of network references:" + strconv.Itoa(definedNetworks))
+    }
+  }
+  return nil
+}
+
+// GetDefaultRouteOwners returns the sequence number of the network connections explicitly claiming the IPv4, and the IPv6 default route of the Pod
+// At most one connection can claim the default route of an IP family. NoDefaultRouteOwner is returned for a family nobody claimed
+func GetDefaultRouteOwners(ifaces []Interface) (int,int,error) {
+  v4Owner, v6Owner := NoDefaultRouteOwner, NoDefaultRouteOwner
+  for ifaceId, iface := range ifaces {
+    if !iface.DefaultRoute {
+      continue
+    }
+    claimsV4, claimsV6 := getClaimedIpFamilies(iface)
+    if !claimsV4 && !claimsV6 {
+      return NoDefaultRouteOwner, NoDefaultRouteOwner, errors.New("network connection no.:" + strconv.Itoa(ifaceId) + " claims the default route, but it does not ask for any IP addresses")
+    }
+    if claimsV4 {
+      if v4Owner != NoDefaultRouteOwner {
+        return NoDefaultRouteOwner, NoDefaultRouteOwner, errors.New("network connections no.:" + strconv.Itoa(v4Owner) + " and no.:" + strconv.Itoa(ifaceId) + " both claim the IPv4 default route")
+      }
+      v4Owner = ifaceId
+    }
+    if claimsV6 {
+      if v6Owner != NoDefaultRouteOwner {
+        return NoDefaultRouteOwner, NoDefaultRouteOwner, errors.New("network connections no.:" + strconv.Itoa(v6Owner) + " and no.:" + strconv.Itoa(ifaceId) + " both claim the IPv6 default route")
+      }
+      v6Owner = ifaceId
+    }
+  }
+  return v4Owner, v6Owner, nil
+}
+
+func getClaimedIpFamilies(iface Interface) (bool,bool) {
+  //Connections without any explicit IP request can still get both kind of addresses from a static backend's own IPAM
+  if iface.Ip == "" && iface.Ip6 == "" {
+    return true, true
+  }
+  return iface.Ip != "" && iface.Ip != NoneAllocType, iface.Ip6 != "" && iface.Ip6 != NoneAllocType
+}
+
+// IsTenantAllowed decides if Pods of the namespace can connect to the network, based on its AllowedTenants whitelist
+func IsTenantAllowed(nameSpace string, netInfo *danmtypes.DanmNet) bool {
+  if len(netInfo.Spec.AllowedTenants) == 0 {
+    return true
+  }
+  var isTenantAllowed bool
+  for _, tenantName := range netInfo.Spec.AllowedTenants {
+    if tenantName == nameSpace {
+      isTenantAllowed = true
+      break
+    }
+  }
+  return isTenantAllowed
+}
@@ -18,8 +18,11 @@ import (
 )
 
 const (
-  NoneAllocType = "none"
+  NoneAllocType = datastructs.NoneAllocType
   DynamicAllocType = "dynamic"
+  Ipv6ModeStatic = "static"
+  Ipv6ModeSlaac = "slaac"
+  Ipv6ModeDhcpv6Passthrough = "dhcpv6-passthrough"
 )
 
 // Reserve inspects the network object received as an input, and allocates an IPv4 or IPv6 address from the appropriate allocation pool
@@ -59,6 +62,9 @@ func Free(danmClient danmclientset.Interface, netInfo danmtypes.DanmNet, rip str
   }
   ripParts := strings.Split(rip, "/")
   ip := net.ParseIP(ripParts[0])
+  if ip != nil && ip.To4() == nil && IsIpv6Autoconfigured(&netInfo) {
+    return nil
+  }
   tempNet := netInfo
   origSpec:= netInfo.Spec
   for {
@@ -93,7 +99,8 @@ func allocateIps(netInfo *danmtypes.DanmNet, req4, req6 string) (string, string,
       return "", "", err
     }
   }
-  if req6 != "" {
+  //IPv6 addresses of autoconfigured networks are assigned by the network itself, DANM IPAM has nothing to reserve
+  if req6 != "" && !IsIpv6Autoconfigured(netInfo) {
     if netInfo.Spec.Options.Net6 != "" && netInfo.Spec.Options.Pool6.Cidr == "" {
       InitV6AllocFields(netInfo)
     }
@@ -108,6 +115,12 @@ func allocateIps(netInfo *danmtypes.DanmNet, req4, req6 string) (string, string,
   return ip4, ip6, err
 }
 
+// IsIpv6Autoconfigured returns true when the IPv6 addresses of the network are not managed by DANM IPAM,
+// but are instead assigned via router advertisements (SLAAC), or by a DHCPv6 server
+func IsIpv6Autoconfigured(netInfo *danmtypes.DanmNet) bool {
+  return netInfo.Spec.Options.Ipv6Mode == Ipv6ModeSlaac || netInfo.Spec.Options.Ipv6Mode == Ipv6ModeDhcpv6Passthrough
+}
+
 func InitV6AllocFields(netInfo *danmtypes.DanmNet) {
   InitV6PoolCidr(netInfo)
   netInfo.Spec.Options.Pool6.Start, netInfo.Spec.Options.Pool6.End, netInfo.Spec.Options.Alloc6 =
@@ -159,12 +172,9 @@ func allocateAddress(pool *danmtypes.IpPool, alloc, reqType, allocCidr, netCidr
     //I guess we are doing backward compatibility now :)
     //You used to be able to define a static IP in CIDR format, so now we need to trim the suffix if it is unnecessarily there
     requestParts := strings.Split(reqType, "/")
-    ip := net.ParseIP(requestParts[0])
-    if ip == nil {
-      return alloc, "", errors.New("static IP allocation failed, requested static IP:" + reqType + " is not a valid IP")
-    }
-    if !(netSubnet.Contains(ip)) {
-      return alloc, "", errors.New("static IP allocation failed, requested static IP:" + reqType + " is outside the network's CIDR:" + netCidr)
+    ip, err := parseStaticIp(reqType, netSubnet)
+    if err != nil {
+      return alloc, "", err
     }
     prefix,_ := netSubnet.Mask.Size()
     allocatedIp = requestParts[0] + "/" + strconv.Itoa(prefix)
@@ -182,6 +192,34 @@ func allocateAddress(pool *danmtypes.IpPool, alloc, reqType, allocCidr, netCidr
   return ba.Encode(), allocatedIp, nil
 }
 
+// ValidateIpRequest checks whether an IP request of a Pod could be satisfied from the network's CIDR, without reserving anything
+// It does not check if a requested static IP is already in use, as that can only be decided at the time of the reservation
+func ValidateIpRequest(reqType, netCidr string) error {
+  if reqType == "" || reqType == NoneAllocType {
+    return nil
+  }
+  _, netSubnet, err := net.ParseCIDR(netCidr)
+  if err != nil {
+    return errors.New("IP address cannot be allocated for an L2 network!")
+  }
+  if reqType == DynamicAllocType {
+    return nil
+  }
+  _, err = parseStaticIp(reqType, netSubnet)
+  return err
+}
+
+func parseStaticIp(reqType string, netSubnet *net.IPNet) (net.IP,error) {
+  ip := net.ParseIP(strings.Split(reqType, "/")[0])
+  if ip == nil {
+    return nil, errors.New("static IP allocation failed, requested static IP:" + reqType + " is not a valid IP")
+  }
+  if !(netSubnet.Contains(ip)) {
+    return nil, errors.New("static IP allocation failed, requested static IP:" + reqType + " is outside the network's CIDR:" + netSubnet.String())
+  }
+  return ip, nil
+}
+
 func getAllocRangeBasedOnCidr(pool *danmtypes.IpPool, cidr *net.IPNet) (uint32,uint32) {
   var beginAsInt, endAsInt uint32
   if cidr.IP.To4() != nil {
@@ -319,6 +357,11 @@ func DoV6CidrsIntersect(masterCidr, subCidr *net.IPNet) bool {
   return false
 }
 
+//DoCidrsOverlap returns true if the two subnets share at least one IP address
+func DoCidrsOverlap(cidr1, cidr2 *net.IPNet) bool {
+  return cidr1.Contains(cidr2.IP) || cidr2.Contains(cidr1.IP)
+}
+
 func GetMaxUsableV6Prefix(dnet *danmtypes.DanmNet) int {
   if dnet.Spec.Options.Cidr == "" {
     return datastructs.MaxV6PrefixLength
@@ -371,6 +414,53 @@ func InitAllocPool(netCidr, start, end, alloc string, routes map[string]string)
   return start, end, alloc
 }
 
+//GetAllocatedIps returns the IPs reserved in an allocation bitarray belonging to the given CIDR
+//Network, and broadcast addresses, as well as the gateways of the network are not considered to be allocated
+func GetAllocatedIps(alloc, allocCidr string, routes map[string]string) []net.IP {
+  var allocatedIps []net.IP
+  _, subnet, err := net.ParseCIDR(allocCidr)
+  if alloc == "" || err != nil {
+    return allocatedIps
+  }
+  gateways := make(map[string]bool)
+  for _, gw := range routes {
+    gateways[net.ParseIP(gw).String()] = true
+  }
+  ba := bitarray.NewBitArrayFromBase64(alloc)
+  lastIndex := GetIndexOfIp(GetBroadcastAddress(subnet), subnet)
+  for i := uint32(1); i < lastIndex && i < ba.Len(); i++ {
+    if !ba.Get(i) {
+      continue
+    }
+    ip, _, _ := net.ParseCIDR(getIpFromIndex(i, subnet, subnet))
+    if !gateways[ip.String()] {
+      allocatedIps = append(allocatedIps, ip)
+    }
+  }
+  return allocatedIps
+}
+
+//RemapAllocationArray moves the allocations of a bitarray belonging to the old CIDR into a newly sized one, belonging to the new CIDR
+//Returns error if any of the allocated IPs would fall outside the new CIDR
+func RemapAllocationArray(alloc, oldCidr, newCidr string, oldRoutes, newRoutes map[string]string) (string,error) {
+  _, newSubnet, err := net.ParseCIDR(newCidr)
+  if err != nil {
+    return alloc, errors.New("invalid CIDR:" + newCidr)
+  }
+  bitArray, err := bitarray.CreateBitArrayFromIpnet(newSubnet)
+  if err != nil {
+    return alloc, err
+  }
+  reserveGatewayIps(newRoutes, bitArray, newSubnet)
+  for _, ip := range GetAllocatedIps(alloc, oldCidr, oldRoutes) {
+    if !newSubnet.Contains(ip) {
+      return alloc, errors.New("allocated IP:" + ip.String() + " would fall outside the new CIDR:" + newCidr)
+    }
+    bitArray.Set(GetIndexOfIp(ip, newSubnet))
+  }
+  return bitArray.Encode(), nil
+}
+
 func GetBroadcastAddress(subnet *net.IPNet) (net.IP) {
   _, lastIp := cidr.AddressRange(subnet)
   return lastIp
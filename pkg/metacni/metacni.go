@@ -1,7 +1,6 @@
 package metacni
 
 import (
-  "bytes"
   "context"
   "errors"
   "fmt"
@@ -10,7 +9,6 @@ import (
   "os"
   "runtime"
   "strconv"
-  "strings"
   "encoding/json"
   "github.com/containernetworking/cni/pkg/skel"
   "github.com/containernetworking/cni/pkg/types"
@@ -34,8 +32,6 @@ import (
 )
 
 const (
-  danmApiPath = "danm.k8s.io"
-  danmIfDefinitionSyntax = danmApiPath + "/interfaces"
   v1Endpoint = "/api/v1/"
   cniVersion = "0.3.1"
   defaultNetworkName = "default"
@@ -132,6 +128,9 @@ func loadNetConf(bytes []byte) error {
   if DanmConfig.CniConfigDir == "" {
     DanmConfig.CniConfigDir = DefaultCniDir
   }
+  if DanmConfig.AnnounceCount == 0 {
+    DanmConfig.AnnounceCount = danmep.DefaultAnnounceCount
+  }
   return nil
 }
 
@@ -154,7 +153,7 @@ func extractCniArgs(args *skel.CmdArgs) (*datastructs.CniArgs,error) {
 }
 
 func getPod(args *datastructs.CniArgs) error {
-  k8sClient, err := createK8sClient(DanmConfig.Kubeconfig)
+  k8sClient, err := CreateK8sClient(DanmConfig.Kubeconfig)
   if err != nil {
     return errors.New("cannot create K8s REST client due to error:" + err.Error())
   }
@@ -166,7 +165,7 @@ func getPod(args *datastructs.CniArgs) error {
   return nil
 }
 
-func createK8sClient(kubeconfig string) (kubernetes.Interface, error) {
+func CreateK8sClient(kubeconfig string) (kubernetes.Interface, error) {
   config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
   if err != nil {
     return nil, err
@@ -175,41 +174,20 @@ func createK8sClient(kubeconfig string) (kubernetes.Interface, error) {
 }
 
 func extractConnections(args *datastructs.CniArgs) error {
-  var ifaces []datastructs.Interface
-  for key, val := range args.Pod.Annotations {
-    if strings.Contains(key, danmIfDefinitionSyntax) {
-      decoder := json.NewDecoder(bytes.NewReader([]byte(val)))
-      //We are using Decoder interface, because it can notify us if any unknown fields were put into the object
-      decoder.DisallowUnknownFields()
-      err := decoder.Decode(&ifaces)
-      if err != nil {
-        return errors.New("Can't create network interfaces for Pod: " + args.Pod.ObjectMeta.Name + " due to badly formatted " + danmIfDefinitionSyntax + " definition in Pod annotation:" + err.Error())
-      }
-      break
-    }
-  }
-  if err := validateAnnotation(ifaces); err!=nil {
-    return errors.New("DANM annotation is invalid for Pod: " + args.Pod.ObjectMeta.Name + ", because:" + err.Error())
+  ifaces, err := datastructs.GetPodInterfaces(args.Pod)
+  if err != nil {
+    return err
   }
   args.Interfaces = ifaces
   return nil
 }
 
-func validateAnnotation(ifaces []datastructs.Interface) error {
-  for ifaceId, iface := range ifaces {
-    var definedNetworks int
-    if iface.Network        != "" {definedNetworks++}
-    if iface.TenantNetwork  != "" {definedNetworks++}
-    if iface.ClusterNetwork != "" {definedNetworks++}
-    if definedNetworks != 1 {
-      return errors.New("network connection no.:" + strconv.Itoa(ifaceId)+ " contains invalid number of network references:" + strconv.Itoa(definedNetworks))
-    }
-  }
-  return nil
-}
-
 func setupNetworking(args *datastructs.CniArgs) (*current.Result, error) {
-  err := preparePodForIpv6(args)
+  v4RouteOwner, v6RouteOwner, err := datastructs.GetDefaultRouteOwners(args.Interfaces)
+  if err != nil {
+    return nil, errors.New("default route cannot be selected for Pod:" + args.PodName + " because:" + err.Error())
+  }
+  err = preparePodForIpv6(args)
   if err != nil {
     return nil, errors.New("failed to prepare Pod for IPv6 due to:" + err.Error())
   }
@@ -231,6 +209,8 @@ func setupNetworking(args *datastructs.CniArgs) (*current.Result, error) {
   for nicID, nicParams := range args.Interfaces {
     nicParams.SequenceId = nicID
     nicParams.DefaultIfaceName = defaultIfName
+    nicParams.IsDefaultRouteOwnedByOther  = v4RouteOwner != datastructs.NoDefaultRouteOwner && v4RouteOwner != nicID
+    nicParams.IsDefaultRoute6OwnedByOther = v6RouteOwner != datastructs.NoDefaultRouteOwner && v6RouteOwner != nicID
     netInfo, err := netcontrol.GetNetworkFromInterface(danmClient, nicParams, args.Pod.ObjectMeta.Namespace)
     if err != nil {
       syncher.PushResult("", errors.New("failed to get network object for Pod:" + args.Pod.ObjectMeta.Name +
@@ -277,10 +257,16 @@ func preparePodForIpv6(args *datastructs.CniArgs) error {
 }
 
 func createIface(args *datastructs.CniArgs, danmClient danmclientset.Interface, netInfo *danmtypes.DanmNet, nicParams datastructs.Interface, syncher *syncher.Syncher, allocatedDevices map[string]*[]string) error {
-  if !isTenantAllowed(args, netInfo) {
+  if !datastructs.IsTenantAllowed(args.Pod.ObjectMeta.Namespace, netInfo) {
     return errors.New("Pod:" + args.PodName + "'s namespace:" + args.Namespace + " is not in the AllowedTenants whitelist of network:" + netInfo.ObjectMeta.Name)
   }
-  var err error
+  nodeName, err := getNodeName(args)
+  if err == nil {
+    err = netcontrol.CheckNetworkProvisioning(danmClient, nodeName, netInfo)
+    if err != nil {
+      return err
+    }
+  }
   if cnidel.IsDeviceNeeded(netInfo.Spec.NetworkType) {
     if _, ok := allocatedDevices[netInfo.Spec.Options.DevicePool]; !ok {
       checkpoint, err := checkpoint_utils.GetCheckpoint()
@@ -301,18 +287,12 @@ func createIface(args *datastructs.CniArgs, danmClient danmclientset.Interface,
   return nil
 }
 
-func isTenantAllowed(args *datastructs.CniArgs, netInfo *danmtypes.DanmNet) bool {
-  if len(netInfo.Spec.AllowedTenants) == 0 {
-    return true
-  }
-  var isTenantAllowed bool
-  for _, tenantName := range netInfo.Spec.AllowedTenants {
-    if tenantName == args.Pod.ObjectMeta.Namespace {
-      isTenantAllowed = true
-      break
-    }
+//getNodeName returns the name of the Node the Pod was scheduled to, which can differ from the hostname, e.g. when kubelet is started with --hostname-override
+func getNodeName(args *datastructs.CniArgs) (string,error) {
+  if args.Pod != nil && args.Pod.Spec.NodeName != "" {
+    return args.Pod.Spec.NodeName, nil
   }
-  return isTenantAllowed
+  return os.Hostname()
 }
 
 func getAllocatedDevices(args *datastructs.CniArgs, checkpoint multus_types.ResourceClient, devicePool string)(*[]string, error){
@@ -359,15 +339,81 @@ func createNic(syncher *syncher.Syncher, danmClient danmclientset.Interface, ifa
     syncher.PushResult(ep.Spec.NetworkName, err, cniResult)
     return
   }
-  err = danmep.PostProcessInterface(ep, netInfo)
+  routedNet, err := selectDefaultRoutes(iface, netInfo)
+  if err != nil {
+    danmep.DeleteDanmEp(danmClient, ep, netInfo)
+    syncher.PushResult(ep.Spec.NetworkName, err, nil)
+    return
+  }
+  origIp6 := ep.Spec.Iface.AddressIPv6
+  err = danmep.PostProcessInterface(ep, routedNet, DanmConfig.AnnounceCount)
   if err != nil {
     danmep.DeleteDanmEp(danmClient, ep, netInfo)
     syncher.PushResult(ep.Spec.NetworkName, errors.New("Post-processing failed for interface:" + ep.Spec.Iface.Name + " because:" + err.Error()), nil)
     return
   }
+  //Autoconfigured IPv6 addresses are only known after the interface is up, so they are recorded afterwards
+  if ep.Spec.Iface.AddressIPv6 != origIp6 {
+    err = danmep.UpdateDanmEp(danmClient, ep)
+    if err != nil {
+      danmep.DeleteDanmEp(danmClient, ep, netInfo)
+      syncher.PushResult(ep.Spec.NetworkName, errors.New("DanmEp:" + ep.ObjectMeta.Name + " could not be updated with the autoconfigured IPv6 address because:" + err.Error()), nil)
+      return
+    }
+    if cniResult != nil {
+      AddIpToResult(ep.Spec.Iface.AddressIPv6,"6",cniResult)
+    }
+  }
   syncher.PushResult(ep.Spec.NetworkName, nil, cniResult)
 }
 
+// selectDefaultRoutes returns a copy of the network, only containing the default IP routes this specific interface is supposed to provision
+// Default routes of the network are left out when another interface of the same Pod explicitly claimed them
+func selectDefaultRoutes(iface datastructs.Interface, netInfo *danmtypes.DanmNet) (*danmtypes.DanmNet,error) {
+  routedNet := netInfo.DeepCopy()
+  if iface.IsDefaultRouteOwnedByOther {
+    routedNet.Spec.Options.Routes = removeDefaultRoute(routedNet.Spec.Options.Routes)
+  }
+  if iface.IsDefaultRoute6OwnedByOther {
+    routedNet.Spec.Options.Routes6 = removeDefaultRoute(routedNet.Spec.Options.Routes6)
+  }
+  if iface.DefaultRoute && !hasDefaultRoute(routedNet.Spec.Options.Routes) && !hasDefaultRoute(routedNet.Spec.Options.Routes6) {
+    return nil, errors.New("interface connected to network:" + netInfo.ObjectMeta.Name + " claims the default route of the Pod, but the network does not define any default IP routes")
+  }
+  return routedNet, nil
+}
+
+func removeDefaultRoute(routes map[string]string) map[string]string {
+  if routes == nil {
+    return nil
+  }
+  filteredRoutes := make(map[string]string)
+  for dst, gw := range routes {
+    if !isDefaultRoute(dst) {
+      filteredRoutes[dst] = gw
+    }
+  }
+  return filteredRoutes
+}
+
+func hasDefaultRoute(routes map[string]string) bool {
+  for dst := range routes {
+    if isDefaultRoute(dst) {
+      return true
+    }
+  }
+  return false
+}
+
+func isDefaultRoute(dst string) bool {
+  _, ipnet, err := net.ParseCIDR(dst)
+  if err != nil {
+    return false
+  }
+  prefix, _ := ipnet.Mask.Size()
+  return prefix == 0
+}
+
 func createDelegatedInterface(danmClient danmclientset.Interface, wasIpReservedByDanmIpam bool, ep *danmtypes.DanmEp, netInfo *danmtypes.DanmNet, args *datastructs.CniArgs) (*current.Result,error) {
   origV4Address := ep.Spec.Iface.Address
   origV6Address := ep.Spec.Iface.AddressIPv6
@@ -487,7 +533,12 @@ func deleteNic(netInfo *danmtypes.DanmNet, ep *danmtypes.DanmEp) error {
   } else {
     err = danmep.DeleteIpvlanInterface(ep)
   }
-  return err
+  //The VRF is torn down even if the interface deletion failed, as the interface might have been already gone
+  vrfErr := danmep.DeleteVrf(ep, netInfo)
+  if err != nil {
+    return err
+  }
+  return vrfErr
 }
 
 func GetInterfaces(args *skel.CmdArgs) error {
@@ -2,6 +2,9 @@ package metacni
 
 import (
   "testing"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/danmep"
+  "github.com/nokia/danm/pkg/datastructs"
 )
 
 var devicePool0 = "pool0"
@@ -31,3 +34,37 @@ func TestPopDevice(t *testing.T) {
     t.Errorf("Empty pool should expect error.")
   }
 }
+
+func TestSelectDefaultRoutes(t *testing.T) {
+  dnet := &danmtypes.DanmNet{Spec: danmtypes.DanmNetSpec{Options: danmtypes.DanmNetOption{
+    Routes: map[string]string{"0.0.0.0/0": "10.0.0.1", "10.20.0.0/24": "10.0.0.1"},
+    Routes6: map[string]string{"::/0": "2a00::1"},
+  }}}
+  routedNet, err := selectDefaultRoutes(datastructs.Interface{IsDefaultRouteOwnedByOther: true}, dnet)
+  if err != nil || len(routedNet.Spec.Options.Routes) != 1 || len(routedNet.Spec.Options.Routes6) != 1 {
+    t.Errorf("Only the IPv4 default route should have been removed.")
+  }
+  if len(dnet.Spec.Options.Routes) != 2 {
+    t.Errorf("Original network should not be modified.")
+  }
+  l3Net := &danmtypes.DanmNet{Spec: danmtypes.DanmNetSpec{Options: danmtypes.DanmNetOption{Routes: map[string]string{"10.20.0.0/24": "10.0.0.1"}}}}
+  _, err = selectDefaultRoutes(datastructs.Interface{DefaultRoute: true}, l3Net)
+  if err == nil {
+    t.Errorf("Claiming the default route of a network without default routes should expect error.")
+  }
+}
+
+func TestLoadNetConfAnnounceCount(t *testing.T) {
+  announceCounts := map[string]int{
+    `{"name":"danm"}`: danmep.DefaultAnnounceCount,
+    `{"name":"danm","announceCount":0}`: danmep.DefaultAnnounceCount,
+    `{"name":"danm","announceCount":3}`: 3,
+    `{"name":"danm","announceCount":-1}`: -1,
+  }
+  for conf, expectedCount := range announceCounts {
+    err := loadNetConf([]byte(conf))
+    if err != nil || DanmConfig.AnnounceCount != expectedCount {
+      t.Errorf("AnnounceCount of CNI config:%s should be:%d, but it is:%d", conf, expectedCount, DanmConfig.AnnounceCount)
+    }
+  }
+}
@@ -15,6 +15,12 @@ const (
   ip6MulticastCidr = "ff02::0/16"
   maxVlanId = 4094
   maxVxlanId = 16777214
+  //VxlanDefaultPort is the IANA assigned destination UDP port of VxLAN
+  VxlanDefaultPort = 4789
+  //VxlanModeMulticast delivers BUM traffic through a multicast group derived from the VNI
+  VxlanModeMulticast = "multicast"
+  //VxlanModeUnicast replicates BUM traffic to the VTEPs of all the other nodes, for underlays without multicast
+  VxlanModeUnicast = "unicast"
 )
 
 // LinkInfo is an absract struct to represent a host NIC of a special type: either VLAN, or VxLAN
@@ -77,7 +83,54 @@ func setupHost(dnet *danmtypes.DanmNet) error {
   if err != nil {
     return err
   }
-  return setupVxlan(vxlanId, netId, hdev)
+  return setupVxlan(vxlanId, netId, hdev, getVxlanParams(dnet))
+}
+
+// IsUnicastVxlan tells whether the VxLAN of the network uses head-end replication instead of multicast
+func IsUnicastVxlan(dnet *danmtypes.DanmNet) bool {
+  return dnet.Spec.Options.Vxlan != 0 && dnet.Spec.Options.VxlanMode == VxlanModeUnicast
+}
+
+// vxlanParams are the tunable attributes of a VxLAN host interface, as requested by its network
+type vxlanParams struct {
+  isUnicast bool
+  port int
+  ttl int
+  tos int
+  sourceCidr string
+  learning bool
+  group string
+}
+
+// AreVxlanParamsEqual tells whether the VxLAN host interfaces of two versions of a network would be configured the same way
+func AreVxlanParamsEqual(oldDnet, newDnet *danmtypes.DanmNet) bool {
+  return getVxlanParams(oldDnet) == getVxlanParams(newDnet)
+}
+
+// GetVxlanPort returns the destination UDP port of the VxLAN host interface of the network
+func GetVxlanPort(dnet *danmtypes.DanmNet) int {
+  if dnet.Spec.Options.VxlanPort != 0 {
+    return dnet.Spec.Options.VxlanPort
+  }
+  return VxlanDefaultPort
+}
+
+func getVxlanParams(dnet *danmtypes.DanmNet) vxlanParams {
+  params := vxlanParams {
+    isUnicast:  IsUnicastVxlan(dnet),
+    port:       GetVxlanPort(dnet),
+    ttl:        dnet.Spec.Options.VxlanTtl,
+    tos:        dnet.Spec.Options.VxlanTos,
+    sourceCidr: dnet.Spec.Options.VxlanSourceCidr,
+    learning:   true,
+  }
+  if dnet.Spec.Options.VxlanLearning != nil {
+    params.learning = *dnet.Spec.Options.VxlanLearning
+  }
+  if !params.isUnicast {
+    params.group = dnet.Spec.Options.VxlanGroup
+  }
+  return params
 }
 
 func setupVlan(vlanId int, netId, hdev string) error {
@@ -125,6 +178,11 @@ func addLink(link netlink.Link) error {
   if err != nil {
     return err
   }
+  //Marking the interface as ours, so reconciliation knows it can be garbage collected
+  err = netlink.LinkSetAlias(link, DanmLinkAlias)
+  if err != nil {
+    return err
+  }
   err = netlink.LinkSetUp(link)
   if err != nil {
     return err
@@ -142,7 +200,7 @@ func determineVlanHdev(vlanId int, netId, hdev string) string {
   return netId + "." + strconv.Itoa(vlanId)
 }
 
-func setupVxlan(vxlanId int, netId, hdev string) error {
+func setupVxlan(vxlanId int, netId, hdev string, params vxlanParams) error {
   vxlanName := "vx_"+netId
   shouldInterfaceBeCreated, hostLink, err := shouldInterfaceBeCreated(vxlanId, vxlanName, hdev)
   if err != nil {
@@ -150,31 +208,29 @@ func setupVxlan(vxlanId int, netId, hdev string) error {
   } else if !shouldInterfaceBeCreated {
     return nil
   }
-  mcastIP, err := getMulticastIp(netlink.FAMILY_V4, strconv.Itoa(vxlanId))
-  if err != nil {
-    return err
+  addr := getVxlanSourceIp(hostLink.link, params.sourceCidr)
+  if addr == nil && params.sourceCidr != "" {
+    return errors.New("VxLAN interface cannot be set-up on top of a host interface:" + hdev + ", which does not have an IP in source CIDR:" + params.sourceCidr)
   }
-  addr, mcast := parseVxlanHostIp(netlink.FAMILY_V4, hostLink.link, mcastIP)
-  if addr.String() == "<nil>" {
-    mcastIP, tempErr := getMulticastIp(netlink.FAMILY_V6, strconv.Itoa(vxlanId))
-    if tempErr != nil {
-      return tempErr
-    }
-    addr, mcast = parseVxlanHostIp(netlink.FAMILY_V6, hostLink.link, mcastIP)
-  }
-  if addr.String() == "<nil>" {
+  if addr == nil {
     return errors.New("VxLAN interface cannot be set-up on top of a host interface:" + hdev + ", which does not have an IP")
   }
+  mcast, err := getVxlanGroup(vxlanId, addr, params)
+  if err != nil {
+    return err
+  }
   vxlan := &netlink.Vxlan {
     LinkAttrs: netlink.LinkAttrs {
       Name: vxlanName,
     },
     VxlanId:      hostLink.interfaceId,
     VtepDevIndex: hostLink.link.Attrs().Index,
-    Port:         4789,
+    Port:         params.port,
     Group:        mcast,
     SrcAddr:      addr,
-    Learning:     true,
+    TTL:          params.ttl,
+    TOS:          params.tos,
+    Learning:     params.learning,
     L2miss:       true,
     L3miss:       true,
   }
@@ -185,6 +241,51 @@ func setupVxlan(vxlanId int, netId, hdev string) error {
   return nil
 }
 
+//getVxlanSourceIp returns the first universe scope address of the host device, preferring IPv4
+//When a source CIDR is configured, only the addresses inside it are considered
+func getVxlanSourceIp(hdev netlink.Link, sourceCidr string) net.IP {
+  var sourceNet *net.IPNet
+  if sourceCidr != "" {
+    var err error
+    _, sourceNet, err = net.ParseCIDR(sourceCidr)
+    if err != nil {
+      return nil
+    }
+  }
+  for _, ipFamily := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+    addresses, err := netlink.AddrList(hdev, ipFamily)
+    if err != nil {
+      continue
+    }
+    for _, address := range addresses {
+      if address.Scope == syscall.RT_SCOPE_UNIVERSE && (sourceNet == nil || sourceNet.Contains(address.IPNet.IP)) {
+        return address.IPNet.IP
+      }
+    }
+  }
+  return nil
+}
+
+//getVxlanGroup returns the configured multicast group of the VxLAN, or derives one from its ID in the IP family of the source address
+//Unicast VxLANs have no group
+func getVxlanGroup(vxlanId int, sourceIp net.IP, params vxlanParams) (net.IP, error) {
+  if params.isUnicast {
+    return nil, nil
+  }
+  ipFamily := netlink.FAMILY_V4
+  if sourceIp.To4() == nil {
+    ipFamily = netlink.FAMILY_V6
+  }
+  if params.group == "" {
+    return getMulticastIp(ipFamily, strconv.Itoa(vxlanId))
+  }
+  group := net.ParseIP(params.group)
+  if group == nil || (group.To4() == nil) != (ipFamily == netlink.FAMILY_V6) {
+    return nil, errors.New("multicast group:" + params.group + " is not in the same IP family as the VxLAN source address:" + sourceIp.String())
+  }
+  return group, nil
+}
+
 func getMulticastIp(ipFamily int, vxlanId string ) (net.IP, error) {
   vxlanIdInt, err := strconv.Atoi(vxlanId)
   if err != nil {
@@ -206,20 +307,3 @@ func getMulticastIp(ipFamily int, vxlanId string ) (net.IP, error) {
   }
   return mcastIP, nil
 }
-
-func parseVxlanHostIp(ipFamily int, hdev netlink.Link, mcastFilter net.IP) (net.IP, net.IP) {
-  var hostAddr net.IP
-  var hostMultiCastAddr net.IP
-  addresses, err := netlink.AddrList(hdev, ipFamily)
-  if err != nil {
-    return hostAddr, hostMultiCastAddr
-  }
-  for _, x := range addresses {
-    if x.Scope == syscall.RT_SCOPE_UNIVERSE {
-      hostAddr = x.IPNet.IP
-      hostMultiCastAddr = mcastFilter
-      return hostAddr, hostMultiCastAddr
-    }
-  }
-  return hostAddr, hostMultiCastAddr
-}
@@ -15,6 +15,7 @@ import (
   "github.com/nokia/danm/pkg/datastructs"
   meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
   apierrors "k8s.io/apimachinery/pkg/api/errors"
+  "k8s.io/client-go/kubernetes"
   "k8s.io/client-go/rest"
   "k8s.io/client-go/tools/cache"
 )
@@ -25,6 +26,9 @@ const (
   DanmNetKind = "DanmNet"
   TenantNetworkKind = "TenantNetwork"
   ClusterNetworkKind = "ClusterNetwork"
+  NodeNetworkStateKind = "NodeNetworkState"
+  //NodeNameEnv is the environment variable the name of the Node is passed in through the downward API
+  NodeNameEnv = "NODE_NAME"
 )
 
 // NetWatcher represents an object watching the K8s API for changes in all three network management API paths
@@ -34,6 +38,16 @@ type NetWatcher struct {
   Clients map[string]danmclientset.Interface
   Controllers map[string]cache.Controller
   StopChan *chan struct{}
+  KubeClient kubernetes.Interface
+  //NodeName is the name of the K8s Node, NodeNetworkStates are named after it
+  NodeName string
+  //HostName is the hostname of the node, DanmEps record it as their Host
+  HostName string
+  reconcileTrigger chan struct{}
+  nodeOwner *meta_v1.OwnerReference
+  isDiscoveryIncomplete bool
+  reconcileInterval time.Duration
+  isRepairDisabled bool
 }
 
 // NewWatcher initializes and returns a new NetWatcher object
@@ -45,6 +59,18 @@ func NewWatcher(cfg *rest.Config, stopChan  *chan struct{}) (*NetWatcher,error)
     Clients: make(map[string]danmclientset.Interface),
     Controllers: make(map[string]cache.Controller),
     StopChan: stopChan,
+    reconcileTrigger: make(chan struct{}, 1),
+  }
+  hostName, err := os.Hostname()
+  if err != nil {
+    return nil, errors.New("hostname of the node cannot be determined, because:" + err.Error())
+  }
+  netWatcher.HostName = hostName
+  //The Node name can differ from the hostname, e.g. when kubelet is started with --hostname-override
+  netWatcher.NodeName = os.Getenv(NodeNameEnv)
+  if netWatcher.NodeName == "" {
+    log.Println("WARNING: " + NodeNameEnv + " is not set, hostname:" + hostName + " is used as the name of the Node")
+    netWatcher.NodeName = hostName
   }
   //this is how we test if the specific API is used within the cluster, or not
   //we can only create an Informer for an existing API, otherwise we get errors
@@ -64,6 +90,7 @@ func NewWatcher(cfg *rest.Config, stopChan  *chan struct{}) (*NetWatcher,error)
       break
     }
   }
+  netWatcher.checkDiscoveryResult(err)
   tnetClient, err := danmclientset.NewForConfig(cfg)
   if err != nil {
     return nil, err
@@ -80,6 +107,7 @@ func NewWatcher(cfg *rest.Config, stopChan  *chan struct{}) (*NetWatcher,error)
       break
     }
   }
+  netWatcher.checkDiscoveryResult(err)
   cnetClient, err := danmclientset.NewForConfig(cfg)
   if err != nil {
     return nil, err
@@ -96,13 +124,44 @@ func NewWatcher(cfg *rest.Config, stopChan  *chan struct{}) (*NetWatcher,error)
       break
     }
   }
+  netWatcher.checkDiscoveryResult(err)
   if len(netWatcher.Controllers) == 0 {
     return nil, errors.New("no network management APIs are installed in the cluster, netwatcher cannot start!")
   }
   log.Println("Number of watchers started for recognized APIs:" + strconv.Itoa(len(netWatcher.Controllers)))
+  //NodeNetworkStates are optional, without them provisioning state is not reported, and unicast VxLAN peers are not learnt
+  nodeStateClient, err := danmclientset.NewForConfig(cfg)
+  if err != nil {
+    return nil, err
+  }
+  for i := 0; i < MaxRetryCount; i++ {
+    log.Println("INFO: Trying to discover NodeNetworkState API in the cluster...")
+    _, err = nodeStateClient.DanmV1().NodeNetworkStates().List(context.TODO(), meta_v1.ListOptions{})
+    if err != nil {
+      log.Println("INFO: NodeNetworkState discovery query failed with error:" + err.Error())
+      time.Sleep(RetryInterval * time.Millisecond)
+    } else {
+      log.Println("INFO: NodeNetworkState API seems to be installed in the cluster")
+      netWatcher.createNodeStateInformer(nodeStateClient)
+      break
+    }
+  }
+  netWatcher.KubeClient, err = kubernetes.NewForConfig(cfg)
+  if err != nil {
+    return nil, err
+  }
   return netWatcher, nil
 }
 
+//checkDiscoveryResult remembers if an API could not be discovered for another reason than not being installed
+//Without knowing all the networks, stale host interfaces cannot be told apart from the ones still in use
+func (netWatcher *NetWatcher) checkDiscoveryResult(err error) {
+  if err != nil && !apierrors.IsNotFound(err) {
+    log.Println("WARNING: network API discovery failed, stale host interfaces are not garbage collected!")
+    netWatcher.isDiscoveryIncomplete = true
+  }
+}
+
 func (netWatcher *NetWatcher) Run(stopCh *chan struct{}) {
   for _, controller := range netWatcher.Controllers {
     go controller.Run(*stopCh)
@@ -133,6 +192,7 @@ func (netWatcher *NetWatcher) createDnetInformer(dnetClient danmclientset.Interf
       UpdateFunc: UpdateDanmNet,
       DeleteFunc: DeleteDanmNet,
   })
+  dnetController.AddEventHandler(netWatcher.getReconcileTriggerFuncs())
   dnetController.SetWatchErrorHandler(netWatcher.WatchErrorHandler)
   netWatcher.Controllers[DanmNetKind] = dnetController
 }
@@ -147,6 +207,7 @@ func (netWatcher *NetWatcher) createTnetInformer(tnetClient danmclientset.Interf
       UpdateFunc: UpdateTenantNetwork,
       DeleteFunc: DeleteTenantNetwork,
   })
+  tnetController.AddEventHandler(netWatcher.getReconcileTriggerFuncs())
   tnetController.SetWatchErrorHandler(netWatcher.WatchErrorHandler)
   netWatcher.Controllers[TenantNetworkKind] = tnetController
 }
@@ -161,10 +222,22 @@ func (netWatcher *NetWatcher) createCnetInformer(cnetClient danmclientset.Interf
       UpdateFunc: UpdateClusterNetwork,
       DeleteFunc: DeleteClusterNetwork,
   })
+  cnetController.AddEventHandler(netWatcher.getReconcileTriggerFuncs())
   cnetController.SetWatchErrorHandler(netWatcher.WatchErrorHandler)
   netWatcher.Controllers[ClusterNetworkKind] = cnetController
 }
 
+func (netWatcher *NetWatcher) createNodeStateInformer(nodeStateClient danmclientset.Interface) {
+  netWatcher.Clients[NodeNetworkStateKind] = nodeStateClient
+  nodeStateInformerFactory := danminformers.NewSharedInformerFactory(nodeStateClient, time.Minute*10)
+  netWatcher.Factories[NodeNetworkStateKind] = nodeStateInformerFactory
+  nodeStateController := nodeStateInformerFactory.Danm().V1().NodeNetworkStates().Informer()
+  //VTEPs of the other nodes are only needed by the reconciler, which maintains the unicast VxLAN peers
+  nodeStateController.AddEventHandler(netWatcher.getReconcileTriggerFuncs())
+  nodeStateController.SetWatchErrorHandler(netWatcher.WatchErrorHandler)
+  netWatcher.Controllers[NodeNetworkStateKind] = nodeStateController
+}
+
 func AddDanmNet(obj interface{}) {
   dn, isNetwork := obj.(*danmtypes.DanmNet)
   if !isNetwork {
@@ -173,7 +246,7 @@ func AddDanmNet(obj interface{}) {
   }
   err := setupHost(dn)
   if err != nil {
-    log.Println("INFO: Creating host interfaces for DanmNet:" + dn.ObjectMeta.Name + " failed with error:" + err.Error())
+    log.Println("ERROR: Creating host interfaces for DanmNet:" + dn.ObjectMeta.Name + " failed with error:" + err.Error())
   }
 }
 
@@ -195,7 +268,7 @@ func UpdateDanmNet(oldObj, newObj interface{}) {
   }
   err = setupHost(newdDn)
   if err != nil {
-    log.Println("INFO: Creating host interfaces for new DanmNet:" + newdDn.ObjectMeta.Name + " after update failed with error:" + err.Error())
+    log.Println("ERROR: Creating host interfaces for new DanmNet:" + newdDn.ObjectMeta.Name + " after update failed with error:" + err.Error())
   }
 }
 
@@ -229,7 +302,7 @@ func AddTenantNetwork(obj interface{}) {
   dnet := ConvertTnetToDnet(tn)
   err := setupHost(dnet)
   if err != nil {
-    log.Println("INFO: Creating host interfaces for TenantNetwork:" + dnet.ObjectMeta.Name + " failed with error:" + err.Error())
+    log.Println("ERROR: Creating host interfaces for TenantNetwork:" + dnet.ObjectMeta.Name + " failed with error:" + err.Error())
   }
 }
 
@@ -253,7 +326,7 @@ func UpdateTenantNetwork(oldObj, newObj interface{}) {
   }
   err = setupHost(newdDn)
   if err != nil {
-    log.Println("INFO: Creating host interfaces for new TenantNetwork:" + newdDn.ObjectMeta.Name + " after update failed with error:" + err.Error())
+    log.Println("ERROR: Creating host interfaces for new TenantNetwork:" + newdDn.ObjectMeta.Name + " after update failed with error:" + err.Error())
   }
 }
 
@@ -288,7 +361,7 @@ func AddClusterNetwork(obj interface{}) {
   dnet := ConvertCnetToDnet(cn)
   err := setupHost(dnet)
   if err != nil {
-    log.Println("INFO: Creating host interfaces for ClusterNetwork:" + dnet.ObjectMeta.Name + " failed with error:" + err.Error())
+    log.Println("ERROR: Creating host interfaces for ClusterNetwork:" + dnet.ObjectMeta.Name + " failed with error:" + err.Error())
   }
 }
 
@@ -312,7 +385,7 @@ func UpdateClusterNetwork(oldObj, newObj interface{}) {
   }
   err = setupHost(newdDn)
   if err != nil {
-    log.Println("INFO: Creating host interfaces for new ClusterNetwork:" + newdDn.ObjectMeta.Name + " after update failed with error:" + err.Error())
+    log.Println("ERROR: Creating host interfaces for new ClusterNetwork:" + newdDn.ObjectMeta.Name + " after update failed with error:" + err.Error())
   }
 }
 
@@ -456,6 +529,14 @@ func GetNetworkFromEp(danmClient danmclientset.Interface, ep *danmtypes.DanmEp)
   return GetNetworkFromInterface(danmClient, dummyIface, ep.ObjectMeta.Namespace)
 }
 
+func GetNetworkFromVip(danmClient danmclientset.Interface, vip *danmtypes.DanmVip) (*danmtypes.DanmNet,error) {
+  dummyIface := datastructs.Interface{}
+  if vip.Spec.ApiType == DanmNetKind || vip.Spec.ApiType == "" {dummyIface.Network = vip.Spec.NetworkName}
+  if vip.Spec.ApiType == TenantNetworkKind  {dummyIface.TenantNetwork = vip.Spec.NetworkName}
+  if vip.Spec.ApiType == ClusterNetworkKind {dummyIface.ClusterNetwork = vip.Spec.NetworkName}
+  return GetNetworkFromInterface(danmClient, dummyIface, vip.ObjectMeta.Namespace)
+}
+
 func RefreshNetwork(danmClient danmclientset.Interface, netInfo danmtypes.DanmNet) (*danmtypes.DanmNet,error) {
   dummyIface := datastructs.Interface{}
   if netInfo.TypeMeta.Kind == DanmNetKind || netInfo.TypeMeta.Kind == "" {dummyIface.Network = netInfo.ObjectMeta.Name}
@@ -464,14 +545,43 @@ func RefreshNetwork(danmClient danmclientset.Interface, netInfo danmtypes.DanmNe
   return GetNetworkFromInterface(danmClient, dummyIface, netInfo.ObjectMeta.Namespace)
 }
 
+//ListAllNetworks returns every DanmNet, TenantNetwork, and ClusterNetwork of the cluster, from all namespaces, in the DanmNet format
+func ListAllNetworks(danmClient danmclientset.Interface) ([]danmtypes.DanmNet,error) {
+  var nets []danmtypes.DanmNet
+  dnets, err := danmClient.DanmV1().DanmNets("").List(context.TODO(), meta_v1.ListOptions{})
+  if err != nil {
+    return nil, errors.New("cannot list DanmNets, because:" + err.Error())
+  }
+  for _, dnet := range dnets.Items {
+    dnet.TypeMeta.Kind = DanmNetKind
+    nets = append(nets, dnet)
+  }
+  tnets, err := danmClient.DanmV1().TenantNetworks("").List(context.TODO(), meta_v1.ListOptions{})
+  if err != nil {
+    return nil, errors.New("cannot list TenantNetworks, because:" + err.Error())
+  }
+  for _, tnet := range tnets.Items {
+    nets = append(nets, *ConvertTnetToDnet(&tnet))
+  }
+  cnets, err := danmClient.DanmV1().ClusterNetworks().List(context.TODO(), meta_v1.ListOptions{})
+  if err != nil {
+    return nil, errors.New("cannot list ClusterNetworks, because:" + err.Error())
+  }
+  for _, cnet := range cnets.Items {
+    nets = append(nets, *ConvertCnetToDnet(&cnet))
+  }
+  return nets, nil
+}
+
 //Little trickery: if there was no change in the VNI+host_device combo during the update we set it to 0 in the manifests.
 //Thus we avoid unnecessarily recreating host interfaces.
+//Changing any of the VxLAN parameters requires the re-creation of the VxLAN interface.
 func zeroVnis(oldDn, newDn *danmtypes.DanmNet) {
   if oldDn.Spec.Options.Vlan == newDn.Spec.Options.Vlan && oldDn.Spec.Options.Device == newDn.Spec.Options.Device {
     oldDn.Spec.Options.Vlan = 0
     newDn.Spec.Options.Vlan = 0
   }
-  if oldDn.Spec.Options.Vxlan == newDn.Spec.Options.Vxlan && oldDn.Spec.Options.Device == newDn.Spec.Options.Device {
+  if oldDn.Spec.Options.Vxlan == newDn.Spec.Options.Vxlan && oldDn.Spec.Options.Device == newDn.Spec.Options.Device && AreVxlanParamsEqual(oldDn, newDn) {
     oldDn.Spec.Options.Vxlan = 0
     newDn.Spec.Options.Vxlan = 0
   }
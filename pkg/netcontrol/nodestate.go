@@ -0,0 +1,203 @@
+package netcontrol
+
+import (
+  "context"
+  "errors"
+  "log"
+  "sort"
+  "time"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  danmclientset "github.com/nokia/danm/crd/client/clientset/versioned"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+  apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+  //ProvisioningFailedReason means the host interface of the network could not be created on the node
+  ProvisioningFailedReason = "HostInterfaceFailed"
+  //InterfaceConflictReason means the host interface of the network is already used by another network with a different configuration
+  InterfaceConflictReason = "HostInterfaceConflict"
+  //InterfaceMismatchReason means the host interface of the network is misconfigured, but it cannot be re-created while Pods are connected to it
+  InterfaceMismatchReason = "HostInterfaceMismatch"
+)
+
+//CheckNetworkProvisioning returns an error if the netwatcher of the node reported, that the host interfaces of the network are not provisioned
+//Networks not reported yet, or nodes without NodeNetworkState are not considered faulty, as netwatcher might not maintain the state at all
+//Stale states are ignored as well, so a netwatcher which stopped reconciling cannot block the Pods of the node forever
+func CheckNetworkProvisioning(danmClient danmclientset.Interface, nodeName string, dnet *danmtypes.DanmNet) error {
+  if !isHostLinkRequired(dnet) {
+    return nil
+  }
+  nodeState, err := danmClient.DanmV1().NodeNetworkStates().Get(context.TODO(), nodeName, meta_v1.GetOptions{})
+  if err != nil || nodeState == nil || IsNodeNetworkStateStale(nodeState) {
+    return nil
+  }
+  for _, netState := range nodeState.Networks {
+    if !isStateOfNetwork(netState, dnet) || netState.Ready {
+      continue
+    }
+    return errors.New("host interfaces of network:" + dnet.ObjectMeta.Name + " are not provisioned on node:" + nodeName + ", because:" +
+      netState.Message + ". See NodeNetworkState:" + nodeName + " for details")
+  }
+  return nil
+}
+
+//IsNodeNetworkStateStale tells whether the netwatcher of the node did not confirm the state for two reconcile intervals
+func IsNodeNetworkStateStale(nodeState *danmtypes.NodeNetworkState) bool {
+  interval := nodeState.ReconcileInterval.Duration
+  return interval <= 0 || nodeState.LastReconcileTime.IsZero() || time.Since(nodeState.LastReconcileTime.Time) > 2 * interval
+}
+
+func isHostLinkRequired(dnet *danmtypes.DanmNet) bool {
+  return dnet.Spec.Options.Device != "" && (dnet.Spec.Options.Vlan != 0 || dnet.Spec.Options.Vxlan != 0)
+}
+
+func isStateOfNetwork(netState danmtypes.NetworkProvisioningState, dnet *danmtypes.DanmNet) bool {
+  return getApiType(netState.ApiType) == getApiType(dnet.TypeMeta.Kind) && netState.Name == dnet.ObjectMeta.Name &&
+    (getApiType(dnet.TypeMeta.Kind) == ClusterNetworkKind || netState.Namespace == dnet.ObjectMeta.Namespace)
+}
+
+func getApiType(kind string) string {
+  if kind == "" {
+    return DanmNetKind
+  }
+  return kind
+}
+
+//getNetworkProvisioningStates tells for every network requiring host interfaces whether they were successfully reconciled
+//Ready unicast VxLAN networks also advertise the local VTEP address, so the other nodes can replicate BUM traffic to it
+func getNetworkProvisioningStates(nets []danmtypes.DanmNet, desiredLinks map[string]hostLink, linkErrors map[string]error, localVteps map[string]string) []danmtypes.NetworkProvisioningState {
+  netStates := make([]danmtypes.NetworkProvisioningState, 0)
+  for _, dnet := range nets {
+    if !isHostLinkRequired(&dnet) {
+      continue
+    }
+    netState := danmtypes.NetworkProvisioningState{ApiType: getApiType(dnet.TypeMeta.Kind), Name: dnet.ObjectMeta.Name, Ready: true}
+    if netState.ApiType != ClusterNetworkKind {
+      netState.Namespace = dnet.ObjectMeta.Namespace
+    }
+    for _, requiredLink := range getRequiredHostLinks(&dnet) {
+      desiredLink := desiredLinks[requiredLink.name]
+      if desiredLink.vni != requiredLink.vni || desiredLink.isVxlan != requiredLink.isVxlan || desiredLink.vxlan != requiredLink.vxlan || desiredLink.hostDevice != requiredLink.hostDevice {
+        netState.Ready, netState.Reason = false, InterfaceConflictReason
+        netState.Message = "host interface:" + requiredLink.name + " is already used by network:" + desiredLink.network
+        break
+      }
+      if _, isMismatch := linkErrors[requiredLink.name].(linkMismatchError); isMismatch {
+        netState.Ready, netState.Reason = false, InterfaceMismatchReason
+        netState.Message = "host interface:" + requiredLink.name + " is misconfigured:" + linkErrors[requiredLink.name].Error()
+        break
+      }
+      if linkErrors[requiredLink.name] != nil {
+        netState.Ready, netState.Reason = false, ProvisioningFailedReason
+        netState.Message = "host interface:" + requiredLink.name + " could not be created:" + linkErrors[requiredLink.name].Error()
+        break
+      }
+      if netState.Ready && requiredLink.vxlan.isUnicast {
+        netState.Vtep = localVteps[requiredLink.name]
+      }
+    }
+    netStates = append(netStates, netState)
+  }
+  sort.Slice(netStates, func(i, j int) bool {
+    return netStates[i].ApiType + "/" + netStates[i].Namespace + "/" + netStates[i].Name < netStates[j].ApiType + "/" + netStates[j].Namespace + "/" + netStates[j].Name
+  })
+  return netStates
+}
+
+//updateNodeNetworkState records the provisioning state of the networks in the NodeNetworkState of the node
+//The transition time of a network is only changed when its readiness changes
+//The object is owned by the Node when it is known, so it is garbage collected when the node leaves the cluster
+//The reconcile time is refreshed every half interval even without changes, so readers can tell whether the state is still maintained
+func updateNodeNetworkState(danmClient danmclientset.Interface, nodeName string, netStates []danmtypes.NetworkProvisioningState, owner *meta_v1.OwnerReference, interval time.Duration) error {
+  nodeState, err := danmClient.DanmV1().NodeNetworkStates().Get(context.TODO(), nodeName, meta_v1.GetOptions{})
+  if err != nil && !apierrors.IsNotFound(err) {
+    return err
+  }
+  now := meta_v1.Now()
+  if err != nil {
+    for index := range netStates {
+      netStates[index].LastTransitionTime = now
+    }
+    nodeState = &danmtypes.NodeNetworkState{ObjectMeta: meta_v1.ObjectMeta{Name: nodeName}, Networks: netStates}
+    nodeState.ReconcileInterval, nodeState.LastReconcileTime = meta_v1.Duration{Duration: interval}, now
+    if owner != nil {
+      nodeState.ObjectMeta.OwnerReferences = []meta_v1.OwnerReference{*owner}
+    }
+    _, err = danmClient.DanmV1().NodeNetworkStates().Create(context.TODO(), nodeState, meta_v1.CreateOptions{})
+    return err
+  }
+  var isStateChanged bool
+  if owner != nil && len(nodeState.ObjectMeta.OwnerReferences) == 0 {
+    nodeState.ObjectMeta.OwnerReferences = []meta_v1.OwnerReference{*owner}
+    isStateChanged = true
+  }
+  if nodeState.ReconcileInterval.Duration != interval || now.Sub(nodeState.LastReconcileTime.Time) >= interval / 2 {
+    isStateChanged = true
+  }
+  nodeState.ReconcileInterval, nodeState.LastReconcileTime = meta_v1.Duration{Duration: interval}, now
+  for index := range netStates {
+    oldState := getOldNetworkState(nodeState.Networks, netStates[index])
+    if oldState == nil || oldState.Ready != netStates[index].Ready {
+      netStates[index].LastTransitionTime = now
+      isStateChanged = true
+      continue
+    }
+    netStates[index].LastTransitionTime = oldState.LastTransitionTime
+    if oldState.Reason != netStates[index].Reason || oldState.Message != netStates[index].Message || oldState.Vtep != netStates[index].Vtep {
+      isStateChanged = true
+    }
+  }
+  if !isStateChanged && len(nodeState.Networks) == len(netStates) {
+    return nil
+  }
+  nodeState.Networks = netStates
+  _, err = danmClient.DanmV1().NodeNetworkStates().Update(context.TODO(), nodeState, meta_v1.UpdateOptions{})
+  return err
+}
+
+func getOldNetworkState(oldStates []danmtypes.NetworkProvisioningState, netState danmtypes.NetworkProvisioningState) *danmtypes.NetworkProvisioningState {
+  for index, oldState := range oldStates {
+    if oldState.ApiType == netState.ApiType && oldState.Namespace == netState.Namespace && oldState.Name == netState.Name {
+      return &oldStates[index]
+    }
+  }
+  return nil
+}
+
+func (netWatcher *NetWatcher) publishNodeNetworkState(nets []danmtypes.DanmNet, desiredLinks map[string]hostLink, linkErrors map[string]error, localVteps map[string]string) {
+  danmClient, isApiUsed := netWatcher.Clients[NodeNetworkStateKind]
+  if !isApiUsed {
+    return
+  }
+  err := updateNodeNetworkState(danmClient, netWatcher.NodeName, getNetworkProvisioningStates(nets, desiredLinks, linkErrors, localVteps), netWatcher.getNodeOwner(), netWatcher.reconcileInterval)
+  if err != nil {
+    log.Println("ERROR: NodeNetworkState of node:" + netWatcher.NodeName + " could not be updated, because:" + err.Error())
+  }
+}
+
+// RemoveNodeNetworkState deletes the NodeNetworkState of the node
+// It is used when host interfaces are not reconciled, so the CNI does not act upon a state nobody maintains
+func (netWatcher *NetWatcher) RemoveNodeNetworkState() {
+  danmClient, isApiUsed := netWatcher.Clients[NodeNetworkStateKind]
+  if !isApiUsed {
+    return
+  }
+  err := danmClient.DanmV1().NodeNetworkStates().Delete(context.TODO(), netWatcher.NodeName, meta_v1.DeleteOptions{})
+  if err != nil && !apierrors.IsNotFound(err) {
+    log.Println("ERROR: NodeNetworkState of node:" + netWatcher.NodeName + " could not be removed, because:" + err.Error())
+  }
+}
+
+func (netWatcher *NetWatcher) getNodeOwner() *meta_v1.OwnerReference {
+  if netWatcher.nodeOwner != nil || netWatcher.KubeClient == nil {
+    return netWatcher.nodeOwner
+  }
+  node, err := netWatcher.KubeClient.CoreV1().Nodes().Get(context.TODO(), netWatcher.NodeName, meta_v1.GetOptions{})
+  if err != nil {
+    log.Println("INFO: Node:" + netWatcher.NodeName + " could not be read, its NodeNetworkState is not garbage collected with it, because:" + err.Error())
+    return nil
+  }
+  netWatcher.nodeOwner = &meta_v1.OwnerReference{APIVersion: "v1", Kind: "Node", Name: node.ObjectMeta.Name, UID: node.ObjectMeta.UID}
+  return netWatcher.nodeOwner
+}
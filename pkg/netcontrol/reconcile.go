@@ -0,0 +1,409 @@
+package netcontrol
+
+import (
+  "context"
+  "errors"
+  "log"
+  "net"
+  "sort"
+  "time"
+  "github.com/vishvananda/netlink"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+  "k8s.io/apimachinery/pkg/labels"
+  "k8s.io/client-go/tools/cache"
+)
+
+const (
+  //DanmLinkAlias marks the host interfaces created by netwatcher, only these interfaces are garbage collected
+  DanmLinkAlias = "danm"
+)
+
+//linkMismatchError means an interface does not match its network, but it is kept as Pods are connected to it
+type linkMismatchError struct {
+  message string
+}
+
+func (err linkMismatchError) Error() string {
+  return err.message
+}
+
+//deleteLink is a variable, so the garbage collection of host interfaces can be unit tested without touching the host
+var deleteLink = netlink.LinkDel
+
+// hostLink is a VLAN, or VxLAN host interface a network requires on the host
+type hostLink struct {
+  name string
+  networkId string
+  vni int
+  isVxlan bool
+  vxlan vxlanParams
+  hostDevice string
+  network string
+}
+
+// RunReconciler synchronizes the VLAN, and VxLAN host interfaces with the networks right after the informer caches are filled, then periodically until stopCh is closed
+// Missing, or misconfigured interfaces are (re-)created, and the ones created by netwatcher for already non-existent networks are deleted
+// Interfaces Pods are still connected to are never deleted
+func (netWatcher *NetWatcher) RunReconciler(interval time.Duration, stopCh *chan struct{}) {
+  netWatcher.reconcileInterval = interval
+  var syncFuncs []cache.InformerSynced
+  for _, controller := range netWatcher.Controllers {
+    syncFuncs = append(syncFuncs, controller.HasSynced)
+  }
+  if !cache.WaitForCacheSync(*stopCh, syncFuncs...) {
+    log.Println("ERROR: Network caches could not be synced, host interfaces are not reconciled!")
+    return
+  }
+  netWatcher.ReconcileHostLinks()
+  ticker := time.NewTicker(interval)
+  defer ticker.Stop()
+  for {
+    select {
+    case <-ticker.C:
+      netWatcher.ReconcileHostLinks()
+    case <-netWatcher.reconcileTrigger:
+      //Network changes are reflected in the provisioning state of the node without waiting for the next period
+      netWatcher.ReconcileHostLinks()
+    case <-*stopCh:
+      return
+    }
+  }
+}
+
+// RunStateReporter periodically reports the state of the VLAN, and VxLAN host interfaces in the NodeNetworkState of the node, and synchronizes the VxLAN peers, but never repairs the interfaces
+// It is used instead of RunReconciler when the reconciliation is disabled, so the CNI, and the peer discovery of unicast VxLANs still have an up-to-date state
+func (netWatcher *NetWatcher) RunStateReporter(interval time.Duration, stopCh *chan struct{}) {
+  netWatcher.isRepairDisabled = true
+  netWatcher.RunReconciler(interval, stopCh)
+}
+
+// ReconcileHostLinks compares the VLAN, and VxLAN host interfaces required by all the known networks with the interfaces existing on the host, and repairs the differences
+// The outcome is recorded per network in the NodeNetworkState object of the node
+func (netWatcher *NetWatcher) ReconcileHostLinks() {
+  nets, err := netWatcher.listCachedNetworks()
+  if err != nil {
+    log.Println("ERROR: Host interfaces cannot be reconciled, because networks cannot be listed:" + err.Error())
+    return
+  }
+  desiredLinks := getDesiredHostLinks(nets)
+  existingLinks, err := netlink.LinkList()
+  if err != nil {
+    log.Println("ERROR: Host interfaces cannot be reconciled, because links cannot be listed:" + err.Error())
+    return
+  }
+  usedNets, err := netWatcher.getUsedNetworks()
+  if err != nil {
+    log.Println("ERROR: Host interfaces in use cannot be determined, because DanmEps cannot be listed:" + err.Error())
+  }
+  if err == nil && !netWatcher.isRepairDisabled && netWatcher.isGarbageCollectionAllowed() {
+    netWatcher.collectStaleLinks(existingLinks, desiredLinks, usedNets, nets)
+  }
+  linkErrors := make(map[string]error)
+  for _, name := range getSortedLinkNames(desiredLinks) {
+    if netWatcher.isRepairDisabled {
+      err = checkHostLink(desiredLinks[name])
+    } else {
+      err = reconcileHostLink(desiredLinks[name], isLinkInUse(name, existingLinks, usedNets, nets))
+    }
+    if err != nil {
+      linkErrors[name] = err
+      log.Println("ERROR: Host interface:" + name + " of network:" + desiredLinks[name].network + " could not be reconciled, because:" + err.Error())
+    }
+  }
+  localVteps := getLocalVteps(desiredLinks, linkErrors)
+  netWatcher.publishNodeNetworkState(nets, desiredLinks, linkErrors, localVteps)
+  netWatcher.syncVxlanPeers(nets, desiredLinks, localVteps)
+}
+
+//isGarbageCollectionAllowed tells if all the networks are known, so the interfaces not required by any of them are surely stale
+func (netWatcher *NetWatcher) isGarbageCollectionAllowed() bool {
+  if netWatcher.isDiscoveryIncomplete {
+    return false
+  }
+  for _, kind := range []string{DanmNetKind, TenantNetworkKind, ClusterNetworkKind} {
+    if controller, isApiUsed := netWatcher.Controllers[kind]; isApiUsed && !controller.HasSynced() {
+      return false
+    }
+  }
+  return true
+}
+
+//collectStaleLinks deletes the interfaces created by DANM, which are not required by any network, and are not used by any Pod
+//Interfaces are kept while any Pod of the node is connected to a network which is not known anymore, as it might be the one using them
+func (netWatcher *NetWatcher) collectStaleLinks(existingLinks []netlink.Link, desiredLinks map[string]hostLink, usedNets map[string]bool, nets []danmtypes.DanmNet) {
+  knownNets := make(map[string]bool)
+  for _, dnet := range nets {
+    knownNets[getNetworkName(&dnet)] = true
+  }
+  for usedNet := range usedNets {
+    if !knownNets[usedNet] {
+      log.Println("INFO: Stale host interfaces are not garbage collected, because Pods are still connected to unknown network:" + usedNet)
+      return
+    }
+  }
+  for _, link := range existingLinks {
+    if _, isDesired := desiredLinks[link.Attrs().Name]; isDesired || !isLinkOwned(link) || hasSlaves(link, existingLinks) {
+      continue
+    }
+    err := deleteLink(link)
+    if err != nil {
+      log.Println("ERROR: Stale host interface:" + link.Attrs().Name + " could not be deleted, because:" + err.Error())
+      continue
+    }
+    log.Println("INFO: Stale host interface:" + link.Attrs().Name + " was deleted")
+  }
+}
+
+//getUsedNetworks returns the names of the networks the Pods of this node are connected to, based on their DanmEps
+func (netWatcher *NetWatcher) getUsedNetworks() (map[string]bool,error) {
+  usedNets := make(map[string]bool)
+  for _, danmClient := range netWatcher.Clients {
+    eps, err := danmClient.DanmV1().DanmEps("").List(context.TODO(), meta_v1.ListOptions{})
+    if err != nil {
+      return nil, err
+    }
+    for _, ep := range eps.Items {
+      if ep.Spec.Host != netWatcher.HostName {
+        continue
+      }
+      epNet := danmtypes.DanmNet{TypeMeta: meta_v1.TypeMeta{Kind: getApiType(ep.Spec.ApiType)}, ObjectMeta: meta_v1.ObjectMeta{Name: ep.Spec.NetworkName, Namespace: ep.ObjectMeta.Namespace}}
+      if epNet.TypeMeta.Kind == ClusterNetworkKind {
+        epNet.ObjectMeta.Namespace = ""
+      }
+      usedNets[getNetworkName(&epNet)] = true
+    }
+    //All the clients reach the same API server
+    break
+  }
+  return usedNets, nil
+}
+
+//isLinkInUse tells if Pods might be connected to the interface, either directly on the host, or through a network requiring it
+//When DanmEps cannot be listed all interfaces are considered to be in use
+func isLinkInUse(name string, existingLinks []netlink.Link, usedNets map[string]bool, nets []danmtypes.DanmNet) bool {
+  if usedNets == nil {
+    return true
+  }
+  for _, link := range existingLinks {
+    if link.Attrs().Name == name && hasSlaves(link, existingLinks) {
+      return true
+    }
+  }
+  for _, dnet := range nets {
+    if !usedNets[getNetworkName(&dnet)] {
+      continue
+    }
+    for _, requiredLink := range getRequiredHostLinks(&dnet) {
+      if requiredLink.name == name {
+        return true
+      }
+    }
+  }
+  return false
+}
+
+//hasSlaves tells if any interface of the host namespace is stacked on, or enslaved to the interface
+//Slaves already moved to Pod namespaces are not visible here, those are covered by the DanmEps
+func hasSlaves(link netlink.Link, existingLinks []netlink.Link) bool {
+  for _, otherLink := range existingLinks {
+    if otherLink.Attrs().Index == link.Attrs().Index {
+      continue
+    }
+    if otherLink.Attrs().ParentIndex == link.Attrs().Index || otherLink.Attrs().MasterIndex == link.Attrs().Index {
+      return true
+    }
+  }
+  return false
+}
+
+func (netWatcher *NetWatcher) getReconcileTriggerFuncs() cache.ResourceEventHandlerFuncs {
+  trigger := func() {
+    select {
+    case netWatcher.reconcileTrigger <- struct{}{}:
+    default:
+    }
+  }
+  return cache.ResourceEventHandlerFuncs{
+    AddFunc: func(obj interface{}) {trigger()},
+    UpdateFunc: func(oldObj, newObj interface{}) {trigger()},
+    DeleteFunc: func(obj interface{}) {trigger()},
+  }
+}
+
+func (netWatcher *NetWatcher) listCachedNetworks() ([]danmtypes.DanmNet,error) {
+  var nets []danmtypes.DanmNet
+  if factory, isApiUsed := netWatcher.Factories[DanmNetKind]; isApiUsed {
+    dnets, err := factory.Danm().V1().DanmNets().Lister().List(labels.Everything())
+    if err != nil {
+      return nil, errors.New("cannot list DanmNets, because:" + err.Error())
+    }
+    for _, dnet := range dnets {
+      netCopy := dnet.DeepCopy()
+      netCopy.TypeMeta.Kind = DanmNetKind
+      nets = append(nets, *netCopy)
+    }
+  }
+  if factory, isApiUsed := netWatcher.Factories[TenantNetworkKind]; isApiUsed {
+    tnets, err := factory.Danm().V1().TenantNetworks().Lister().List(labels.Everything())
+    if err != nil {
+      return nil, errors.New("cannot list TenantNetworks, because:" + err.Error())
+    }
+    for _, tnet := range tnets {
+      nets = append(nets, *ConvertTnetToDnet(tnet))
+    }
+  }
+  if factory, isApiUsed := netWatcher.Factories[ClusterNetworkKind]; isApiUsed {
+    cnets, err := factory.Danm().V1().ClusterNetworks().Lister().List(labels.Everything())
+    if err != nil {
+      return nil, errors.New("cannot list ClusterNetworks, because:" + err.Error())
+    }
+    for _, cnet := range cnets {
+      nets = append(nets, *ConvertCnetToDnet(cnet))
+    }
+  }
+  return nets, nil
+}
+
+//getDesiredHostLinks returns the host interfaces required by the networks, indexed by their name
+//When multiple networks would require the same interface, the one belonging to the network with the lexicographically first kind, namespace, and name is kept
+func getDesiredHostLinks(nets []danmtypes.DanmNet) map[string]hostLink {
+  sort.Slice(nets, func(i, j int) bool {
+    return getNetworkName(&nets[i]) < getNetworkName(&nets[j])
+  })
+  desiredLinks := make(map[string]hostLink)
+  for _, dnet := range nets {
+    for _, link := range getRequiredHostLinks(&dnet) {
+      if _, isAlreadyDesired := desiredLinks[link.name]; !isAlreadyDesired {
+        desiredLinks[link.name] = link
+      }
+    }
+  }
+  return desiredLinks
+}
+
+func getRequiredHostLinks(dnet *danmtypes.DanmNet) []hostLink {
+  var links []hostLink
+  if dnet.Spec.Options.Device == "" {
+    return links
+  }
+  netName := getNetworkName(dnet)
+  if dnet.Spec.Options.Vlan != 0 {
+    vlanName := determineVlanHdev(dnet.Spec.Options.Vlan, dnet.Spec.NetworkID, dnet.Spec.Options.Device)
+    links = append(links, hostLink{name: vlanName, networkId: dnet.Spec.NetworkID, vni: dnet.Spec.Options.Vlan, hostDevice: dnet.Spec.Options.Device, network: netName})
+  }
+  if dnet.Spec.Options.Vxlan != 0 {
+    vxlanName := "vx_" + dnet.Spec.NetworkID
+    links = append(links, hostLink{name: vxlanName, networkId: dnet.Spec.NetworkID, vni: dnet.Spec.Options.Vxlan, isVxlan: true, vxlan: getVxlanParams(dnet), hostDevice: dnet.Spec.Options.Device, network: netName})
+  }
+  return links
+}
+
+func getNetworkName(dnet *danmtypes.DanmNet) string {
+  return dnet.TypeMeta.Kind + ":" + dnet.ObjectMeta.Namespace + "/" + dnet.ObjectMeta.Name
+}
+
+func getSortedLinkNames(links map[string]hostLink) []string {
+  names := make([]string, 0, len(links))
+  for name := range links {
+    names = append(names, name)
+  }
+  sort.Strings(names)
+  return names
+}
+
+//reconcileHostLink creates the missing interface, or re-creates the misconfigured one
+//Interfaces Pods are connected to are never deleted, their mismatch is only reported
+func reconcileHostLink(desiredLink hostLink, isInUse bool) error {
+  existingLink, err := netlink.LinkByName(desiredLink.name)
+  if err == nil {
+    isUpToDate, err := isLinkUpToDate(existingLink, desiredLink)
+    if err != nil {
+      return err
+    }
+    if isUpToDate {
+      //Interfaces created by earlier netwatcher versions are adopted, so they are garbage collected too
+      if !isLinkOwned(existingLink) {
+        err = netlink.LinkSetAlias(existingLink, DanmLinkAlias)
+        if err != nil {
+          return errors.New("existing interface could not be marked as created by DANM:" + err.Error())
+        }
+      }
+      if existingLink.Attrs().Flags & net.FlagUp == 0 {
+        return netlink.LinkSetUp(existingLink)
+      }
+      return nil
+    }
+    if !isLinkOwned(existingLink) {
+      return errors.New("interface with the same name, but different configuration already exists, and it was not created by DANM")
+    }
+    if isInUse {
+      return linkMismatchError{message: "interface does not match the configuration of the network, but it is not re-created while Pods are connected to it"}
+    }
+    err = netlink.LinkDel(existingLink)
+    if err != nil {
+      return errors.New("misconfigured interface could not be deleted:" + err.Error())
+    }
+    log.Println("INFO: Misconfigured host interface:" + desiredLink.name + " was deleted to be re-created")
+  }
+  if desiredLink.isVxlan {
+    err = setupVxlan(desiredLink.vni, desiredLink.networkId, desiredLink.hostDevice, desiredLink.vxlan)
+  } else {
+    err = setupVlan(desiredLink.vni, desiredLink.networkId, desiredLink.hostDevice)
+  }
+  if err != nil {
+    return err
+  }
+  log.Println("INFO: Missing host interface:" + desiredLink.name + " of network:" + desiredLink.network + " was created")
+  return nil
+}
+
+//checkHostLink only reports the problems of an interface, when the reconciliation is disabled
+func checkHostLink(desiredLink hostLink) error {
+  existingLink, err := netlink.LinkByName(desiredLink.name)
+  if err != nil {
+    return errors.New("interface does not exist, and it is not re-created while the reconciliation is disabled")
+  }
+  isUpToDate, err := isLinkUpToDate(existingLink, desiredLink)
+  if err != nil {
+    return err
+  }
+  if !isUpToDate {
+    return linkMismatchError{message: "interface does not match the configuration of the network, and it is not re-created while the reconciliation is disabled"}
+  }
+  return nil
+}
+
+//isLinkUpToDate returns an error when the host device cannot be read, so a transient failure never leads to deleting the interface
+func isLinkUpToDate(link netlink.Link, desiredLink hostLink) (bool,error) {
+  hostDev, err := netlink.LinkByName(desiredLink.hostDevice)
+  if err != nil {
+    return false, errors.New("host device:" + desiredLink.hostDevice + " cannot be found:" + err.Error())
+  }
+  if desiredLink.isVxlan {
+    vxlan, isVxlan := link.(*netlink.Vxlan)
+    return isVxlan && vxlan.VxlanId == desiredLink.vni && vxlan.VtepDevIndex == hostDev.Attrs().Index && isVxlanUpToDate(vxlan, desiredLink.vxlan), nil
+  }
+  vlan, isVlan := link.(*netlink.Vlan)
+  return isVlan && vlan.VlanId == desiredLink.vni && vlan.Attrs().ParentIndex == hostDev.Attrs().Index, nil
+}
+
+func isVxlanUpToDate(vxlan *netlink.Vxlan, params vxlanParams) bool {
+  //Unicast VxLANs have no multicast group
+  isUnicast := vxlan.Group == nil || vxlan.Group.IsUnspecified()
+  if isUnicast != params.isUnicast || vxlan.Port != params.port || vxlan.TTL != params.ttl || vxlan.TOS != params.tos || vxlan.Learning != params.learning {
+    return false
+  }
+  if params.group != "" && !vxlan.Group.Equal(net.ParseIP(params.group)) {
+    return false
+  }
+  if params.sourceCidr != "" {
+    _, sourceNet, err := net.ParseCIDR(params.sourceCidr)
+    return err == nil && sourceNet.Contains(vxlan.SrcAddr)
+  }
+  return true
+}
+
+func isLinkOwned(link netlink.Link) bool {
+  return link.Attrs().Alias == DanmLinkAlias
+}
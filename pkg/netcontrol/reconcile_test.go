@@ -0,0 +1,164 @@
+package netcontrol
+
+import (
+  "errors"
+  "reflect"
+  "sort"
+  "testing"
+  "github.com/vishvananda/netlink"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var desiredLinkTcs = []struct {
+  tcName string
+  nets []danmtypes.DanmNet
+  expectedLinks map[string]string
+}{
+  {"vlanAndVxlanOfNetwork", []danmtypes.DanmNet{createReconcileNet("DanmNet", "default", "a", "nida", "ens4", 100, 200)}, map[string]string{"nida.100": "DanmNet:default/a", "vx_nida": "DanmNet:default/a"}},
+  {"networkWithoutDeviceIgnored", []danmtypes.DanmNet{createReconcileNet("DanmNet", "default", "a", "nida", "", 100, 200)}, map[string]string{}},
+  {"networkWithoutVniIgnored", []danmtypes.DanmNet{createReconcileNet("DanmNet", "default", "a", "nida", "ens4", 0, 0)}, map[string]string{}},
+  {"firstNameKeepsSharedLink", []danmtypes.DanmNet{createReconcileNet("DanmNet", "default", "b", "nid", "ens4", 0, 10), createReconcileNet("DanmNet", "default", "a", "nid", "ens4", 0, 20)}, map[string]string{"vx_nid": "DanmNet:default/a"}},
+  {"firstNamespaceKeepsSharedLink", []danmtypes.DanmNet{createReconcileNet("DanmNet", "blue", "a", "nid", "ens4", 0, 10), createReconcileNet("DanmNet", "alpha", "b", "nid", "ens4", 0, 20)}, map[string]string{"vx_nid": "DanmNet:alpha/b"}},
+  {"firstKindKeepsSharedLink", []danmtypes.DanmNet{createReconcileNet("TenantNetwork", "default", "a", "nid", "ens4", 0, 10), createReconcileNet("DanmNet", "default", "a", "nid", "ens4", 0, 20)}, map[string]string{"vx_nid": "DanmNet:default/a"}},
+}
+
+var linkInUseTcs = []struct {
+  tcName string
+  linkName string
+  usedNets map[string]bool
+  isInUseExpected bool
+}{
+  {"unknownUsageMeansInUse", "vx_unused", nil, true},
+  {"linkWithStackedSlave", "vx_parent", map[string]bool{}, true},
+  {"linkWithEnslavedSlave", "vx_master", map[string]bool{}, true},
+  {"linkOfUsedNetwork", "nida.100", map[string]bool{"DanmNet:default/a": true}, true},
+  {"linkOfUnusedNetwork", "nida.100", map[string]bool{"DanmNet:default/b": true}, false},
+  {"linkWithoutSlavesAndNetwork", "vx_unused", map[string]bool{"DanmNet:default/a": true}, false},
+}
+
+var staleLinkTcs = []struct {
+  tcName string
+  usedNets map[string]bool
+  isDeleteFailing bool
+  expectedDeletions []string
+}{
+  {"onlyStaleOwnedLinksDeleted", map[string]bool{}, false, []string{"old.100", "vx_old"}},
+  {"usedKnownNetworkDoesNotAbort", map[string]bool{"DanmNet:default/a": true}, false, []string{"old.100", "vx_old"}},
+  {"usedUnknownNetworkAborts", map[string]bool{"DanmNet:default/a": true, "TenantNetwork:default/deleted": true}, false, nil},
+  {"failedDeletionDoesNotStopOthers", map[string]bool{}, true, []string{"old.100", "vx_old"}},
+}
+
+var provisioningStateTcs = []struct {
+  tcName string
+  nets []danmtypes.DanmNet
+  linkErrors map[string]error
+  expectedReasons map[string]string
+}{
+  {"readyNetwork", []danmtypes.DanmNet{createReconcileNet("DanmNet", "default", "a", "nida", "ens4", 100, 0)}, nil, map[string]string{"a": ""}},
+  {"failedLink", []danmtypes.DanmNet{createReconcileNet("DanmNet", "default", "a", "nida", "ens4", 100, 0)}, map[string]error{"nida.100": errors.New("ens4 not found")}, map[string]string{"a": ProvisioningFailedReason}},
+  {"mismatchingLink", []danmtypes.DanmNet{createReconcileNet("DanmNet", "default", "a", "nida", "ens4", 100, 0)}, map[string]error{"nida.100": linkMismatchError{message: "Pods are connected"}}, map[string]string{"a": InterfaceMismatchReason}},
+  {"conflictingLink", []danmtypes.DanmNet{createReconcileNet("DanmNet", "default", "a", "nid", "ens4", 0, 10), createReconcileNet("DanmNet", "default", "b", "nid", "ens4", 0, 20)}, nil, map[string]string{"a": "", "b": InterfaceConflictReason}},
+  {"networkWithoutLinkNotReported", []danmtypes.DanmNet{createReconcileNet("DanmNet", "default", "a", "nida", "", 0, 0)}, nil, map[string]string{}},
+}
+
+func TestGetDesiredHostLinks(t *testing.T) {
+  for _, tc := range desiredLinkTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      desiredLinks := getDesiredHostLinks(tc.nets)
+      linkOwners := make(map[string]string)
+      for name, link := range desiredLinks {
+        linkOwners[name] = link.network
+      }
+      if !reflect.DeepEqual(linkOwners, tc.expectedLinks) {
+        t.Errorf("Desired host interfaces:%v do not match with expectation:%v", linkOwners, tc.expectedLinks)
+      }
+    })
+  }
+}
+
+func TestIsLinkInUse(t *testing.T) {
+  nets := []danmtypes.DanmNet{createReconcileNet("DanmNet", "default", "a", "nida", "ens4", 100, 0)}
+  existingLinks := []netlink.Link {
+    createReconcileLink("nida.100", 2, 0, 0, DanmLinkAlias),
+    createReconcileLink("vx_parent", 3, 0, 0, DanmLinkAlias),
+    createReconcileLink("parent.200", 4, 3, 0, DanmLinkAlias),
+    createReconcileLink("vx_master", 5, 0, 0, DanmLinkAlias),
+    createReconcileLink("enslaved", 6, 0, 5, ""),
+    createReconcileLink("vx_unused", 7, 0, 0, DanmLinkAlias),
+  }
+  for _, tc := range linkInUseTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      isInUse := isLinkInUse(tc.linkName, existingLinks, tc.usedNets, nets)
+      if isInUse != tc.isInUseExpected {
+        t.Errorf("Interface:%s is in use:%t, but we expected:%t", tc.linkName, isInUse, tc.isInUseExpected)
+      }
+    })
+  }
+}
+
+func TestCollectStaleLinks(t *testing.T) {
+  origDeleteLink := deleteLink
+  defer func() {
+    deleteLink = origDeleteLink
+  }()
+  nets := []danmtypes.DanmNet{createReconcileNet("DanmNet", "default", "a", "nida", "ens4", 100, 0)}
+  existingLinks := []netlink.Link {
+    createReconcileLink("ens4", 1, 0, 0, ""),
+    createReconcileLink("nida.100", 2, 1, 0, DanmLinkAlias),
+    createReconcileLink("old.100", 3, 1, 0, DanmLinkAlias),
+    createReconcileLink("vx_old", 4, 1, 0, DanmLinkAlias),
+    createReconcileLink("foreign.300", 5, 1, 0, ""),
+    createReconcileLink("other.400", 6, 1, 0, "other"),
+    createReconcileLink("vx_stacked", 7, 1, 0, DanmLinkAlias),
+    createReconcileLink("stacked.500", 8, 7, 0, ""),
+  }
+  netWatcher := &NetWatcher{}
+  for _, tc := range staleLinkTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      var deletedLinks []string
+      deleteLink = func(link netlink.Link) error {
+        deletedLinks = append(deletedLinks, link.Attrs().Name)
+        if tc.isDeleteFailing {
+          return errors.New("deletion failed")
+        }
+        return nil
+      }
+      netWatcher.collectStaleLinks(existingLinks, getDesiredHostLinks(nets), tc.usedNets, nets)
+      sort.Strings(deletedLinks)
+      if !reflect.DeepEqual(deletedLinks, tc.expectedDeletions) {
+        t.Errorf("Deleted interfaces:%v do not match with expectation:%v", deletedLinks, tc.expectedDeletions)
+      }
+    })
+  }
+}
+
+func TestGetNetworkProvisioningStates(t *testing.T) {
+  for _, tc := range provisioningStateTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      netStates := getNetworkProvisioningStates(tc.nets, getDesiredHostLinks(tc.nets), tc.linkErrors, nil)
+      reasons := make(map[string]string)
+      for _, netState := range netStates {
+        if netState.Ready != (netState.Reason == "") {
+          t.Errorf("Network:%s is ready:%t with reason:%s", netState.Name, netState.Ready, netState.Reason)
+        }
+        reasons[netState.Name] = netState.Reason
+      }
+      if !reflect.DeepEqual(reasons, tc.expectedReasons) {
+        t.Errorf("Reasons of the provisioning states:%v do not match with expectation:%v", reasons, tc.expectedReasons)
+      }
+    })
+  }
+}
+
+func createReconcileNet(kind, namespace, name, networkId, device string, vlan, vxlan int) danmtypes.DanmNet {
+  return danmtypes.DanmNet {
+    TypeMeta: meta_v1.TypeMeta{Kind: kind},
+    ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: namespace},
+    Spec: danmtypes.DanmNetSpec{NetworkID: networkId, Options: danmtypes.DanmNetOption{Device: device, Vlan: vlan, Vxlan: vxlan}},
+  }
+}
+
+func createReconcileLink(name string, index, parentIndex, masterIndex int, alias string) netlink.Link {
+  return &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: name, Index: index, ParentIndex: parentIndex, MasterIndex: masterIndex, Alias: alias}}
+}
@@ -0,0 +1,160 @@
+package netcontrol
+
+import (
+  "context"
+  "errors"
+  "log"
+  "net"
+  "sort"
+  "syscall"
+  "github.com/vishvananda/netlink"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+  "k8s.io/apimachinery/pkg/labels"
+)
+
+var (
+  //BUM traffic of unicast VxLANs is replicated to every FDB entry with the all-zero MAC
+  floodMac = net.HardwareAddr{0, 0, 0, 0, 0, 0}
+)
+
+// GetVxlanPeers returns the VTEP addresses the other nodes advertised in their NodeNetworkStates for a unicast VxLAN network
+// Only nodes where the VxLAN interface of the network is ready are returned
+// NodeNetworkStates of Nodes not existing anymore are skipped, as their garbage collection might lag behind. Nodes are not checked when existingNodes is nil
+func GetVxlanPeers(nodeStates []*danmtypes.NodeNetworkState, existingNodes map[string]bool, nodeName string, dnet *danmtypes.DanmNet) []string {
+  peerSet := make(map[string]bool)
+  for _, nodeState := range nodeStates {
+    if nodeState.ObjectMeta.Name == nodeName || (existingNodes != nil && !existingNodes[nodeState.ObjectMeta.Name]) {
+      continue
+    }
+    for _, netState := range nodeState.Networks {
+      if isStateOfNetwork(netState, dnet) && netState.Ready && net.ParseIP(netState.Vtep) != nil {
+        peerSet[netState.Vtep] = true
+      }
+    }
+  }
+  peers := make([]string, 0, len(peerSet))
+  for peer := range peerSet {
+    peers = append(peers, peer)
+  }
+  sort.Strings(peers)
+  return peers
+}
+
+//getLocalVteps returns the source addresses of the successfully reconciled unicast VxLAN interfaces, indexed by the name of the interface
+func getLocalVteps(desiredLinks map[string]hostLink, linkErrors map[string]error) map[string]string {
+  vteps := make(map[string]string)
+  for name, desiredLink := range desiredLinks {
+    if !desiredLink.vxlan.isUnicast || linkErrors[name] != nil {
+      continue
+    }
+    link, err := netlink.LinkByName(name)
+    if err != nil {
+      continue
+    }
+    vxlan, isVxlan := link.(*netlink.Vxlan)
+    if isVxlan && vxlan.SrcAddr != nil {
+      vteps[name] = vxlan.SrcAddr.String()
+    }
+  }
+  return vteps
+}
+
+//syncVxlanPeers sets the head-end replication list of every local unicast VxLAN interface to the VTEPs of the other nodes
+func (netWatcher *NetWatcher) syncVxlanPeers(nets []danmtypes.DanmNet, desiredLinks map[string]hostLink, localVteps map[string]string) {
+  factory, isApiUsed := netWatcher.Factories[NodeNetworkStateKind]
+  if !isApiUsed {
+    return
+  }
+  nodeStates, err := factory.Danm().V1().NodeNetworkStates().Lister().List(labels.Everything())
+  if err != nil {
+    log.Println("ERROR: Unicast VxLAN peers cannot be synchronized, because NodeNetworkStates cannot be listed:" + err.Error())
+    return
+  }
+  existingNodes, err := netWatcher.getExistingNodes()
+  if err != nil {
+    log.Println("ERROR: Unicast VxLAN peers cannot be synchronized, because Nodes cannot be listed:" + err.Error())
+    return
+  }
+  for _, dnet := range nets {
+    if !IsUnicastVxlan(&dnet) || dnet.Spec.Options.Device == "" {
+      continue
+    }
+    linkName := "vx_" + dnet.Spec.NetworkID
+    //Peers are only synchronized on interfaces which are up, and belong to the network
+    if desiredLinks[linkName].network != getNetworkName(&dnet) || localVteps[linkName] == "" {
+      continue
+    }
+    err = syncFloodEntries(linkName, GetVxlanPeers(nodeStates, existingNodes, netWatcher.NodeName, &dnet))
+    if err != nil {
+      log.Println("ERROR: Peers of unicast VxLAN interface:" + linkName + " could not be synchronized, because:" + err.Error())
+    }
+  }
+}
+
+//getExistingNodes returns the names of the Nodes of the cluster, or nil when netwatcher has no K8s client
+func (netWatcher *NetWatcher) getExistingNodes() (map[string]bool,error) {
+  if netWatcher.KubeClient == nil {
+    return nil, nil
+  }
+  nodes, err := netWatcher.KubeClient.CoreV1().Nodes().List(context.TODO(), meta_v1.ListOptions{ResourceVersion: "0"})
+  if err != nil {
+    return nil, err
+  }
+  existingNodes := make(map[string]bool)
+  for _, node := range nodes.Items {
+    existingNodes[node.ObjectMeta.Name] = true
+  }
+  return existingNodes, nil
+}
+
+func syncFloodEntries(linkName string, peers []string) error {
+  link, err := netlink.LinkByName(linkName)
+  if err != nil {
+    return errors.New("interface cannot be found:" + err.Error())
+  }
+  fdbEntries, err := netlink.NeighList(link.Attrs().Index, syscall.AF_BRIDGE)
+  if err != nil {
+    return errors.New("FDB entries cannot be listed:" + err.Error())
+  }
+  existingPeers := make(map[string]bool)
+  for _, entry := range fdbEntries {
+    if entry.IP != nil && entry.HardwareAddr.String() == floodMac.String() {
+      existingPeers[entry.IP.String()] = true
+    }
+  }
+  desiredPeers := make(map[string]bool)
+  for _, peer := range peers {
+    desiredPeers[peer] = true
+    if existingPeers[peer] {
+      continue
+    }
+    err = netlink.NeighAppend(newFloodEntry(link, peer))
+    if err != nil {
+      return errors.New("FDB entry of peer:" + peer + " cannot be added:" + err.Error())
+    }
+    log.Println("INFO: Peer:" + peer + " was added to unicast VxLAN interface:" + linkName)
+  }
+  for peer := range existingPeers {
+    if desiredPeers[peer] {
+      continue
+    }
+    err = netlink.NeighDel(newFloodEntry(link, peer))
+    if err != nil {
+      return errors.New("FDB entry of departed peer:" + peer + " cannot be deleted:" + err.Error())
+    }
+    log.Println("INFO: Departed peer:" + peer + " was removed from unicast VxLAN interface:" + linkName)
+  }
+  return nil
+}
+
+func newFloodEntry(link netlink.Link, peer string) *netlink.Neigh {
+  return &netlink.Neigh {
+    LinkIndex:    link.Attrs().Index,
+    Family:       syscall.AF_BRIDGE,
+    State:        netlink.NUD_PERMANENT | netlink.NUD_NOARP,
+    Flags:        netlink.NTF_SELF,
+    IP:           net.ParseIP(peer),
+    HardwareAddr: floodMac,
+  }
+}
@@ -10,6 +10,8 @@ type ClientStub struct {
   Objects utils.TestArtifacts
   NetClient *NetClientStub
   TconfClient *TconfClientStub
+  VipClient *VipClientStub
+  EpClient *EpClientStub
 }
 
 func (client *ClientStub) DanmNets(namespace string) client.DanmNetInterface {
@@ -20,7 +22,17 @@ func (client *ClientStub) DanmNets(namespace string) client.DanmNetInterface {
 }
 
 func (client *ClientStub) DanmEps(namespace string) client.DanmEpInterface {
-  return newEpClientStub(client.Objects.TestEps)
+  if client.EpClient == nil {
+    client.EpClient = newEpClientStub(client.Objects.TestEps)
+  }
+  return client.EpClient
+}
+
+func (client *ClientStub) DanmVips(namespace string) client.DanmVipInterface {
+  if client.VipClient == nil {
+    client.VipClient = newVipClientStub(client.Objects.TestVips)
+  }
+  return client.VipClient
 }
 
 func (client *ClientStub) TenantConfigs() client.TenantConfigInterface {
@@ -31,11 +43,15 @@ func (client *ClientStub) TenantConfigs() client.TenantConfigInterface {
 }
 
 func (client *ClientStub) TenantNetworks(namespace string) client.TenantNetworkInterface {
-  return nil
+  return newTenantNetClientStub(client.Objects.TestNets)
 }
 
 func (client *ClientStub) ClusterNetworks() client.ClusterNetworkInterface {
-  return nil
+  return newClusterNetClientStub(client.Objects.TestNets)
+}
+
+func (client *ClientStub) NodeNetworkStates() client.NodeNetworkStateInterface {
+  return newNodeStateClientStub(client.Objects.TestNodeStates)
 }
 
 func (client *ClientStub) RESTClient() rest.Interface {
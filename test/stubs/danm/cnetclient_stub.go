@@ -0,0 +1,62 @@
+package danm
+
+import (
+  "context"
+  "errors"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+  types "k8s.io/apimachinery/pkg/types"
+  watch "k8s.io/apimachinery/pkg/watch"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+)
+
+//ClusterNetClientStub only knows about the test networks explicitly marked with the ClusterNetwork Kind
+type ClusterNetClientStub struct{
+  TestNets []danmtypes.DanmNet
+}
+
+func newClusterNetClientStub(nets []danmtypes.DanmNet) *ClusterNetClientStub {
+  return &ClusterNetClientStub{TestNets: nets}
+}
+
+func (cnetClient *ClusterNetClientStub) Create(ctx context.Context, obj *danmtypes.ClusterNetwork, opts meta_v1.CreateOptions) (*danmtypes.ClusterNetwork, error) {
+  return nil, nil
+}
+
+func (cnetClient *ClusterNetClientStub) Update(ctx context.Context, obj *danmtypes.ClusterNetwork, opts meta_v1.UpdateOptions) (*danmtypes.ClusterNetwork, error) {
+  return obj, nil
+}
+
+func (cnetClient *ClusterNetClientStub) Delete(ctx context.Context, name string, options meta_v1.DeleteOptions) error {
+  return nil
+}
+
+func (cnetClient *ClusterNetClientStub) DeleteCollection(ctx context.Context, options meta_v1.DeleteOptions, listOptions meta_v1.ListOptions) error {
+  return nil
+}
+
+func (cnetClient *ClusterNetClientStub) Get(ctx context.Context, netName string, options meta_v1.GetOptions) (*danmtypes.ClusterNetwork, error) {
+  for _, testNet := range cnetClient.TestNets {
+    if testNet.TypeMeta.Kind == "ClusterNetwork" && testNet.ObjectMeta.Name == netName {
+      return &danmtypes.ClusterNetwork{TypeMeta: testNet.TypeMeta, ObjectMeta: testNet.ObjectMeta, Spec: testNet.Spec}, nil
+    }
+  }
+  return nil, errors.New("ClusterNetwork:" + netName + " does not exist")
+}
+
+func (cnetClient *ClusterNetClientStub) List(ctx context.Context, opts meta_v1.ListOptions) (*danmtypes.ClusterNetworkList, error) {
+  cnetList := danmtypes.ClusterNetworkList{}
+  for _, testNet := range cnetClient.TestNets {
+    if testNet.TypeMeta.Kind == "ClusterNetwork" {
+      cnetList.Items = append(cnetList.Items, danmtypes.ClusterNetwork{TypeMeta: testNet.TypeMeta, ObjectMeta: testNet.ObjectMeta, Spec: testNet.Spec})
+    }
+  }
+  return &cnetList, nil
+}
+
+func (cnetClient *ClusterNetClientStub) Watch(ctx context.Context, opts meta_v1.ListOptions) (watch.Interface, error) {
+  return watch.NewEmptyWatch(), nil
+}
+
+func (cnetClient *ClusterNetClientStub) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts meta_v1.PatchOptions, subresources ...string) (result *danmtypes.ClusterNetwork, err error) {
+  return nil, nil
+}
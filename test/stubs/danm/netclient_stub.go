@@ -103,8 +103,15 @@ func (netClient *NetClientStub) Watch(ctx context.Context, opts meta_v1.ListOpti
   return watch, nil
 }
 
+//Only the test networks explicitly marked with the DanmNet Kind are listed, so they don't collide with validated manifests by accident
 func (netClient *NetClientStub) List(ctx context.Context, opts meta_v1.ListOptions) (*danmtypes.DanmNetList, error) {
-  return nil, nil
+  dnetList := danmtypes.DanmNetList{}
+  for _, testNet := range netClient.TestNets {
+    if testNet.TypeMeta.Kind == "DanmNet" {
+      dnetList.Items = append(dnetList.Items, testNet)
+    }
+  }
+  return &dnetList, nil
 }
 
 func (netClient *NetClientStub) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts meta_v1.PatchOptions, subresources ...string) (result *danmtypes.DanmNet, err error) {
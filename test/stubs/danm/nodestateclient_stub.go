@@ -0,0 +1,55 @@
+package danm
+
+import (
+  "context"
+  "errors"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+  types "k8s.io/apimachinery/pkg/types"
+  watch "k8s.io/apimachinery/pkg/watch"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+)
+
+type NodeStateClientStub struct{
+  TestNodeStates []danmtypes.NodeNetworkState
+}
+
+func newNodeStateClientStub(nodeStates []danmtypes.NodeNetworkState) *NodeStateClientStub {
+  return &NodeStateClientStub{TestNodeStates: nodeStates}
+}
+
+func (nodeStateClient *NodeStateClientStub) Create(ctx context.Context, obj *danmtypes.NodeNetworkState, opts meta_v1.CreateOptions) (*danmtypes.NodeNetworkState, error) {
+  return obj, nil
+}
+
+func (nodeStateClient *NodeStateClientStub) Update(ctx context.Context, obj *danmtypes.NodeNetworkState, opts meta_v1.UpdateOptions) (*danmtypes.NodeNetworkState, error) {
+  return obj, nil
+}
+
+func (nodeStateClient *NodeStateClientStub) Delete(ctx context.Context, name string, options meta_v1.DeleteOptions) error {
+  return nil
+}
+
+func (nodeStateClient *NodeStateClientStub) DeleteCollection(ctx context.Context, options meta_v1.DeleteOptions, listOptions meta_v1.ListOptions) error {
+  return nil
+}
+
+func (nodeStateClient *NodeStateClientStub) Get(ctx context.Context, nodeName string, options meta_v1.GetOptions) (*danmtypes.NodeNetworkState, error) {
+  for _, nodeState := range nodeStateClient.TestNodeStates {
+    if nodeState.ObjectMeta.Name == nodeName {
+      return &nodeState, nil
+    }
+  }
+  return nil, errors.New("NodeNetworkState:" + nodeName + " does not exist")
+}
+
+func (nodeStateClient *NodeStateClientStub) List(ctx context.Context, opts meta_v1.ListOptions) (*danmtypes.NodeNetworkStateList, error) {
+  return &danmtypes.NodeNetworkStateList{Items: nodeStateClient.TestNodeStates}, nil
+}
+
+func (nodeStateClient *NodeStateClientStub) Watch(ctx context.Context, opts meta_v1.ListOptions) (watch.Interface, error) {
+  return watch.NewEmptyWatch(), nil
+}
+
+func (nodeStateClient *NodeStateClientStub) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts meta_v1.PatchOptions, subresources ...string) (result *danmtypes.NodeNetworkState, err error) {
+  return nil, nil
+}
@@ -19,6 +19,7 @@ type TconfClientStub struct{
   ReservedVnis []utils.ReservedVnisList
   TimesUpdateWasCalled int
   ExhaustAllocs []int
+  LastUpdatedTconf *danmtypes.TenantConfig
 }
 
 func newTconfClientStub(tconfs []danmtypes.TenantConfig, vnis []utils.ReservedVnisList, exhaustAllocs []int) *TconfClientStub {
@@ -68,6 +69,7 @@ func (tconfClient *TconfClientStub) Update(ctx context.Context, obj *danmtypes.T
   if strings.HasPrefix(obj.ObjectMeta.Name,"error") {
     return nil, errors.New("here you go")
   }
+  tconfClient.LastUpdatedTconf = obj.DeepCopy()
   return &danmtypes.TenantConfig{}, nil
 }
 
@@ -88,7 +90,7 @@ func (tconfClient *TconfClientStub) Get(ctx context.Context, tconfName string, o
       return &tconf, nil
     }
   }
-  return nil, nil
+  return nil, errors.New("TenantConfig:" + tconfName + " does not exist")
 }
 
 func (tconfClient *TconfClientStub) Watch(ctx context.Context, opts meta_v1.ListOptions) (watch.Interface, error) {
@@ -0,0 +1,62 @@
+package danm
+
+import (
+  "context"
+  "errors"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+  types "k8s.io/apimachinery/pkg/types"
+  watch "k8s.io/apimachinery/pkg/watch"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+)
+
+//TenantNetClientStub only knows about the test networks explicitly marked with the TenantNetwork Kind
+type TenantNetClientStub struct{
+  TestNets []danmtypes.DanmNet
+}
+
+func newTenantNetClientStub(nets []danmtypes.DanmNet) *TenantNetClientStub {
+  return &TenantNetClientStub{TestNets: nets}
+}
+
+func (tnetClient *TenantNetClientStub) Create(ctx context.Context, obj *danmtypes.TenantNetwork, opts meta_v1.CreateOptions) (*danmtypes.TenantNetwork, error) {
+  return nil, nil
+}
+
+func (tnetClient *TenantNetClientStub) Update(ctx context.Context, obj *danmtypes.TenantNetwork, opts meta_v1.UpdateOptions) (*danmtypes.TenantNetwork, error) {
+  return obj, nil
+}
+
+func (tnetClient *TenantNetClientStub) Delete(ctx context.Context, name string, options meta_v1.DeleteOptions) error {
+  return nil
+}
+
+func (tnetClient *TenantNetClientStub) DeleteCollection(ctx context.Context, options meta_v1.DeleteOptions, listOptions meta_v1.ListOptions) error {
+  return nil
+}
+
+func (tnetClient *TenantNetClientStub) Get(ctx context.Context, netName string, options meta_v1.GetOptions) (*danmtypes.TenantNetwork, error) {
+  for _, testNet := range tnetClient.TestNets {
+    if testNet.TypeMeta.Kind == "TenantNetwork" && testNet.ObjectMeta.Name == netName {
+      return &danmtypes.TenantNetwork{TypeMeta: testNet.TypeMeta, ObjectMeta: testNet.ObjectMeta, Spec: testNet.Spec}, nil
+    }
+  }
+  return nil, errors.New("TenantNetwork:" + netName + " does not exist")
+}
+
+func (tnetClient *TenantNetClientStub) List(ctx context.Context, opts meta_v1.ListOptions) (*danmtypes.TenantNetworkList, error) {
+  tnetList := danmtypes.TenantNetworkList{}
+  for _, testNet := range tnetClient.TestNets {
+    if testNet.TypeMeta.Kind == "TenantNetwork" {
+      tnetList.Items = append(tnetList.Items, danmtypes.TenantNetwork{TypeMeta: testNet.TypeMeta, ObjectMeta: testNet.ObjectMeta, Spec: testNet.Spec})
+    }
+  }
+  return &tnetList, nil
+}
+
+func (tnetClient *TenantNetClientStub) Watch(ctx context.Context, opts meta_v1.ListOptions) (watch.Interface, error) {
+  return watch.NewEmptyWatch(), nil
+}
+
+func (tnetClient *TenantNetClientStub) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts meta_v1.PatchOptions, subresources ...string) (result *danmtypes.TenantNetwork, err error) {
+  return nil, nil
+}
@@ -0,0 +1,61 @@
+package danm
+
+import (
+  "context"
+  "errors"
+  "strings"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+  types "k8s.io/apimachinery/pkg/types"
+  watch "k8s.io/apimachinery/pkg/watch"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+)
+
+type VipClientStub struct{
+  TestVips []danmtypes.DanmVip
+  UpdatedVips []danmtypes.DanmVip
+}
+
+func newVipClientStub(vips []danmtypes.DanmVip) *VipClientStub {
+  return &VipClientStub{TestVips: vips}
+}
+
+func (vipClient *VipClientStub) Create(ctx context.Context, obj *danmtypes.DanmVip, opts meta_v1.CreateOptions) (*danmtypes.DanmVip, error) {
+  return obj, nil
+}
+
+func (vipClient *VipClientStub) Update(ctx context.Context, obj *danmtypes.DanmVip, opts meta_v1.UpdateOptions) (*danmtypes.DanmVip, error) {
+  if strings.Contains(obj.ObjectMeta.Name, "error") {
+    return nil, errors.New("fatal error, don't retry")
+  }
+  vipClient.UpdatedVips = append(vipClient.UpdatedVips, *obj)
+  return obj, nil
+}
+
+func (vipClient *VipClientStub) Delete(ctx context.Context, name string, options meta_v1.DeleteOptions) error {
+  return nil
+}
+
+func (vipClient *VipClientStub) DeleteCollection(ctx context.Context, options meta_v1.DeleteOptions, listOptions meta_v1.ListOptions) error {
+  return nil
+}
+
+func (vipClient *VipClientStub) Get(ctx context.Context, vipName string, options meta_v1.GetOptions) (*danmtypes.DanmVip, error) {
+  for _, vip := range vipClient.TestVips {
+    if vip.ObjectMeta.Name == vipName {
+      return &vip, nil
+    }
+  }
+  return nil, errors.New("DanmVip:" + vipName + " does not exist")
+}
+
+func (vipClient *VipClientStub) List(ctx context.Context, opts meta_v1.ListOptions) (*danmtypes.DanmVipList, error) {
+  return &danmtypes.DanmVipList{Items: vipClient.TestVips}, nil
+}
+
+func (vipClient *VipClientStub) Watch(ctx context.Context, opts meta_v1.ListOptions) (watch.Interface, error) {
+  return watch.NewEmptyWatch(), nil
+}
+
+func (vipClient *VipClientStub) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts meta_v1.PatchOptions, subresources ...string) (result *danmtypes.DanmVip, err error) {
+  return nil, nil
+}
@@ -5,7 +5,7 @@ import (
   "errors"
   "io/ioutil"
   "net/http"
-  "k8s.io/api/admission/v1beta1"
+  admissionv1 "k8s.io/api/admission/v1"
   "k8s.io/apimachinery/pkg/runtime"
   "k8s.io/apimachinery/pkg/runtime/serializer"
 )
@@ -34,11 +34,19 @@ func (writer *ResponseWriterStub) WriteHeader(statusCode int) {
   return
 }
 
-func (writer *ResponseWriterStub) GetAdmissionResponse() (*v1beta1.AdmissionResponse,error) {
+func (writer *ResponseWriterStub) GetAdmissionResponse() (*admissionv1.AdmissionResponse,error) {
+  review, err := writer.GetAdmissionReview()
+  if err != nil {
+    return nil, err
+  }
+  return review.Response, nil
+}
+
+func (writer *ResponseWriterStub) GetAdmissionReview() (*admissionv1.AdmissionReview,error) {
   if writer.Response == nil {
     return nil, errors.New("no response was sent")
   }
-  review := v1beta1.AdmissionReview{}
+  review := admissionv1.AdmissionReview{}
   reader := bytes.NewReader(writer.Response)
   readCloser := ioutil.NopCloser(reader)
   payload, err := ioutil.ReadAll(readCloser)
@@ -48,5 +56,5 @@ func (writer *ResponseWriterStub) GetAdmissionResponse() (*v1beta1.AdmissionResp
   codecs := serializer.NewCodecFactory(runtime.NewScheme())
   deserializer := codecs.UniversalDeserializer()
   _, _, err = deserializer.Decode(payload, nil, &review)
-  return review.Response, err
+  return &review, err
 }
\ No newline at end of file
@@ -14,7 +14,13 @@ import (
   "github.com/nokia/danm/pkg/ipam"
   "github.com/nokia/danm/pkg/admit"
   httpstub "github.com/nokia/danm/test/stubs/http"
+  admissionv1 "k8s.io/api/admission/v1"
   "k8s.io/api/admission/v1beta1"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+  TestReviewUid = "danm-test-review"
 )
 
 var (
@@ -29,6 +35,8 @@ type TestArtifacts struct {
   TestTconfs []danmtypes.TenantConfig
   ReservedVnis []ReservedVnisList
   ExhaustAllocs []int
+  TestNodeStates []danmtypes.NodeNetworkState
+  TestVips []danmtypes.DanmVip
 }
 
 type ReservedIpsList struct {
@@ -133,8 +141,13 @@ func GetTconf(tconfName string, tconfSet []danmtypes.TenantConfig) *danmtypes.Te
 }
 
 func CreateHttpRequest(oldObj, newObj []byte, isOldMalformed, isNewMalformed bool, opType v1beta1.Operation) (*http.Request, error) {
-  request := v1beta1.AdmissionRequest{}
-  review := v1beta1.AdmissionReview{Request: &request}
+  return CreateVersionedHttpRequest(oldObj, newObj, isOldMalformed, isNewMalformed, admissionv1.Operation(opType), v1beta1.SchemeGroupVersion.String())
+}
+
+func CreateVersionedHttpRequest(oldObj, newObj []byte, isOldMalformed, isNewMalformed bool, opType admissionv1.Operation, apiVersion string) (*http.Request, error) {
+  request := admissionv1.AdmissionRequest{UID: TestReviewUid}
+  review := admissionv1.AdmissionReview{Request: &request}
+  review.TypeMeta = meta_v1.TypeMeta{APIVersion: apiVersion, Kind: "AdmissionReview"}
   if opType != "" {
     review.Request.Operation = opType
   }
@@ -157,6 +170,21 @@ func CreateHttpRequest(oldObj, newObj []byte, isOldMalformed, isNewMalformed boo
   return &httpRequest, err
 }
 
+func CreateHttpRequestFromUser(oldObj, newObj []byte, opType admissionv1.Operation, userName string) (*http.Request, error) {
+  request := admissionv1.AdmissionRequest{UID: TestReviewUid, Operation: opType}
+  request.UserInfo.Username = userName
+  request.OldObject.Raw = oldObj
+  request.Object.Raw = newObj
+  review := admissionv1.AdmissionReview{Request: &request}
+  review.TypeMeta = meta_v1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"}
+  rawReview, err := json.Marshal(review)
+  if err != nil {
+    return nil, errors.New("AdmissionReview couldn't be marshalled because:" + err.Error())
+  }
+  httpRequest := http.Request{Body: ioutil.NopCloser(bytes.NewReader(rawReview))}
+  return &httpRequest, nil
+}
+
 func canItMalform(obj []byte, shouldBeMalformed bool) []byte {
   if shouldBeMalformed {
     malformedObj := MalformedObject{ExtraField: "blupp"}
@@ -191,13 +219,16 @@ func ValidateHttpResponse(writer *httpstub.ResponseWriterStub, isErrorExpected b
   return validatePatches(response, expectedPatches)
 }
 
-func validatePatches(response *v1beta1.AdmissionResponse, expectedPatches []admit.Patch) error {
+func validatePatches(response *admissionv1.AdmissionResponse, expectedPatches []admit.Patch) error {
   if len(expectedPatches) == 0 {
     if response.Patch != nil {
       return errors.New("did not expect any patches but some were included in the admission response")
     }
     return nil
   }
+  if response.PatchType == nil || *response.PatchType != admissionv1.PatchTypeJSONPatch {
+    return errors.New("patches were included in the admission response without setting the JSONPatch patchType")
+  }
   var patches []admit.Patch
   err := json.Unmarshal(response.Patch, &patches)
   if err != nil {
@@ -0,0 +1,65 @@
+package admit_tests
+
+import (
+  "testing"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/admit"
+  "github.com/nokia/danm/pkg/bitarray"
+  stubs "github.com/nokia/danm/test/stubs/danm"
+  "github.com/nokia/danm/test/utils"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+  auditNets = []danmtypes.DanmNet {
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "audit-default", Namespace: "default"},
+      TypeMeta: meta_v1.TypeMeta {Kind: TnetType},
+      Spec: danmtypes.DanmNetSpec{NetworkID: "audit-default", NetworkType: "ipvlan", Options: danmtypes.DanmNetOption{Device: "ens4", Vlan: 900}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "audit-blue", Namespace: "blue"},
+      TypeMeta: meta_v1.TypeMeta {Kind: TnetType},
+      Spec: danmtypes.DanmNetSpec{NetworkID: "audit-blue", NetworkType: "ipvlan", Options: danmtypes.DanmNetOption{Device: "ens4", Vlan: 901}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "audit-moved", Namespace: "default", Annotations: map[string]string{admit.TenantConfigAnnotation: "audit-blue"}},
+      TypeMeta: meta_v1.TypeMeta {Kind: TnetType},
+      Spec: danmtypes.DanmNetSpec{NetworkID: "audit-moved", NetworkType: "ipvlan", Options: danmtypes.DanmNetOption{Device: "ens4", Vlan: 902}},
+    },
+  }
+)
+
+func TestAuditTenantConfigs(t *testing.T) {
+  allocs := bitarray.NewBitArrayFromBase64(utils.AllocFor5k)
+  allocs.Set(900)
+  allocs.Set(901)
+  allocs.Set(902)
+  tconf := danmtypes.TenantConfig {
+    ObjectMeta: meta_v1.ObjectMeta {Name: "audit-blue"},
+    Namespaces: []string{"blue"},
+    HostDevices: []danmtypes.IfaceProfile {
+      danmtypes.IfaceProfile{Name: "ens4", VniType: "vlan", VniRange: "900-999", Alloc: allocs.Encode()},
+    },
+  }
+  testClient := stubs.NewClientSetStub(utils.TestArtifacts{TestNets: auditNets, TestTconfs: []danmtypes.TenantConfig{tconf}})
+  validator := admit.Validator{Client: testClient}
+  err := validator.AuditTenantConfigs(false)
+  if err != nil {
+    t.Errorf("TenantConfigs could not be audited, because:%v", err)
+    return
+  }
+  updatedTconf := testClient.DanmClient.TconfClient.LastUpdatedTconf
+  if updatedTconf == nil || updatedTconf.Status == nil || len(updatedTconf.Status.Profiles) != 1 {
+    t.Errorf("Audit status was not recorded in TenantConfig")
+    return
+  }
+  profileStatus := updatedTconf.Status.Profiles[0]
+  //Only the TenantNetwork of the default namespace whose VNI was reserved from the audited TenantConfig is served by it
+  if profileStatus.UsedVnis != 2 || len(profileStatus.LeakedVnis) != 1 || profileStatus.LeakedVnis[0] != 900 {
+    t.Errorf("Audit status:%+v does not report VNI:900 as leaked", profileStatus)
+  }
+  if !bitarray.NewBitArrayFromBase64(updatedTconf.HostDevices[0].Alloc).Get(900) {
+    t.Errorf("Leaked VNI:900 was freed without repair being requested")
+  }
+}
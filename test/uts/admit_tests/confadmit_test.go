@@ -6,8 +6,11 @@ import (
   "encoding/json"
   danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
   "github.com/nokia/danm/pkg/admit"
+  "github.com/nokia/danm/pkg/bitarray"
+  stubs "github.com/nokia/danm/test/stubs/danm"
   httpstub "github.com/nokia/danm/test/stubs/http"
   "github.com/nokia/danm/test/utils"
+  admissionv1 "k8s.io/api/admission/v1"
   "k8s.io/api/admission/v1beta1"
   meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -135,6 +138,164 @@ var (
         "flannel": "flannel",
        },
     },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "overlapping-list"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      Namespaces: []string{"tenant-b", "tenant-a"},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "listed-conf"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      Namespaces: []string{"tenant-a"},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "overlapping-selector"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      NamespaceSelector: &meta_v1.LabelSelector{MatchExpressions: []meta_v1.LabelSelectorRequirement{meta_v1.LabelSelectorRequirement{Key: "tenant", Operator: meta_v1.LabelSelectorOpIn, Values: []string{"blue", "red"}}}},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "disjoint-selector"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      NamespaceSelector: &meta_v1.LabelSelector{MatchLabels: map[string]string{"tenant": "red"}},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "invalid-selector"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      NamespaceSelector: &meta_v1.LabelSelector{MatchExpressions: []meta_v1.LabelSelectorRequirement{meta_v1.LabelSelectorRequirement{Key: "tenant", Operator: "Maybe"}}},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "empty-namespace"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      Namespaces: []string{""},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "negative-quota"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      Quota: &danmtypes.TenantQuota{MaxNetworks: 5, MaxIps: -1},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "valid-quota"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      Quota: &danmtypes.TenantQuota{MaxNetworks: 5, MaxVnisPerProfile: 2},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "invalid-placement"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile{danmtypes.IfaceProfile{Name: "ens4"}},
+      PlacementPolicy: "first-fit",
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "negative-weight"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile{danmtypes.IfaceProfile{Name: "ens4", Weight: -1}},
+      PlacementPolicy: "weighted",
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "valid-placement"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile{danmtypes.IfaceProfile{Name: "ens4", Weight: 3, Labels: map[string]string{"speed": "fast"}}},
+      PlacementPolicy: "label-affinity",
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "invalid-supernet"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      Supernets: []danmtypes.TenantSupernet{danmtypes.TenantSupernet{Cidr: "2a00:8a00:a000:1193::/64", PrefixLength: 80}},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "short-supernet-prefix"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      Supernets: []danmtypes.TenantSupernet{danmtypes.TenantSupernet{Cidr: "10.0.0.0/16", PrefixLength: 12}},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "too-many-subnets"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      Supernets: []danmtypes.TenantSupernet{danmtypes.TenantSupernet{Cidr: "10.0.0.0/8", PrefixLength: 24}},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "manual-supernet-alloc"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      Supernets: []danmtypes.TenantSupernet{danmtypes.TenantSupernet{Cidr: "10.0.0.0/16", PrefixLength: 24, Alloc: "AA=="}},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "valid-supernet"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      Supernets: []danmtypes.TenantSupernet{danmtypes.TenantSupernet{Cidr: "10.0.0.0/16", PrefixLength: 24}},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "carved-supernet"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      Supernets: []danmtypes.TenantSupernet{danmtypes.TenantSupernet{Cidr: "10.0.0.0/24", PrefixLength: 26, Alloc: "QA=="}},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "resized-supernet"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel"},
+      Supernets: []danmtypes.TenantSupernet{danmtypes.TenantSupernet{Cidr: "10.0.0.0/24", PrefixLength: 25, Alloc: "QA=="}},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "carved-supernet-kept"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      NetworkIds: map[string]string {"flannel": "flannel", "calico": "calico"},
+      Supernets: []danmtypes.TenantSupernet{danmtypes.TenantSupernet{Cidr: "10.0.0.0/24", PrefixLength: 26, Alloc: "QA=="}},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vni-in-use"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens4", VniType: "vxlan", VniRange: "700-710", Alloc: vniInUseAlloc},
+        danmtypes.IfaceProfile{Name: "ens5", VniType: "vlan", VniRange: "200-210", Alloc: utils.AllocFor5k},
+      },
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "used-profile-removed"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens5", VniType: "vlan", VniRange: "200-210", Alloc: utils.AllocFor5k},
+      },
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "used-profile-type-changed"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens4", VniType: "vlan", VniRange: "700-710"},
+      },
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "unused-profile-removed"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens4", VniType: "vxlan", VniRange: "700-710", Alloc: vniInUseAlloc},
+      },
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "used-vni-out-of-range"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens4", VniType: "vxlan", VniRange: "700-704", Alloc: vniInUseAlloc},
+        danmtypes.IfaceProfile{Name: "ens5", VniType: "vlan", VniRange: "200-210", Alloc: utils.AllocFor5k},
+      },
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "leaked-vni-out-of-range"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens4", VniType: "vxlan", VniRange: "700-705", Alloc: vniInUseAlloc},
+        danmtypes.IfaceProfile{Name: "ens5", VniType: "vlan", VniRange: "200-210", Alloc: utils.AllocFor5k},
+      },
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "used-vni-freed"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens4", VniType: "vxlan", VniRange: "700-710", Alloc: createVniAlloc(706)},
+        danmtypes.IfaceProfile{Name: "ens5", VniType: "vlan", VniRange: "200-210", Alloc: utils.AllocFor5k},
+      },
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vni-range-grown"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens4", VniType: "vxlan", VniRange: "700-800"},
+        danmtypes.IfaceProfile{Name: "ens5", VniType: "vlan", VniRange: "200-210", Alloc: utils.AllocFor5k},
+      },
+    },
+  }
+  existingSelectionConfs = []danmtypes.TenantConfig {
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "listed-conf"},
+      Namespaces: []string{"tenant-a"},
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "blue-conf"},
+      NamespaceSelector: &meta_v1.LabelSelector{MatchLabels: map[string]string{"tenant": "blue"}},
+    },
   }
 )
 
@@ -157,25 +318,73 @@ var validateTconfTcs = []struct {
   {"interfaceProfileWithInvalidVniType", "", "invalid-vni-type", "", true, nil},
   {"interfaceProfileWithInvalidVniValue", "", "invalid-vni-value", "", true, nil},
   {"interfaceProfileWithInvalidVniRange", "", "invalid-vni-range", "", true, nil},
-  {"interfaceProfileWithValidVniRange", "", "valid-vni-range", "", false, expectedPatch},
+  {"interfaceProfileWithValidVniRange", "", "valid-vni-range", "", false, firstAllocPatch},
   {"interfaceProfileWithSetAlloc", "", "manual-alloc", v1beta1.Create, true, nil},
-  {"interfaceProfileChangeWithAlloc", "manual-alloc-old", "manual-alloc", v1beta1.Update, false, expectedPatch},
+  {"interfaceProfileChangeWithAlloc", "manual-alloc-old", "manual-alloc", v1beta1.Update, false, secondAllocPatch},
   {"networkIdWithoutKey", "", "nonid", "", true, nil},
   {"networkIdWithoutValue", "", "nonetype", "", true, nil},
   {"longNidWithStaticNeType", "", "longnid", "", false, nil},
   {"longNidWithDynamicNeType", "", "longnid-sriov", "", true, nil},
   {"okayNids", "", "shortnid", "", false, nil},
   {"noChangeInIfaces", "old-iface", "new-iface", v1beta1.Update, false, nil},
+  {"overlappingNamespaceList", "", "overlapping-list", v1beta1.Create, true, nil},
+  {"updateOfListedNamespaces", "listed-conf", "listed-conf", v1beta1.Update, false, nil},
+  {"overlappingNamespaceSelector", "", "overlapping-selector", v1beta1.Create, true, nil},
+  {"disjointNamespaceSelector", "", "disjoint-selector", v1beta1.Create, false, nil},
+  {"invalidNamespaceSelector", "", "invalid-selector", v1beta1.Create, true, nil},
+  {"emptyNamespaceName", "", "empty-namespace", v1beta1.Create, true, nil},
+  {"negativeQuota", "", "negative-quota", v1beta1.Create, true, nil},
+  {"validQuota", "", "valid-quota", v1beta1.Create, false, nil},
+  {"invalidPlacementPolicy", "", "invalid-placement", v1beta1.Create, true, nil},
+  {"negativeProfileWeight", "", "negative-weight", v1beta1.Create, true, nil},
+  {"validPlacementPolicy", "", "valid-placement", v1beta1.Create, false, nil},
+  {"nonIpv4Supernet", "", "invalid-supernet", v1beta1.Create, true, nil},
+  {"supernetPrefixLengthShorterThanSupernet", "", "short-supernet-prefix", v1beta1.Create, true, nil},
+  {"supernetWithTooManySubnets", "", "too-many-subnets", v1beta1.Create, true, nil},
+  {"supernetWithSetAlloc", "", "manual-supernet-alloc", v1beta1.Create, true, nil},
+  {"validSupernet", "", "valid-supernet", v1beta1.Create, false, supernetAllocPatch},
+  {"removeSupernetWithCarvedSubnets", "carved-supernet", "shortnid", v1beta1.Update, true, nil},
+  {"resizeSupernetWithCarvedSubnets", "carved-supernet", "resized-supernet", v1beta1.Update, true, nil},
+  {"keepSupernetWithCarvedSubnets", "carved-supernet", "carved-supernet-kept", v1beta1.Update, false, nil},
+  {"removeProfileWithUsedVnis", "vni-in-use", "used-profile-removed", v1beta1.Update, true, nil},
+  {"changeVniTypeOfProfileWithUsedVnis", "vni-in-use", "used-profile-type-changed", v1beta1.Update, true, nil},
+  {"removeProfileWithoutUsedVnis", "vni-in-use", "unused-profile-removed", v1beta1.Update, false, nil},
+  {"shrinkVniRangeUnderUsedVni", "vni-in-use", "used-vni-out-of-range", v1beta1.Update, true, nil},
+  {"shrinkVniRangeUnderLeakedVni", "vni-in-use", "leaked-vni-out-of-range", v1beta1.Update, false, firstAllocPatch},
+  {"growVniRangeWithoutAlloc", "vni-in-use", "vni-range-grown", v1beta1.Update, false, firstAllocPatch},
 }
 
 var (
-  expectedPatch = []admit.Patch {
-    admit.Patch {Path: "/hostDevices"},
+  //VNI:705 is used by a TenantNetwork, VNI:706 is leaked
+  vniInUseAlloc = createVniAlloc(705, 706)
+  vniUsingNets = []danmtypes.DanmNet {
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vxlan-705", Namespace: "default"},
+      TypeMeta: meta_v1.TypeMeta {Kind: TnetType},
+      Spec: danmtypes.DanmNetSpec{NetworkID: "vxlan-705", NetworkType: "ipvlan", Options: danmtypes.DanmNetOption{Device: "ens4", Vxlan: 705}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vlan-706", Namespace: "default"},
+      TypeMeta: meta_v1.TypeMeta {Kind: TnetType},
+      Spec: danmtypes.DanmNetSpec{NetworkID: "vlan-706", NetworkType: "ipvlan", Options: danmtypes.DanmNetOption{Device: "ens4", Vlan: 706}},
+    },
+  }
+)
+
+var (
+  firstAllocPatch = []admit.Patch {
+    admit.Patch {Path: "/hostDevices/0/alloc"},
+  }
+  secondAllocPatch = []admit.Patch {
+    admit.Patch {Path: "/hostDevices/1/alloc"},
+  }
+  supernetAllocPatch = []admit.Patch {
+    admit.Patch {Path: "/supernets/0/alloc"},
   }
 )
 
 func TestValidateTenantConfig(t *testing.T) {
-  validator := admit.Validator{}
+  validator := admit.Validator{Client: stubs.NewClientSetStub(utils.TestArtifacts{TestTconfs: existingSelectionConfs, TestNets: vniUsingNets})}
   for _, tc := range validateTconfTcs {
     t.Run(tc.tcName, func(t *testing.T) {
       writerStub := httpstub.NewWriterStub()
@@ -196,6 +405,58 @@ func TestValidateTenantConfig(t *testing.T) {
   }
 }
 
+var carryOverTcs = []struct {
+  tcName string
+  newTconfName string
+  userName string
+  expectedAlloc string
+}{
+  {"allocationOfGrownRange", "vni-range-grown", "", vniInUseAlloc},
+  {"usedVniFreedByUser", "used-vni-freed", "", vniInUseAlloc},
+  {"usedVniFreedByWebhook", "used-vni-freed", admit.DefaultWebhookUser, ""},
+}
+
+func TestVniAllocationCarryOver(t *testing.T) {
+  validator := admit.Validator{Client: stubs.NewClientSetStub(utils.TestArtifacts{TestNets: vniUsingNets})}
+  oldTconf, _ := getTestConf("vni-in-use", validateConfs)
+  for _, tc := range carryOverTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      newTconf, _ := getTestConf(tc.newTconfName, validateConfs)
+      request, err := utils.CreateHttpRequestFromUser(oldTconf, newTconf, admissionv1.Update, tc.userName)
+      if err != nil {
+        t.Errorf("Could not create test HTTP Request object, because:%v", err)
+        return
+      }
+      writerStub := httpstub.NewWriterStub()
+      validator.ValidateTenantConfig(writerStub, request)
+      response, err := writerStub.GetAdmissionResponse()
+      if err != nil || !response.Allowed {
+        t.Errorf("TenantConfig update was not admitted, error:%v", err)
+        return
+      }
+      var patches []admit.Patch
+      json.Unmarshal(response.Patch, &patches)
+      if tc.expectedAlloc == "" {
+        if len(patches) != 0 {
+          t.Errorf("Allocation of the interface profile should not have been changed, received patches:%+v", patches)
+        }
+        return
+      }
+      if len(patches) != 1 || patches[0].Value != tc.expectedAlloc {
+        t.Errorf("Allocation of the interface profile was not carried over, received patches:%+v", patches)
+      }
+    })
+  }
+}
+
+func createVniAlloc(vnis ...int) string {
+  allocs := bitarray.NewBitArrayFromBase64(utils.AllocFor5k)
+  for _, vni := range vnis {
+    allocs.Set(uint32(vni))
+  }
+  return allocs.Encode()
+}
+
 func getTestConf(name string, confs []danmtypes.TenantConfig) ([]byte, bool) {
   tconf := utils.GetTconf(name, confs)
   if tconf == nil {
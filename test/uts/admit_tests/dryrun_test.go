@@ -0,0 +1,74 @@
+package admit_tests
+
+import (
+  "testing"
+  "github.com/nokia/danm/pkg/admit"
+)
+
+const (
+  dryRunTconf = `{"apiVersion":"danm.k8s.io/v1","kind":"TenantConfig","metadata":{"name":"tconf"},"hostDevices":[{"name":"ens4","vniType":"vxlan","vniRange":"700-710"}]}`
+  dryRunTnet = `{"apiVersion":"danm.k8s.io/v1","kind":"TenantNetwork","metadata":{"name":"tnet","namespace":"tenant"},"spec":{"NetworkID":"tnet","Options":{"cidr":"10.1.0.0/24"}}}`
+  dryRunCnet = `{"apiVersion":"danm.k8s.io/v1","kind":"ClusterNetwork","metadata":{"name":"cnet"},"spec":{"NetworkID":"cnet","NetworkType":"ipvlan","Options":{"host_device":"ens3","vlan":100,"cidr":"10.0.0.0/24"}}}`
+  dryRunOverlappingDnet = `{"apiVersion":"danm.k8s.io/v1","kind":"DanmNet","metadata":{"name":"dnet","namespace":"default"},"spec":{"NetworkID":"dnet","NetworkType":"ipvlan","Options":{"host_device":"ens3","vlan":100,"cidr":"10.0.0.128/25"}}}`
+  dryRunInvalidDnet = `{"apiVersion":"danm.k8s.io/v1","kind":"DanmNet","metadata":{"name":"dnet","namespace":"default"},"spec":{"NetworkID":"dnet","Options":{"cidr":"10.0.0.0/24","routes":{"10.1.0.0/24":"10.2.0.1"}}}}`
+  dryRunUnknownKind = `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"pod"}}`
+)
+
+var dryRunTcs = []struct {
+  tcName string
+  objects []string
+  expectedResults []bool
+  expectedPatches [][]admit.Patch
+}{
+  {"TenantNetworkWithTenantConfig", []string{dryRunTnet, dryRunTconf}, []bool{true, true},
+    [][]admit.Patch{tnetDryRunPatches, []admit.Patch{admit.Patch{Path: "/hostDevices/0/alloc"}}}},
+  {"TenantNetworkWithoutTenantConfig", []string{dryRunTnet}, []bool{false}, nil},
+  {"OverlapWithinTheRun", []string{dryRunCnet, dryRunOverlappingDnet}, []bool{true, false},
+    [][]admit.Patch{[]admit.Patch{admit.Patch{Path: "/spec/Options/alloc"}, admit.Patch{Path: "/spec/Options/allocation_pool"}}, nil}},
+  {"InvalidNetwork", []string{dryRunInvalidDnet}, []bool{false}, nil},
+  {"UnknownKind", []string{dryRunUnknownKind}, []bool{false}, nil},
+  {"MalformedObject", []string{"{"}, []bool{false}, nil},
+}
+
+var tnetDryRunPatches = []admit.Patch {
+  admit.Patch{Path: "/metadata/annotations"},
+  admit.Patch{Path: "/spec/NetworkType"},
+  admit.Patch{Path: "/spec/Options/alloc"},
+  admit.Patch{Path: "/spec/Options/allocation_pool"},
+  admit.Patch{Path: "/spec/Options/host_device"},
+  admit.Patch{Path: "/spec/Options/vxlan"},
+}
+
+func TestDryRun(t *testing.T) {
+  for _, tc := range dryRunTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      var rawObjects [][]byte
+      for _, object := range tc.objects {
+        rawObjects = append(rawObjects, []byte(object))
+      }
+      results := admit.DryRun(rawObjects)
+      if len(results) != len(tc.expectedResults) {
+        t.Errorf("Received %d results, but we expected:%d", len(results), len(tc.expectedResults))
+        return
+      }
+      for index, result := range results {
+        if (result.Err == nil) != tc.expectedResults[index] {
+          t.Errorf("Object no.%d was admitted:%t, but we expected:%t. Received error:%v", index, result.Err == nil, tc.expectedResults[index], result.Err)
+          return
+        }
+        if tc.expectedPatches == nil {
+          continue
+        }
+        if len(result.Patches) != len(tc.expectedPatches[index]) {
+          t.Errorf("Received patches:%v for object no.%d, but we expected:%v", result.Patches, index, tc.expectedPatches[index])
+          return
+        }
+        for patchIndex, expPatch := range tc.expectedPatches[index] {
+          if result.Patches[patchIndex].Path != expPatch.Path {
+            t.Errorf("Received patch:%v does not match the expected:%v", result.Patches[patchIndex], expPatch)
+          }
+        }
+      }
+    })
+  }
+}
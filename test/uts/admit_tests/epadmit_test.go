@@ -0,0 +1,176 @@
+package admit_tests
+
+import (
+  "testing"
+  "encoding/json"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/admit"
+  "github.com/nokia/danm/pkg/danmep"
+  stubs "github.com/nokia/danm/test/stubs/danm"
+  httpstub "github.com/nokia/danm/test/stubs/http"
+  "github.com/nokia/danm/test/utils"
+  admissionv1 "k8s.io/api/admission/v1"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+  otherUser = "kubernetes-admin"
+)
+
+var (
+  epNets = []danmtypes.DanmNet {
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "ep-net"},
+      TypeMeta: meta_v1.TypeMeta {Kind: "DanmNet"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "epnet", Options: danmtypes.DanmNetOption{Device: "ens9", Cidr: "10.120.0.0/24", Alloc: createAllocWithIps("10.120.0.0/24", nil, "10.120.0.5")}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "ep-broken"},
+      TypeMeta: meta_v1.TypeMeta {Kind: "DanmNet"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "broken-error", Options: danmtypes.DanmNetOption{Device: "ens9", Cidr: "10.121.0.0/24", Alloc: createAllocWithIps("10.121.0.0/24", nil, "10.121.0.5")}},
+    },
+  }
+  epFreedIp = []utils.ReservedIpsList{utils.ReservedIpsList{NetworkId: "epnet", Reservations: []utils.Reservation{utils.Reservation{Ip: "10.120.0.5/24", Set: false}}}}
+)
+
+var validateEpTcs = []struct {
+  tcName string
+  oldEp *danmtypes.DanmEp
+  newEp *danmtypes.DanmEp
+  opType admissionv1.Operation
+  userName string
+  isErrorExpected bool
+}{
+  {"CreateByCni", nil, createTestEp("ep-net", "ipvlan", "10.120.0.5/24"), admissionv1.Create, admit.DefaultCniUser, false},
+  {"CreateByOtherUser", nil, createTestEp("ep-net", "ipvlan", "10.120.0.5/24"), admissionv1.Create, otherUser, true},
+  {"CreateWithNonExistingNetwork", nil, createTestEp("ep-nonexisting", "ipvlan", "10.120.0.5/24"), admissionv1.Create, admit.DefaultCniUser, true},
+  {"CreateWithMismatchingNetworkType", nil, createTestEp("ep-net", "macvlan", "10.120.0.5/24"), admissionv1.Create, admit.DefaultCniUser, true},
+  {"CreateWithUnreservedIp", nil, createTestEp("ep-net", "ipvlan", "10.120.0.6/24"), admissionv1.Create, admit.DefaultCniUser, true},
+  {"CreateWithIpOutsideCidr", nil, createTestEp("ep-net", "ipvlan", "10.200.0.6/24"), admissionv1.Create, admit.DefaultCniUser, false},
+  {"CreateWithoutPod", nil, withoutPod(createTestEp("ep-net", "ipvlan", "10.120.0.5/24")), admissionv1.Create, admit.DefaultCniUser, true},
+  {"UpdateMetadataByOtherUser", createTestEp("ep-net", "ipvlan", "10.120.0.5/24"), withLabels(createTestEp("ep-net", "ipvlan", "10.120.0.5/24")), admissionv1.Update, otherUser, false},
+  {"RemoveFinalizerByWebhook", deleted(createTestEp("ep-net", "ipvlan", "10.120.0.5/24")), withoutFinalizer(deleted(createTestEp("ep-net", "ipvlan", "10.120.0.5/24"))), admissionv1.Update, admit.DefaultWebhookUser, false},
+  {"UpdateSpecByOtherUser", createTestEp("ep-net", "ipvlan", "10.120.0.5/24"), createTestEp("ep-net", "ipvlan", "10.120.0.7/24"), admissionv1.Update, otherUser, true},
+  {"UpdateSpecByCni", createTestEp("ep-net", "ipvlan", ""), createTestEp("ep-net", "ipvlan", "10.120.0.5/24"), admissionv1.Update, admit.DefaultCniUser, false},
+}
+
+func TestValidateDanmEp(t *testing.T) {
+  for _, tc := range validateEpTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      writerStub := httpstub.NewWriterStub()
+      request, err := utils.CreateHttpRequestFromUser(marshalEp(tc.oldEp), marshalEp(tc.newEp), tc.opType, tc.userName)
+      if err != nil {
+        t.Errorf("Could not create test HTTP Request object, because:%v", err)
+        return
+      }
+      testClient := stubs.NewClientSetStub(utils.TestArtifacts{TestNets: append([]danmtypes.DanmNet{}, epNets...)})
+      validator := admit.Validator{Client: testClient}
+      validator.ValidateDanmEp(writerStub, request)
+      err = utils.ValidateHttpResponse(writerStub, tc.isErrorExpected, nil)
+      if err != nil {
+        t.Errorf("Received HTTP Response did not match expectation, because:%v", err)
+      }
+    })
+  }
+}
+
+var freeDeletedEpTcs = []struct {
+  tcName string
+  ep *danmtypes.DanmEp
+  isErrorExpected bool
+  timesNetUpdateShouldBeCalled int
+  timesEpUpdateShouldBeCalled int
+}{
+  {"NotDeleted", createTestEp("ep-net", "ipvlan", "10.120.0.5/24"), false, 0, 0},
+  {"DeletedByCni", withoutFinalizer(deleted(createTestEp("ep-net", "ipvlan", "10.120.0.5/24"))), false, 0, 0},
+  {"DeletedByOtherUser", deleted(createTestEp("ep-net", "ipvlan", "10.120.0.5/24")), false, 1, 2},
+  {"DeletedWithIpsAlreadyFreed", withIpsFreed(deleted(createTestEp("ep-net", "ipvlan", "10.120.0.5/24"))), false, 0, 1},
+  {"DeletedWithoutDanmIp", deleted(createTestEp("ep-net", "ipvlan", "10.200.0.5/24")), false, 0, 2},
+  {"DeletedWithNonExistingNetwork", deleted(createTestEp("ep-nonexisting", "ipvlan", "10.120.0.5/24")), false, 0, 2},
+  {"DeletedWhenFreeingFails", deleted(createTestEp("ep-broken", "ipvlan", "10.121.0.5/24")), true, 1, 0},
+}
+
+//Every test case uses a fresh Validator: the intent to free the IPs is only recorded on the DanmEp itself, so it survives the restart of the Webhook
+func TestFreeDeletedDanmEp(t *testing.T) {
+  for _, tc := range freeDeletedEpTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      testClient := stubs.NewClientSetStub(utils.TestArtifacts{TestNets: append([]danmtypes.DanmNet{}, epNets...), ReservedIps: epFreedIp})
+      validator := admit.Validator{Client: testClient}
+      err := validator.FreeDeletedDanmEp(tc.ep)
+      if (err != nil) != tc.isErrorExpected {
+        t.Errorf("Received error:%v while freeing the deleted DanmEp does not match with expectation:%t", err, tc.isErrorExpected)
+        return
+      }
+      var timesNetUpdateWasCalled int
+      if testClient.DanmClient.NetClient != nil {
+        timesNetUpdateWasCalled = testClient.DanmClient.NetClient.TimesUpdateWasCalled
+      }
+      if timesNetUpdateWasCalled != tc.timesNetUpdateShouldBeCalled {
+        t.Errorf("Network should have been updated:%d times, but it happened:%d times instead", tc.timesNetUpdateShouldBeCalled, timesNetUpdateWasCalled)
+      }
+      var updatedEps []danmtypes.DanmEp
+      if testClient.DanmClient.EpClient != nil {
+        updatedEps = testClient.DanmClient.EpClient.UpdatedEps
+      }
+      if len(updatedEps) != tc.timesEpUpdateShouldBeCalled {
+        t.Errorf("DanmEp should have been updated:%d times, but it happened:%d times instead", tc.timesEpUpdateShouldBeCalled, len(updatedEps))
+        return
+      }
+      if len(updatedEps) == 0 {
+        return
+      }
+      lastEp := updatedEps[len(updatedEps)-1]
+      if danmep.HasEpFinalizer(&lastEp) || lastEp.ObjectMeta.Annotations[danmep.IpsFreedAnnotation] != "true" {
+        t.Errorf("DanmEp should have been let go with its IPs recorded as freed, but its finalizers are:%v, and its annotations are:%v", lastEp.ObjectMeta.Finalizers, lastEp.ObjectMeta.Annotations)
+      }
+    })
+  }
+}
+
+func createTestEp(netName, neType, address string) *danmtypes.DanmEp {
+  return &danmtypes.DanmEp {
+    TypeMeta: meta_v1.TypeMeta {Kind: "DanmEp"},
+    ObjectMeta: meta_v1.ObjectMeta {Name: "ep", Namespace: "default", Finalizers: []string{danmep.EpFinalizer}},
+    Spec: danmtypes.DanmEpSpec {
+      NetworkName: netName,
+      NetworkType: neType,
+      ApiType: "DanmNet",
+      Pod: "pod",
+      Iface: danmtypes.DanmEpIface{Name: "eth1", Address: address},
+    },
+  }
+}
+
+func withoutPod(ep *danmtypes.DanmEp) *danmtypes.DanmEp {
+  ep.Spec.Pod = ""
+  return ep
+}
+
+func deleted(ep *danmtypes.DanmEp) *danmtypes.DanmEp {
+  deletionTime := meta_v1.Now()
+  ep.ObjectMeta.DeletionTimestamp = &deletionTime
+  return ep
+}
+
+func withoutFinalizer(ep *danmtypes.DanmEp) *danmtypes.DanmEp {
+  ep.ObjectMeta.Finalizers = nil
+  return ep
+}
+
+func withIpsFreed(ep *danmtypes.DanmEp) *danmtypes.DanmEp {
+  ep.ObjectMeta.Annotations = map[string]string{danmep.IpsFreedAnnotation: "true"}
+  return ep
+}
+
+func withLabels(ep *danmtypes.DanmEp) *danmtypes.DanmEp {
+  ep.ObjectMeta.Labels = map[string]string{"app": "test"}
+  return ep
+}
+
+func marshalEp(ep *danmtypes.DanmEp) []byte {
+  if ep == nil {
+    return nil
+  }
+  epBinary, _ := json.Marshal(ep)
+  return epBinary
+}
@@ -0,0 +1,91 @@
+package admit_tests
+
+import (
+  "reflect"
+  "testing"
+  "encoding/json"
+  jsonpatch "github.com/evanphx/json-patch"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/admit"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+  diffBaseNet = danmtypes.DanmNet {
+    TypeMeta: meta_v1.TypeMeta {Kind: "ClusterNetwork"},
+    ObjectMeta: meta_v1.ObjectMeta {Name: "diff"},
+    Spec: danmtypes.DanmNetSpec{NetworkID: "diff", Options: danmtypes.DanmNetOption{Cidr: "10.0.0.0/24", Routes: map[string]string{"10.1.0.0/24": "10.0.0.1"}}},
+  }
+)
+
+var objectDiffTcs = []struct {
+  tcName string
+  rawObject string
+  mutate func(*danmtypes.DanmNet)
+  expectedPatches []admit.Patch
+}{
+  {"NoChange", "", func(dnet *danmtypes.DanmNet) {}, nil},
+  {"PreviouslyUnpatchedFields", "", func(dnet *danmtypes.DanmNet) {dnet.Spec.Options.Prefix = "eth"; dnet.Spec.Options.RTables = 10},
+    []admit.Patch{admit.Patch{Op: "add", Path: "/spec/Options/container_prefix"}, admit.Patch{Op: "add", Path: "/spec/Options/rt_tables"}}},
+  {"RouteKeysAreEscaped", "", func(dnet *danmtypes.DanmNet) {dnet.Spec.Options.Routes["10.2.0.0/24"] = "10.0.0.2"},
+    []admit.Patch{admit.Patch{Op: "add", Path: "/spec/Options/routes/10.2.0.0~124"}}},
+  {"ChangedRoute", "", func(dnet *danmtypes.DanmNet) {dnet.Spec.Options.Routes["10.1.0.0/24"] = "10.0.0.254"},
+    []admit.Patch{admit.Patch{Op: "replace", Path: "/spec/Options/routes/10.1.0.0~124"}}},
+  {"RemovedRoutes", "", func(dnet *danmtypes.DanmNet) {dnet.Spec.Options.Routes = nil},
+    []admit.Patch{admit.Patch{Op: "remove", Path: "/spec/Options/routes"}}},
+  {"ValueWithQuotes", "", func(dnet *danmtypes.DanmNet) {dnet.Spec.Options.Device = `ens"3`},
+    []admit.Patch{admit.Patch{Op: "add", Path: "/spec/Options/host_device"}}},
+  {"ChangedSlice", "", func(dnet *danmtypes.DanmNet) {dnet.Spec.AllowedTenants = []string{"tenant1","tenant2"}},
+    []admit.Patch{admit.Patch{Op: "add", Path: "/spec/AllowedTenants"}}},
+  {"MissingParentInRawObject", `{"kind":"ClusterNetwork","metadata":{"name":"diff"},"spec":{"NetworkID":"diff"}}`,
+    func(dnet *danmtypes.DanmNet) {dnet.Spec.Options.Alloc = "gAAAAAE="},
+    []admit.Patch{admit.Patch{Op: "add", Path: "/spec/Options"}}},
+}
+
+func TestCreatePatchListFromObjectDiff(t *testing.T) {
+  for _, tc := range objectDiffTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      origNet := diffBaseNet.DeepCopy()
+      changedNet := diffBaseNet.DeepCopy()
+      tc.mutate(changedNet)
+      rawObject := []byte(tc.rawObject)
+      if tc.rawObject == "" {
+        rawObject, _ = json.Marshal(origNet)
+      }
+      patchList, err := admit.CreatePatchListFromObjectDiff(rawObject, origNet, changedNet)
+      if err != nil {
+        t.Errorf("Patch list could not be created, because:%v", err)
+        return
+      }
+      if len(patchList) != len(tc.expectedPatches) {
+        t.Errorf("Received %d patches:%v, but we expected:%v", len(patchList), patchList, tc.expectedPatches)
+        return
+      }
+      for index, expPatch := range tc.expectedPatches {
+        if patchList[index].Op != expPatch.Op || patchList[index].Path != expPatch.Path {
+          t.Errorf("Received patch:%v does not match the expected:%v", patchList[index], expPatch)
+          return
+        }
+      }
+      if len(patchList) == 0 {
+        return
+      }
+      rawPatch, _ := json.Marshal(patchList)
+      patch, err := jsonpatch.DecodePatch(rawPatch)
+      if err != nil {
+        t.Errorf("Patch list is not a valid RFC 6902 document, because:%v", err)
+        return
+      }
+      patchedObject, err := patch.Apply(rawObject)
+      if err != nil {
+        t.Errorf("Patch list could not be applied to the reviewed object, because:%v", err)
+        return
+      }
+      patchedNet := danmtypes.DanmNet{}
+      json.Unmarshal(patchedObject, &patchedNet)
+      if !reflect.DeepEqual(patchedNet.Spec, changedNet.Spec) {
+        t.Errorf("Patched object:%v does not match the mutated object:%v", patchedNet.Spec, changedNet.Spec)
+      }
+    })
+  }
+}
@@ -0,0 +1,45 @@
+package admit_tests
+
+import (
+  "sync"
+  "testing"
+  "time"
+  "github.com/nokia/danm/pkg/admit"
+  k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRunAsLeader(t *testing.T) {
+  kubeClient := k8sfake.NewSimpleClientset()
+  stopCh := make(chan struct{})
+  defer close(stopCh)
+  var lock sync.Mutex
+  var leaders []string
+  for _, identity := range []string{"webhook-1", "webhook-2"} {
+    validator := admit.Validator{KubeClient: kubeClient}
+    leaderIdentity := identity
+    err := validator.RunAsLeader(admit.DefaultLeaseNamespace, leaderIdentity, func(leaderStopCh <-chan struct{}) {
+      lock.Lock()
+      leaders = append(leaders, leaderIdentity)
+      lock.Unlock()
+      <-leaderStopCh
+    }, stopCh)
+    if err != nil {
+      t.Errorf("Leader election could not be started, because:%v", err)
+      return
+    }
+  }
+  time.Sleep(admit.LeaseRetryPeriod + time.Second)
+  lock.Lock()
+  defer lock.Unlock()
+  if len(leaders) != 1 {
+    t.Errorf("Exactly one Webhook instance should run the background controllers, but they are run by:%v", leaders)
+  }
+}
+
+func TestRunAsLeaderWithoutK8sClient(t *testing.T) {
+  validator := admit.Validator{}
+  err := validator.RunAsLeader(admit.DefaultLeaseNamespace, "webhook-1", func(leaderStopCh <-chan struct{}) {}, make(chan struct{}))
+  if err == nil {
+    t.Errorf("Leader election without K8s client should expect error")
+  }
+}
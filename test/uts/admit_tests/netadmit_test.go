@@ -1,17 +1,24 @@
 package admit_tests
 
 import (
+  "net"
   "strconv"
   "strings"
   "testing"
   "encoding/json"
   danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
   "github.com/nokia/danm/pkg/admit"
+  "github.com/nokia/danm/pkg/bitarray"
+  "github.com/nokia/danm/pkg/ipam"
   stubs "github.com/nokia/danm/test/stubs/danm"
   httpstub "github.com/nokia/danm/test/stubs/http"
   "github.com/nokia/danm/test/utils"
+  admissionv1 "k8s.io/api/admission/v1"
   "k8s.io/api/admission/v1beta1"
+  corev1 "k8s.io/api/core/v1"
   meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+  "k8s.io/apimachinery/pkg/runtime"
+  k8sfake "k8s.io/client-go/kubernetes/fake"
 )
 
 const (
@@ -108,6 +115,9 @@ var validateNetworkTcs = []struct {
   {"NoFreeVnisForTnet", "", "tnet-device", TnetType, v1beta1.Create, oneDev, nil, true, nil, 0},
   {"DeviceAndVlanTnetSuccess", "", "tnet-ens3", TnetType, v1beta1.Create, twoDevs, nil, false, allocAndVlan, 1},
   {"DeviceAndVxlanTnetSuccess", "", "tnet-ens4", TnetType, v1beta1.Create, twoDevs, nil, false, allocAndVxlan, 1},
+  {"OwnerTconfDuringCreateTNet", "", "tnet-with-owner", TnetType, v1beta1.Create, twoDevs, nil, true, nil, 0},
+  {"OwnerTconfChangedTNet", "tnet-with-owner", "tnet-other-owner", TnetType, v1beta1.Update, twoDevs, nil, true, nil, 0},
+  {"LongNidTnetReleasesVni", "", "tnet-long-nid", TnetType, v1beta1.Create, twoDevs, nil, true, nil, 2},
   {"DevicePoolAndVlanTnetSuccess", "", "tnet-ens1f0", TnetType, v1beta1.Create, twoDevPools, nil, false, allocAndVlan, 1},
   {"DevicePoolAndVxlanTnetSuccess", "", "tnet-ens1f1", TnetType, v1beta1.Create, twoDevPools, nil, false, allocAndVxlan, 1},
   {"RandomDeviceAndVxlanTnetSuccess", "", "tnet-random", TnetType, v1beta1.Create, randomDev, nil, false, allocAndVxlanAndDevice, 1},
@@ -150,6 +160,75 @@ var validateNetworkTcs = []struct {
   {"Pool6CidrBiggerThanNet6", "", "pool6-cidr-outside-net6", DnetType, "", nil, nil, true, nil, 0},
   {"InvalidPool6StartAddress", "", "invalid-pool6-start", DnetType, "", nil, nil, true, nil, 0},
   {"Pool6StartAddressMatchesEnd", "", "pool6-end-equals-start", DnetType, "", nil, nil, true, nil, 0},
+  {"VrfWithoutRtTablesDNet", "", "vrf-without-rtables", DnetType, "", nil, nil, true, nil, 0},
+  {"VrfWithoutRtTablesTNet", "", "vrf-without-rtables", TnetType, "", nil, nil, true, nil, 0},
+  {"VrfWithoutRtTablesCNet", "", "vrf-without-rtables", CnetType, "", nil, nil, true, nil, 0},
+  {"VrfWithReservedTable", "", "vrf-main-table", DnetType, "", nil, nil, true, nil, 0},
+  {"TooLongVrfName", "", "long-vrf", CnetType, "", nil, nil, true, nil, 0},
+  {"VrfCreateSuccess", "", "vrf-l2", CnetType, v1beta1.Create, nil, nil, false, nil, 0},
+  {"InvalidIpv6Mode", "", "invalid-ipv6-mode", DnetType, "", nil, nil, true, nil, 0},
+  {"SlaacCreateSuccess", "", "slaac-l2", CnetType, v1beta1.Create, nil, nil, false, nil, 0},
+  {"InvalidVxlanMode", "", "invalid-vxlan-mode", DnetType, "", nil, nil, true, nil, 0},
+  {"VxlanModeWithoutVxlan", "", "vxlan-mode-without-vxlan", CnetType, v1beta1.Create, nil, nil, true, nil, 0},
+  {"UnicastVxlanCreateSuccess", "", "vxlan-unicast", CnetType, v1beta1.Create, nil, nil, false, nil, 0},
+  {"ChangeVxlanModeWithConnectedPods", "vxlan-multicast", "vxlan-unicast", CnetType, v1beta1.Update, nil, vxlanModeEps, true, nil, 0},
+  {"ChangeVxlanModeWithoutPods", "vxlan-multicast", "vxlan-unicast", CnetType, v1beta1.Update, nil, nil, false, nil, 0},
+  {"InvalidVxlanPort", "", "invalid-vxlan-port", DnetType, "", nil, nil, true, nil, 0},
+  {"InvalidVxlanTtl", "", "invalid-vxlan-ttl", DnetType, "", nil, nil, true, nil, 0},
+  {"InvalidVxlanTos", "", "invalid-vxlan-tos", CnetType, "", nil, nil, true, nil, 0},
+  {"InvalidVxlanSourceCidr", "", "invalid-vxlan-source", CnetType, "", nil, nil, true, nil, 0},
+  {"NonMulticastVxlanGroup", "", "unicast-vxlan-group", DnetType, "", nil, nil, true, nil, 0},
+  {"VxlanGroupInUnicastMode", "", "vxlan-group-in-unicast-mode", DnetType, "", nil, nil, true, nil, 0},
+  {"VxlanPortWithoutVxlan", "", "vxlan-port-without-vxlan", DnetType, v1beta1.Create, nil, nil, true, nil, 0},
+  {"TunedVxlanCreateSuccess", "", "vxlan-tuned", CnetType, v1beta1.Create, nil, nil, false, nil, 0},
+  {"TuneVxlanWithConnectedPods", "vxlan-multicast", "vxlan-tuned", CnetType, v1beta1.Update, nil, vxlanModeEps, true, nil, 0},
+  {"TuneVxlanWithoutPods", "vxlan-multicast", "vxlan-tuned", CnetType, v1beta1.Update, nil, nil, false, nil, 0},
+  {"OverlappingCidrInSameVlan", "", "overlap-v4", DnetType, v1beta1.Create, nil, nil, true, nil, 0},
+  {"OverlappingNet6InSameVlan", "", "overlap-v6", DnetType, v1beta1.Create, nil, nil, true, nil, 0},
+  {"OverlappingCidrInOtherVlan", "", "overlap-other-vlan", DnetType, v1beta1.Create, nil, nil, false, allocOnly, 0},
+  {"OverlappingCidrWithAnnotation", "", "overlap-allowed", DnetType, v1beta1.Create, nil, nil, false, allocOnly, 0},
+  {"OverlappingCidrWithClusterNetwork", "", "overlap-cnet", CnetType, v1beta1.Create, nil, nil, true, nil, 0},
+  {"OverlappingCidrWithTenantNetwork", "", "overlap-tnet", CnetType, v1beta1.Create, nil, nil, true, nil, 0},
+  {"VlanCollisionDNet", "", "vlan-collision", DnetType, v1beta1.Create, nil, nil, true, nil, 0},
+  {"VlanSharedWithSameNidDNet", "", "vlan-same-nid", DnetType, v1beta1.Create, nil, nil, false, nil, 0},
+  {"VxlanCollisionCNet", "", "vxlan-collision", CnetType, v1beta1.Create, nil, nil, true, nil, 0},
+  {"VxlanCollisionOnOtherDeviceCNet", "", "vxlan-collision-other-dev", CnetType, v1beta1.Create, nil, nil, true, nil, 0},
+  {"VxlanWithOtherPortOnOtherDeviceCNet", "", "vxlan-other-port-other-dev", CnetType, v1beta1.Create, nil, nil, false, nil, 0},
+  {"VniUsedOnOtherDeviceLeftForTnet", "", "tnet-ens9", TnetType, v1beta1.Create, usedVniOtherDev, nil, true, nil, 0},
+  {"VniUsedWithOtherPortReservedForTnet", "", "tnet-ens9-other-port", TnetType, v1beta1.Create, usedVniOtherDev, nil, false, onlyVxlan, 1},
+  {"OnlyUsedVnisLeftForTnet", "", "tnet-ens7", TnetType, v1beta1.Create, usedVniDev, nil, true, nil, 0},
+  {"UsedVniSkippedForTnet", "", "tnet-ens7", TnetType, v1beta1.Create, partlyUsedVniDev, nil, false, onlyVxlan, 1},
+  {"OverlappingCidrWithItself", "", "existing-cnet", CnetType, v1beta1.Update, nil, nil, false, allocOnly, 0},
+  {"GrowCidrDNet", "resize-old", "resize-grow", DnetType, v1beta1.Update, nil, nil, false, onlyAlloc, 0},
+  {"GrowCidrCNet", "resize-old", "resize-grow", CnetType, v1beta1.Update, nil, resizeEps, false, onlyAlloc, 0},
+  {"ShrinkCidrCNet", "resize-old", "resize-shrink", CnetType, v1beta1.Update, nil, resizeEps, false, onlyAlloc, 0},
+  {"ShrinkCidrWithAllocatedIpOutsideCNet", "resize-old", "resize-shrink-outside", CnetType, v1beta1.Update, nil, resizeEps, true, nil, 0},
+  {"ShrinkCidrWithEpOutsideCNet", "resize-old", "resize-shrink", CnetType, v1beta1.Update, nil, resizeOutsideEps, true, nil, 0},
+  {"ShrinkPoolCNet", "resize-old", "resize-pool-shrink", CnetType, v1beta1.Update, nil, resizeEps, false, nil, 0},
+  {"ShrinkPoolWithAllocatedIpOutsideCNet", "resize-old", "resize-pool-shrink-outside", CnetType, v1beta1.Update, nil, resizeEps, true, nil, 0},
+  {"RemoveCidrWithAllocatedIpsCNet", "resize-old", "resize-remove-cidr", CnetType, v1beta1.Update, nil, resizeEps, true, nil, 0},
+}
+
+var ipamUpdateTcs = []struct {
+  tcName string
+  oldNetName string
+  newNetName string
+  neType string
+  userName string
+  tconf []danmtypes.TenantConfig
+  eps []danmtypes.DanmEp
+  isErrorExpected bool
+}{
+  {"IpReservedByCniCNet", "resize-old", "resize-ip-reserved", CnetType, admit.DefaultCniUser, nil, resizeOutsideEps, false},
+  {"IpReservedByCniTNet", "tnet-with-owner", "tnet-with-owner-ip-reserved", TnetType, admit.DefaultCniUser, nil, nil, false},
+  {"IpReservedByNetwatcherTNet", "tnet-with-owner", "tnet-with-owner-ip-reserved", TnetType, admit.DefaultNetwatcherUser, nil, nil, false},
+  {"IpFreedByWebhookTNet", "tnet-with-owner-ip-reserved", "tnet-with-owner", TnetType, admit.DefaultWebhookUser, nil, nil, false},
+  {"IpReservedWithOwnerChangedByCniTNet", "tnet-with-owner", "tnet-other-owner-ip-reserved", TnetType, admit.DefaultCniUser, twoDevs, nil, true},
+  {"IpReservedByOtherUserIsValidatedTNet", "tnet-with-owner", "tnet-with-owner-ip-reserved", TnetType, "system:serviceaccount:default:intruder", nil, nil, true},
+  {"IpReservedByOtherUserCNet", "resize-old", "resize-ip-reserved", CnetType, "system:serviceaccount:default:intruder", nil, resizeEps, false},
+  {"AllocWithWrongSizeByOtherUserCNet", "resize-old", "resize-wrong-alloc", CnetType, "system:serviceaccount:default:intruder", nil, resizeEps, true},
+  {"Alloc6WithWrongSizeByOtherUserCNet", "ipam-v6-old", "ipam-v6-wrong-alloc6", CnetType, "system:serviceaccount:default:intruder", nil, nil, true},
+  {"Alloc6WithRightSizeByOtherUserCNet", "ipam-v6-old", "ipam-v6-ip-reserved", CnetType, "system:serviceaccount:default:intruder", nil, nil, false},
 }
 
 var (
@@ -259,7 +338,7 @@ var (
     },
     danmtypes.DanmNet {
       ObjectMeta: meta_v1.ObjectMeta {Name: "no-netype-update"},
-      Spec: danmtypes.DanmNetSpec{NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Alloc: "gAAAAAE=", Pool: danmtypes.IpPool{Start: "192.168.1.65",End: "192.168.1.126"}, Cidr: "192.168.1.64/26", Routes: map[string]string{"10.20.0.0/24": "192.168.1.64"}}},
+      Spec: danmtypes.DanmNetSpec{NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Alloc: "gAAAAAAAAAE=", Pool: danmtypes.IpPool{Start: "192.168.1.65",End: "192.168.1.126"}, Cidr: "192.168.1.64/26", Routes: map[string]string{"10.20.0.0/24": "192.168.1.64"}}},
     },
     danmtypes.DanmNet {
       ObjectMeta: meta_v1.ObjectMeta {Name: "l2"},
@@ -273,6 +352,18 @@ var (
       ObjectMeta: meta_v1.ObjectMeta {Name: "tnet-ens3"},
       Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens3", Pool: danmtypes.IpPool{Start: "192.168.1.65",End: "192.168.1.126"}, Cidr: "192.168.1.64/26"}},
     },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "tnet-with-owner", Annotations: map[string]string{admit.TenantConfigAnnotation: "tconf"}},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens3", Cidr: "192.168.1.64/26"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "tnet-other-owner", Annotations: map[string]string{admit.TenantConfigAnnotation: "other"}},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens3", Cidr: "192.168.1.64/26"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "tnet-long-nid"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg-long", Options: danmtypes.DanmNetOption{Device: "ens3", Cidr: "192.168.1.64/26"}},
+    },
     danmtypes.DanmNet {
       ObjectMeta: meta_v1.ObjectMeta {Name: "tnet-ens4"},
       Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens4", Pool: danmtypes.IpPool{Start: "192.168.1.65",End: "192.168.1.126"}, Cidr: "192.168.1.64/26"}},
@@ -373,48 +464,273 @@ var (
       ObjectMeta: meta_v1.ObjectMeta {Name: "pool6-end-equals-start"},
       Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Net6: "2001:db8:85a3::8a2e:370:7334/108", Pool6: danmtypes.IpPoolV6{Cidr: "2001:db8:85a3::8a2e:370:7334/109", IpPool: danmtypes.IpPool{Start: "2001:db8:85a3::8a2e:370:7340", End: "2001:db8:85a3::8a2e:370:7340"}}}},
     },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vrf-without-rtables"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens3", Vrf: "blue"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vrf-main-table"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens3", Vrf: "blue", RTables: 254}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "long-vrf"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens3", Vrf: "averyveryverylongvrf", RTables: 10}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vrf-l2"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens3", Vrf: "blue", RTables: 10}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "invalid-ipv6-mode"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens3", Ipv6Mode: "stateless"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "slaac-l2"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens3", Ipv6Mode: "slaac"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "invalid-vxlan-mode"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "unicast", Options: danmtypes.DanmNetOption{Device: "ens3", Vxlan: 1777, VxlanMode: "broadcast"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vxlan-mode-without-vxlan"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "unicast", Options: danmtypes.DanmNetOption{Device: "ens3", VxlanMode: "unicast"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vxlan-multicast"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "unicast", Options: danmtypes.DanmNetOption{Device: "ens3", Vxlan: 1777}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vxlan-unicast"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "unicast", Options: danmtypes.DanmNetOption{Device: "ens3", Vxlan: 1777, VxlanMode: "unicast"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "invalid-vxlan-port"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "unicast", Options: danmtypes.DanmNetOption{Device: "ens3", Vxlan: 1777, VxlanPort: 65536}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "invalid-vxlan-ttl"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "unicast", Options: danmtypes.DanmNetOption{Device: "ens3", Vxlan: 1777, VxlanTtl: 256}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "invalid-vxlan-tos"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "unicast", Options: danmtypes.DanmNetOption{Device: "ens3", Vxlan: 1777, VxlanTos: -1}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "invalid-vxlan-source"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "unicast", Options: danmtypes.DanmNetOption{Device: "ens3", Vxlan: 1777, VxlanSourceCidr: "10.0.0.1"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "unicast-vxlan-group"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "unicast", Options: danmtypes.DanmNetOption{Device: "ens3", Vxlan: 1777, VxlanGroup: "10.0.0.1"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vxlan-group-in-unicast-mode"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "unicast", Options: danmtypes.DanmNetOption{Device: "ens3", Vxlan: 1777, VxlanMode: "unicast", VxlanGroup: "239.1.1.1"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vxlan-port-without-vxlan"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "unicast", Options: danmtypes.DanmNetOption{Device: "ens3", VxlanPort: 8472}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vxlan-tuned"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "unicast", Options: danmtypes.DanmNetOption{Device: "ens3", Vxlan: 1777, VxlanPort: 8472, VxlanTtl: 64, VxlanTos: 1,
+        VxlanSourceCidr: "192.168.1.0/24", VxlanLearning: &disabled, VxlanGroup: "239.1.1.1"}},
+    },
+    danmtypes.DanmNet {
+      TypeMeta: meta_v1.TypeMeta {Kind: "DanmNet"},
+      ObjectMeta: meta_v1.ObjectMeta {Name: "existing-dnet", Namespace: "default"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "existing", Options: danmtypes.DanmNetOption{Device: "ens5", Vlan: 100, Cidr: "10.100.0.0/24", Net6: "fd00:100::/64"}},
+    },
+    danmtypes.DanmNet {
+      TypeMeta: meta_v1.TypeMeta {Kind: "ClusterNetwork"},
+      ObjectMeta: meta_v1.ObjectMeta {Name: "existing-cnet"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "existing", Options: danmtypes.DanmNetOption{Device: "ens6", Cidr: "10.101.0.0/24"}},
+    },
+    danmtypes.DanmNet {
+      TypeMeta: meta_v1.TypeMeta {Kind: "TenantNetwork"},
+      ObjectMeta: meta_v1.ObjectMeta {Name: "existing-tnet", Namespace: "tenant"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "existing", Options: danmtypes.DanmNetOption{Device: "ens7", Vxlan: 700, Cidr: "10.102.0.0/24"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "overlap-v4", Namespace: "default"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "overlap", Options: danmtypes.DanmNetOption{Device: "ens5", Vlan: 100, Cidr: "10.100.0.128/25"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "overlap-v6", Namespace: "other"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "overlap", Options: danmtypes.DanmNetOption{Device: "ens5", Vlan: 100, Net6: "fd00:100::/48"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "overlap-other-vlan", Namespace: "default"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "overlap", Options: danmtypes.DanmNetOption{Device: "ens5", Vlan: 101, Cidr: "10.100.0.0/24"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "overlap-allowed", Namespace: "default", Annotations: map[string]string{"danm.k8s.io/allow-cidr-overlap": "true"}},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "existing", Options: danmtypes.DanmNetOption{Device: "ens5", Vlan: 100, Cidr: "10.100.0.0/24"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "overlap-cnet"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "macvlan", NetworkID: "overlap", Options: danmtypes.DanmNetOption{Device: "ens6", Cidr: "10.101.0.0/16"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vlan-collision", Namespace: "other"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "collision", Options: danmtypes.DanmNetOption{Device: "ens5", Vlan: 100}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vlan-same-nid", Namespace: "other"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "existing", Options: danmtypes.DanmNetOption{Device: "ens5", Vlan: 100}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vxlan-collision"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "collision", Options: danmtypes.DanmNetOption{Device: "ens7", Vxlan: 700}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vxlan-collision-other-dev"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "collision", Options: danmtypes.DanmNetOption{Device: "ens9", Vxlan: 700}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vxlan-other-port-other-dev"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "collision", Options: danmtypes.DanmNetOption{Device: "ens9", Vxlan: 700, VxlanPort: 8472}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "tnet-ens9", Namespace: "tenant"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "tnet9", Options: danmtypes.DanmNetOption{Device: "ens9"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "tnet-ens9-other-port", Namespace: "tenant"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "tnet9", Options: danmtypes.DanmNetOption{Device: "ens9", VxlanPort: 8472}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "tnet-ens7", Namespace: "tenant"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "tnet7", Options: danmtypes.DanmNetOption{Device: "ens7"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "overlap-tnet"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "overlap", Options: danmtypes.DanmNetOption{Device: "ens7", Vxlan: 700, Cidr: "10.102.0.64/26"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "resize-old"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "resize", Options: danmtypes.DanmNetOption{Device: "ens8", Cidr: "10.110.0.0/24", Routes: resizeRoutes, Alloc: resizeAlloc, Pool: danmtypes.IpPool{Start: "10.110.0.10", End: "10.110.0.100", LastIp: "10.110.0.50"}}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "resize-grow"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "resize", Options: danmtypes.DanmNetOption{Device: "ens8", Cidr: "10.110.0.0/23", Routes: resizeRoutes, Alloc: resizeAlloc, Pool: danmtypes.IpPool{Start: "10.110.0.10", End: "10.110.1.200", LastIp: "10.110.0.50"}}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "resize-shrink"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "resize", Options: danmtypes.DanmNetOption{Device: "ens8", Cidr: "10.110.0.0/25", Routes: resizeRoutes, Alloc: resizeAlloc, Pool: danmtypes.IpPool{Start: "10.110.0.10", End: "10.110.0.100", LastIp: "10.110.0.50"}}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "resize-shrink-outside"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "resize", Options: danmtypes.DanmNetOption{Device: "ens8", Cidr: "10.110.0.0/27", Routes: resizeRoutes, Alloc: resizeAlloc, Pool: danmtypes.IpPool{Start: "10.110.0.10", End: "10.110.0.30"}}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "resize-pool-shrink"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "resize", Options: danmtypes.DanmNetOption{Device: "ens8", Cidr: "10.110.0.0/24", Routes: resizeRoutes, Alloc: resizeAlloc, Pool: danmtypes.IpPool{Start: "10.110.0.10", End: "10.110.0.60", LastIp: "10.110.0.50"}}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "resize-pool-shrink-outside"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "resize", Options: danmtypes.DanmNetOption{Device: "ens8", Cidr: "10.110.0.0/24", Routes: resizeRoutes, Alloc: resizeAlloc, Pool: danmtypes.IpPool{Start: "10.110.0.10", End: "10.110.0.40"}}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "resize-remove-cidr"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "resize", Options: danmtypes.DanmNetOption{Device: "ens8", Alloc: resizeAlloc}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "resize-ip-reserved"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "resize", Options: danmtypes.DanmNetOption{Device: "ens8", Cidr: "10.110.0.0/24", Routes: resizeRoutes, Alloc: createAllocWithIps("10.110.0.0/24", resizeRoutes, "10.110.0.10", "10.110.0.50", "10.110.0.51"), Pool: danmtypes.IpPool{Start: "10.110.0.10", End: "10.110.0.100", LastIp: "10.110.0.51"}}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "resize-wrong-alloc"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "resize", Options: danmtypes.DanmNetOption{Device: "ens8", Cidr: "10.110.0.0/24", Routes: resizeRoutes, Alloc: createAllocWithIps("10.110.0.0/26", nil, "10.110.0.10"), Pool: danmtypes.IpPool{Start: "10.110.0.10", End: "10.110.0.100", LastIp: "10.110.0.50"}}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "ipam-v6-old"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "ipamv6", Options: danmtypes.DanmNetOption{Device: "ens9", Net6: "2a00:8a00:a000:1193::/64", Pool6: danmtypes.IpPoolV6{IpPool: danmtypes.IpPool{Start: "2a00:8a00:a000:1193::1", End: "2a00:8a00:a000:1193::fe"}, Cidr: "2a00:8a00:a000:1193::/120"}, Alloc6: createAllocWithIps("2a00:8a00:a000:1193::/120", nil)}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "ipam-v6-ip-reserved"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "ipamv6", Options: danmtypes.DanmNetOption{Device: "ens9", Net6: "2a00:8a00:a000:1193::/64", Pool6: danmtypes.IpPoolV6{IpPool: danmtypes.IpPool{Start: "2a00:8a00:a000:1193::1", End: "2a00:8a00:a000:1193::fe"}, Cidr: "2a00:8a00:a000:1193::/120"}, Alloc6: createAllocWithIps("2a00:8a00:a000:1193::/120", nil, "2a00:8a00:a000:1193::5")}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "ipam-v6-wrong-alloc6"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "ipamv6", Options: danmtypes.DanmNetOption{Device: "ens9", Net6: "2a00:8a00:a000:1193::/64", Pool6: danmtypes.IpPoolV6{IpPool: danmtypes.IpPool{Start: "2a00:8a00:a000:1193::1", End: "2a00:8a00:a000:1193::fe"}, Cidr: "2a00:8a00:a000:1193::/120"}, Alloc6: createAllocWithIps("2a00:8a00:a000:1193::/112", nil, "2a00:8a00:a000:1193::5")}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "tnet-with-owner-ip-reserved", Annotations: map[string]string{admit.TenantConfigAnnotation: "tconf"}},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens3", Cidr: "192.168.1.64/26", Alloc: createAllocWithIps("192.168.1.64/26", nil, "192.168.1.65"), Pool: danmtypes.IpPool{LastIp: "192.168.1.65"}}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "tnet-other-owner-ip-reserved", Annotations: map[string]string{admit.TenantConfigAnnotation: "other"}},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens3", Cidr: "192.168.1.64/26", Alloc: createAllocWithIps("192.168.1.64/26", nil, "192.168.1.65"), Pool: danmtypes.IpPool{LastIp: "192.168.1.65"}}},
+    },
   }
+  resizeRoutes = map[string]string{"10.20.0.0/24": "10.110.0.1"}
+  resizeAlloc = createAllocWithIps("10.110.0.0/24", resizeRoutes, "10.110.0.10", "10.110.0.50")
 )
 
 var (
   neTypeAndAlloc = []admit.Patch {
     admit.Patch {Path: "/spec/NetworkType"},
     admit.Patch {Path: "/spec/Options/alloc"},
-    admit.Patch {Path: "/spec/Options/allocation_pool"},
+    admit.Patch {Path: "/spec/Options/allocation_pool/start"},
+    admit.Patch {Path: "/spec/Options/allocation_pool/end"},
+  }
+  allocOnly = []admit.Patch {
+    admit.Patch {Path: "/spec/Options/alloc"},
+    admit.Patch {Path: "/spec/Options/allocation_pool/start"},
+    admit.Patch {Path: "/spec/Options/allocation_pool/end"},
+  }
+  onlyAlloc = []admit.Patch {
+    admit.Patch {Path: "/spec/Options/alloc"},
   }
   onlyNeType = []admit.Patch {
     admit.Patch {Path: "/spec/NetworkType"},
   }
   allocAndVlan = []admit.Patch {
+    admit.Patch {Path: "/metadata/annotations"},
     admit.Patch {Path: "/spec/Options/alloc"},
     admit.Patch {Path: "/spec/Options/vlan"},
   }
   allocAndVxlan = []admit.Patch {
+    admit.Patch {Path: "/metadata/annotations"},
     admit.Patch {Path: "/spec/Options/alloc"},
     admit.Patch {Path: "/spec/Options/vxlan"},
   }
   allocAndVxlanAndDevice = []admit.Patch {
+    admit.Patch {Path: "/metadata/annotations"},
     admit.Patch {Path: "/spec/Options/alloc"},
     admit.Patch {Path: "/spec/Options/vxlan"},
     admit.Patch {Path: "/spec/Options/host_device"},
   }
+  onlyVxlan = []admit.Patch {
+    admit.Patch {Path: "/metadata/annotations"},
+    admit.Patch {Path: "/spec/Options/vxlan"},
+  }
   onlyNid = []admit.Patch {
+    admit.Patch {Path: "/metadata/annotations"},
     admit.Patch {Path: "/spec/NetworkID"},
   }
   deviceAndNidAndVxlan = []admit.Patch {
+    admit.Patch {Path: "/metadata/annotations"},
     admit.Patch {Path: "/spec/NetworkID"},
     admit.Patch {Path: "/spec/Options/host_device"},
     admit.Patch {Path: "/spec/Options/vxlan"},
   }
   v6Allocs = []admit.Patch {
     admit.Patch {Path: "/spec/Options/alloc6"},
-    admit.Patch {Path: "/spec/Options/allocation_pool_v6"},
+    admit.Patch {Path: "/spec/Options/allocation_pool_v6/start"},
+    admit.Patch {Path: "/spec/Options/allocation_pool_v6/end"},
+    admit.Patch {Path: "/spec/Options/allocation_pool_v6/cidr"},
   }
   v6AllocsForTnet = []admit.Patch {
+    admit.Patch {Path: "/metadata/annotations"},
     admit.Patch {Path: "/spec/Options/host_device"},
     admit.Patch {Path: "/spec/Options/vxlan"},
     admit.Patch {Path: "/spec/Options/alloc6"},
-    admit.Patch {Path: "/spec/Options/allocation_pool_v6"},
+    admit.Patch {Path: "/spec/Options/allocation_pool_v6/start"},
+    admit.Patch {Path: "/spec/Options/allocation_pool_v6/end"},
+    admit.Patch {Path: "/spec/Options/allocation_pool_v6/cidr"},
   }
 )
 
@@ -461,6 +777,30 @@ var (
        },
     },
   }
+  usedVniDev = []danmtypes.TenantConfig {
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "tconf"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens7", VniType: "vxlan", VniRange: "700", Alloc: utils.AllocFor5k},
+       },
+    },
+  }
+  usedVniOtherDev = []danmtypes.TenantConfig {
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "tconf"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens9", VniType: "vxlan", VniRange: "700", Alloc: utils.AllocFor5k},
+       },
+    },
+  }
+  partlyUsedVniDev = []danmtypes.TenantConfig {
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "tconf"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens7", VniType: "vxlan", VniRange: "700-701", Alloc: utils.AllocFor5k},
+       },
+    },
+  }
   nidMappings = []danmtypes.TenantConfig {
       danmtypes.TenantConfig {
       ObjectMeta: meta_v1.ObjectMeta {Name: "tconf"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
@@ -529,6 +869,32 @@ var (
   }
 )
 
+var (
+  disabled = false
+  vxlanModeEps = []danmtypes.DanmEp {
+    danmtypes.DanmEp{
+      ObjectMeta: meta_v1.ObjectMeta {Name: "vxlan-mode-ep"},
+      Spec: danmtypes.DanmEpSpec {ApiType: "ClusterNetwork", NetworkName: "vxlan-multicast", Pod: "vxlan-pod"},
+    },
+  }
+  resizeEps = []danmtypes.DanmEp {
+    danmtypes.DanmEp{
+      ObjectMeta: meta_v1.ObjectMeta {Name: "resize1"},
+      Spec: danmtypes.DanmEpSpec {ApiType: "ClusterNetwork", NetworkName: "resize-old", Iface: danmtypes.DanmEpIface{Address: "10.110.0.10/24"}},
+    },
+    danmtypes.DanmEp{
+      ObjectMeta: meta_v1.ObjectMeta {Name: "resize2"},
+      Spec: danmtypes.DanmEpSpec {ApiType: "ClusterNetwork", NetworkName: "resize-old", Iface: danmtypes.DanmEpIface{Address: "10.110.0.50/24"}},
+    },
+  }
+  resizeOutsideEps = append([]danmtypes.DanmEp {
+    danmtypes.DanmEp{
+      ObjectMeta: meta_v1.ObjectMeta {Name: "resize3"},
+      Spec: danmtypes.DanmEpSpec {ApiType: "ClusterNetwork", NetworkName: "resize-old", Iface: danmtypes.DanmEpIface{Address: "10.110.0.200/24"}},
+    },
+  }, resizeEps...)
+)
+
 func TestValidateNetwork(t *testing.T) {
   validator := admit.Validator{}
   for _, tc := range validateNetworkTcs {
@@ -564,6 +930,27 @@ func TestValidateNetwork(t *testing.T) {
   }
 }
 
+func TestValidateNetworkIpamUpdate(t *testing.T) {
+  for _, tc := range ipamUpdateTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      writerStub := httpstub.NewWriterStub()
+      oldNet, _, _ := getNetForValidate(tc.oldNetName, valNets, tc.neType)
+      newNet, _, _ := getNetForValidate(tc.newNetName, valNets, tc.neType)
+      request, err := utils.CreateHttpRequestFromUser(oldNet, newNet, admissionv1.Update, tc.userName)
+      if err != nil {
+        t.Errorf("Could not create test HTTP Request object, because:%v", err)
+        return
+      }
+      validator := admit.Validator{Client: stubs.NewClientSetStub(utils.TestArtifacts{TestNets: valNets, TestEps: tc.eps, TestTconfs: tc.tconf})}
+      validator.ValidateNetwork(writerStub, request)
+      err = utils.ValidateHttpResponse(writerStub, tc.isErrorExpected, nil)
+      if err != nil {
+        t.Errorf("Received HTTP Response did not match expectation, because:%v", err)
+      }
+    })
+  }
+}
+
 func getNetForValidate(name string, nets []danmtypes.DanmNet, neType string) ([]byte, *danmtypes.DanmNet, bool) {
   dnet := utils.GetTestNet(name, nets)
   if dnet == nil {
@@ -576,4 +963,94 @@ func getNetForValidate(name string, nets []danmtypes.DanmNet, neType string) ([]
   dnet.TypeMeta.Kind = neType
   dnetBinary,_ := json.Marshal(dnet)
   return dnetBinary, dnet, shouldItMalform
-}
\ No newline at end of file
+}
+
+func createAllocWithIps(cidr string, routes map[string]string, ips ...string) string {
+  _, subnet, _ := net.ParseCIDR(cidr)
+  ba := bitarray.NewBitArrayFromBase64(ipam.CreateAllocationArray(subnet, routes))
+  for _, ip := range ips {
+    ba.Set(ipam.GetIndexOfIp(net.ParseIP(ip), subnet))
+  }
+  return ba.Encode()
+}
+
+var (
+  selectionTconfs = []danmtypes.TenantConfig {
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "default-conf"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens4", VniType: "vlan", VniRange: "200-210", Alloc: utils.AllocFor5k},
+      },
+    },
+    danmtypes.TenantConfig {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "blue-conf"},TypeMeta: meta_v1.TypeMeta {Kind: "TenantConfig"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens5", VniType: "vlan", VniRange: "300-310", Alloc: utils.AllocFor5k},
+      },
+      NamespaceSelector: &meta_v1.LabelSelector{MatchLabels: map[string]string{"tenant": "blue"}},
+    },
+  }
+  selectionNamespaces = []runtime.Object {
+    &corev1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "blue-ns", Labels: map[string]string{"tenant": "blue"}}},
+    &corev1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "red-ns", Labels: map[string]string{"tenant": "red"}}},
+  }
+)
+
+var tconfSelectionTcs = []struct {
+  tcName string
+  namespace string
+  isErrorExpected bool
+  expectedDevice string
+}{
+  {"namespaceSelectedByLabels", "blue-ns", false, "ens5"},
+  {"namespaceFallingBackToDefault", "red-ns", false, "ens4"},
+  {"nonExistingNamespace", "missing-ns", true, ""},
+}
+
+func TestTenantConfigSelection(t *testing.T) {
+  for _, tc := range tconfSelectionTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      tnet := danmtypes.DanmNet {
+        ObjectMeta: meta_v1.ObjectMeta {Name: "selection", Namespace: tc.namespace},
+        TypeMeta: meta_v1.TypeMeta {Kind: TnetType},
+        Spec: danmtypes.DanmNetSpec{NetworkID: "selection", NetworkType: "ipvlan"},
+      }
+      tnetBinary, _ := json.Marshal(tnet)
+      request, err := utils.CreateHttpRequest(nil, tnetBinary, false, false, v1beta1.Create)
+      if err != nil {
+        t.Errorf("Could not create test HTTP Request object, because:%v", err)
+        return
+      }
+      tconfs := []danmtypes.TenantConfig{*selectionTconfs[0].DeepCopy(), *selectionTconfs[1].DeepCopy()}
+      validator := admit.Validator {
+        Client: stubs.NewClientSetStub(utils.TestArtifacts{TestTconfs: tconfs}),
+        KubeClient: k8sfake.NewSimpleClientset(selectionNamespaces...),
+      }
+      writerStub := httpstub.NewWriterStub()
+      validator.ValidateNetwork(writerStub, request)
+      response, err := writerStub.GetAdmissionResponse()
+      if err != nil {
+        t.Errorf("Admission response could not be read, because:%v", err)
+        return
+      }
+      if response.Allowed == tc.isErrorExpected {
+        t.Errorf("TenantNetwork was admitted:%t, but we expected:%t", response.Allowed, !tc.isErrorExpected)
+        return
+      }
+      if tc.isErrorExpected {
+        return
+      }
+      var patches []admit.Patch
+      json.Unmarshal(response.Patch, &patches)
+      var chosenDevice interface{}
+      for _, patch := range patches {
+        if patch.Path == "/spec/Options/host_device" {
+          chosenDevice = patch.Value
+        }
+      }
+      if chosenDevice != tc.expectedDevice {
+        t.Errorf("TenantNetwork was attached to host_device:%v, but we expected:%s", chosenDevice, tc.expectedDevice)
+      }
+    })
+  }
+}
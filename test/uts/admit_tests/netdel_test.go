@@ -7,6 +7,7 @@ import (
   "encoding/json"
   danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
   "github.com/nokia/danm/pkg/admit"
+  "github.com/nokia/danm/pkg/confman"
   stubs "github.com/nokia/danm/test/stubs/danm"
   httpstub "github.com/nokia/danm/test/stubs/http"
   "github.com/nokia/danm/test/utils"
@@ -36,6 +37,11 @@ var deleteNetworkTcs = []struct {
   {"cannotDeleteDueToError", "ipvlan", validConf, errorEp, true, false, nil, 0},
   {"cannotDeleteDueToConnectedPods", "ipvlan", validConf, existingPods, true, false, nil, 0},
   {"noMatchingPods", "ipvlan", validConf, notMatchingPods, false, true, nil, 1},
+  {"staticNetworkWithoutCarvedSubnet", "flannel", supernetConf, nil, false, false, nil, 0},
+  {"releaseCarvedSubnet", "carved", supernetConf, nil, false, false, nil, 1},
+  {"explicitCidrInSupernetIsKept", "explicit-cidr-in-supernet", supernetConf, nil, false, false, nil, 0},
+  {"freeFromOwnerTenantConfig", "owned-ipvlan", ownedConfs, nil, false, true, nil, 1},
+  {"missingOwnerTenantConfig", "orphan-ipvlan", ownedConfs, nil, true, false, nil, 0},
 }
 
 var (
@@ -57,11 +63,39 @@ var (
       TypeMeta: meta_v1.TypeMeta {Kind: "TenantNetwork"},
       Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens4", Vlan: 500}},
     },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "owned-ipvlan", Annotations: map[string]string{admit.TenantConfigAnnotation: "owner"}},
+      TypeMeta: meta_v1.TypeMeta {Kind: "TenantNetwork"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens4", Vlan: 450}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "orphan-ipvlan", Annotations: map[string]string{admit.TenantConfigAnnotation: "deleted"}},
+      TypeMeta: meta_v1.TypeMeta {Kind: "TenantNetwork"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "nanomsg", Options: danmtypes.DanmNetOption{Device: "ens4", Vlan: 450}},
+    },
     danmtypes.DanmNet {
       ObjectMeta: meta_v1.ObjectMeta {Name: "sriov"},
       TypeMeta: meta_v1.TypeMeta {Kind: "TenantNetwork"},
       Spec: danmtypes.DanmNetSpec{NetworkType: "sriov", NetworkID: "e2", Options: danmtypes.DanmNetOption{DevicePool: "nokia.k8s.io/sriov_ens1f1", Vlan: 500}},
     },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "carved", Annotations: map[string]string{confman.CarvedSubnetAnnotation: "10.70.0.64/26"}},
+      TypeMeta: meta_v1.TypeMeta {Kind: "TenantNetwork"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "flannel", NetworkID: "flannel", Options: danmtypes.DanmNetOption{Cidr: "10.70.0.64/26"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "explicit-cidr-in-supernet"},
+      TypeMeta: meta_v1.TypeMeta {Kind: "TenantNetwork"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "flannel", NetworkID: "flannel", Options: danmtypes.DanmNetOption{Cidr: "10.70.0.64/26"}},
+    },
+  }
+  ownedConfs = []danmtypes.TenantConfig {
+    delConf[0],
+    danmtypes.TenantConfig{
+      ObjectMeta: meta_v1.ObjectMeta {Name: "owner"},
+      HostDevices: []danmtypes.IfaceProfile {
+        danmtypes.IfaceProfile{Name: "ens4", VniType: "vlan", VniRange: "400-500", Alloc: utils.ExhaustedAllocFor5k},},
+    },
   }
   delConf = []danmtypes.TenantConfig {
     danmtypes.TenantConfig{
@@ -79,6 +113,12 @@ var (
         danmtypes.IfaceProfile{Name: "nokia.k8s.io/sriov_ens1f0", VniType: "vlan", VniRange: "1500-1550", Alloc: utils.ExhaustedAllocFor5k},},
     },
   }
+  supernetConf = []danmtypes.TenantConfig {
+    danmtypes.TenantConfig{
+      ObjectMeta: meta_v1.ObjectMeta {Name: "tconf"},
+      Supernets: []danmtypes.TenantSupernet{danmtypes.TenantSupernet{Cidr: "10.70.0.0/24", PrefixLength: 26, Alloc: "wA=="}},
+    },
+  }
   validConf = []danmtypes.TenantConfig {
     danmtypes.TenantConfig{
       ObjectMeta: meta_v1.ObjectMeta {Name: "tconf"},
@@ -0,0 +1,128 @@
+package admit_tests
+
+import (
+  "testing"
+  "encoding/json"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/admit"
+  stubs "github.com/nokia/danm/test/stubs/danm"
+  httpstub "github.com/nokia/danm/test/stubs/http"
+  "github.com/nokia/danm/test/utils"
+  "k8s.io/api/admission/v1beta1"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+  placementNets = []danmtypes.DanmNet {
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "placement-ens5-1"},
+      TypeMeta: meta_v1.TypeMeta {Kind: "DanmNet"},
+      Spec: danmtypes.DanmNetSpec{NetworkID: "ens5-1", NetworkType: "ipvlan", Options: danmtypes.DanmNetOption{Device: "ens5"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "placement-ens5-2"},
+      TypeMeta: meta_v1.TypeMeta {Kind: "DanmNet"},
+      Spec: danmtypes.DanmNetSpec{NetworkID: "ens5-2", NetworkType: "ipvlan", Options: danmtypes.DanmNetOption{Device: "ens5"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "placement-ens4"},
+      TypeMeta: meta_v1.TypeMeta {Kind: "DanmNet"},
+      Spec: danmtypes.DanmNetSpec{NetworkID: "ens4", NetworkType: "ipvlan", Options: danmtypes.DanmNetOption{Device: "ens4"}},
+    },
+  }
+)
+
+var placementTcs = []struct {
+  tcName string
+  policy string
+  lastPlacement string
+  netLabels map[string]string
+  exhaustedOnly bool
+  isErrorExpected bool
+  expectedDevice string
+}{
+  {"RandomFallsBackFromExhaustedProfile", admit.RandomPlacement, "", nil, false, false, ""},
+  {"RandomWithAllProfilesExhausted", admit.RandomPlacement, "", nil, true, true, ""},
+  {"LeastUsedSkipsExhaustedProfile", admit.LeastUsedPlacement, "", nil, false, false, "ens4"},
+  {"RoundRobinContinuesAfterLastPlacement", admit.RoundRobinPlacement, "ens4", nil, false, false, "ens5"},
+  {"RoundRobinFallsBackFromExhaustedProfile", admit.RoundRobinPlacement, "ens5", nil, false, false, "ens4"},
+  {"RoundRobinWithoutLastPlacement", admit.RoundRobinPlacement, "", nil, false, false, "ens4"},
+  {"WeightedFallsBackFromExhaustedProfile", admit.WeightedPlacement, "", nil, false, false, ""},
+  {"LabelAffinityPrefersMoreSpecificProfile", admit.LabelAffinityPlacement, "", map[string]string{"speed": "fast", "zone": "a"}, false, false, "ens5"},
+  {"LabelAffinityMatchesSubset", admit.LabelAffinityPlacement, "", map[string]string{"speed": "fast", "zone": "b"}, false, false, "ens4"},
+  {"LabelAffinityWithOnlyExhaustedMatch", admit.LabelAffinityPlacement, "", map[string]string{"speed": "slow"}, false, true, ""},
+}
+
+func TestIfaceProfilePlacement(t *testing.T) {
+  for _, tc := range placementTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      tnet := danmtypes.DanmNet {
+        ObjectMeta: meta_v1.ObjectMeta {Name: "placement", Namespace: "default", Labels: tc.netLabels},
+        TypeMeta: meta_v1.TypeMeta {Kind: TnetType},
+        Spec: danmtypes.DanmNetSpec{NetworkID: "placement", NetworkType: "ipvlan"},
+      }
+      tnetBinary, _ := json.Marshal(tnet)
+      request, err := utils.CreateHttpRequest(nil, tnetBinary, false, false, v1beta1.Create)
+      if err != nil {
+        t.Errorf("Could not create test HTTP Request object, because:%v", err)
+        return
+      }
+      tconf := createPlacementConf(tc.policy, tc.lastPlacement, tc.exhaustedOnly)
+      nets := append([]danmtypes.DanmNet{}, placementNets...)
+      testClient := stubs.NewClientSetStub(utils.TestArtifacts{TestNets: nets, TestTconfs: []danmtypes.TenantConfig{tconf}})
+      validator := admit.Validator{Client: testClient}
+      writerStub := httpstub.NewWriterStub()
+      validator.ValidateNetwork(writerStub, request)
+      response, err := writerStub.GetAdmissionResponse()
+      if err != nil {
+        t.Errorf("Admission response could not be read, because:%v", err)
+        return
+      }
+      if response.Allowed == tc.isErrorExpected {
+        t.Errorf("TenantNetwork was admitted:%t, but we expected:%t", response.Allowed, !tc.isErrorExpected)
+        return
+      }
+      if tc.isErrorExpected {
+        return
+      }
+      var patches []admit.Patch
+      json.Unmarshal(response.Patch, &patches)
+      var chosenDevice interface{}
+      for _, patch := range patches {
+        if patch.Path == "/spec/Options/host_device" {
+          chosenDevice = patch.Value
+        }
+      }
+      if chosenDevice == "ens3" || (tc.expectedDevice != "" && chosenDevice != tc.expectedDevice) {
+        t.Errorf("TenantNetwork was attached to host_device:%v, but we expected:%s", chosenDevice, tc.expectedDevice)
+        return
+      }
+      if tc.policy == admit.RoundRobinPlacement {
+        lastUpdatedTconf := testClient.DanmClient.TconfClient.LastUpdatedTconf
+        if lastUpdatedTconf == nil || lastUpdatedTconf.LastPlacement != chosenDevice {
+          t.Errorf("Round-robin placement of the TenantConfig was not advanced to:%v", chosenDevice)
+        }
+      }
+    })
+  }
+}
+
+//ens3 is exhausted, ens4 has free VNIs, and ens5 is not virtual
+func createPlacementConf(policy, lastPlacement string, exhaustedOnly bool) danmtypes.TenantConfig {
+  tconf := danmtypes.TenantConfig {
+    ObjectMeta: meta_v1.ObjectMeta {Name: "placement-conf"},
+    PlacementPolicy: policy,
+    LastPlacement: lastPlacement,
+    HostDevices: []danmtypes.IfaceProfile {
+      danmtypes.IfaceProfile{Name: "ens3", VniType: "vlan", VniRange: "900-4999,5000", Alloc: utils.ExhaustedAllocFor5k, Weight: 1000, Labels: map[string]string{"speed": "slow"}},
+    },
+  }
+  if exhaustedOnly {
+    return tconf
+  }
+  tconf.HostDevices = append(tconf.HostDevices,
+    danmtypes.IfaceProfile{Name: "ens4", VniType: "vlan", VniRange: "900-4999,5000", Alloc: utils.AllocFor5k, Labels: map[string]string{"speed": "fast"}},
+    danmtypes.IfaceProfile{Name: "ens5", Labels: map[string]string{"speed": "fast", "zone": "a"}},
+  )
+  return tconf
+}
@@ -0,0 +1,100 @@
+package admit_tests
+
+import (
+  "testing"
+  "encoding/json"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/admit"
+  stubs "github.com/nokia/danm/test/stubs/danm"
+  httpstub "github.com/nokia/danm/test/stubs/http"
+  "github.com/nokia/danm/test/utils"
+  corev1 "k8s.io/api/core/v1"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var validatePodTcs = []struct {
+  tcName string
+  annotation string
+  isErrorExpected bool
+}{
+  {"noAnnotation", "", false},
+  {"malformedAnnotation", `[{"network":"ipvlan"`, true},
+  {"unknownField", `[{"network":"ipvlan","hululu":"lulu"}]`, true},
+  {"noNetworkReference", `[{"ip":"dynamic"}]`, true},
+  {"multipleNetworkReferences", `[{"network":"ipvlan","clusterNetwork":"ipvlan"}]`, true},
+  {"nonExistentNetwork", `[{"network":"nonexistent"}]`, true},
+  {"tenantNotAllowed", `[{"network":"restricted"}]`, true},
+  {"tenantAllowed", `[{"network":"allowed"}]`, false},
+  {"dynamicIpFromL2Network", `[{"network":"l2","ip":"dynamic"}]`, true},
+  {"noIpFromL2Network", `[{"network":"l2","ip":"none"}]`, false},
+  {"invalidStaticIp", `[{"network":"ipvlan","ip":"10.0.0.hululu"}]`, true},
+  {"staticIpOutsideCidr", `[{"network":"ipvlan","ip":"10.1.0.5/24"}]`, true},
+  {"staticIpSuccess", `[{"network":"ipvlan","ip":"10.0.0.5/24"}]`, false},
+  {"staticIp6OutsideNet6", `[{"network":"ipvlan","ip6":"2a00:8a00:a000:1194::5"}]`, true},
+  {"staticIp6Success", `[{"network":"ipvlan","ip":"dynamic","ip6":"2a00:8a00:a000:1193::5"}]`, false},
+  {"ip6IgnoredForSlaac", `[{"network":"slaac","ip6":"2a00:8a00:a000:1194::5"}]`, false},
+  {"ipIgnoredForStaticDelegate", `[{"network":"flannel","ip":"dynamic"}]`, false},
+  {"secondConnectionInvalid", `[{"network":"ipvlan"},{"network":"ipvlan","ip":"10.1.0.5"}]`, true},
+  {"twoDefaultRouteClaims", `[{"network":"ipvlan","ip":"dynamic","defaultRoute":true},{"network":"allowed","defaultRoute":true}]`, true},
+  {"defaultRouteClaimWithoutIp", `[{"network":"l2","ip":"none","defaultRoute":true}]`, true},
+  {"defaultRouteOwnershipInAnnotation", `[{"network":"ipvlan","ip":"dynamic","IsDefaultRouteOwnedByOther":true}]`, true},
+  {"defaultRoute6OwnershipInAnnotation", `[{"network":"ipvlan","ip6":"dynamic","IsDefaultRoute6OwnedByOther":true}]`, true},
+  {"defaultRouteClaimsOfOtherFamilies", `[{"network":"ipvlan","ip":"dynamic","defaultRoute":true},{"network":"ipvlan","ip6":"dynamic","defaultRoute":true}]`, false},
+}
+
+var (
+  podNets = []danmtypes.DanmNet {
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "ipvlan"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "ipvlan", Options: danmtypes.DanmNetOption{Cidr: "10.0.0.0/24", Net6: "2a00:8a00:a000:1193::/64"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "restricted"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "restricted", AllowedTenants: []string{"other"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "allowed"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "allowed", AllowedTenants: []string{"other","podns"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "l2"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "l2"},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "slaac"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "ipvlan", NetworkID: "slaac", Options: danmtypes.DanmNetOption{Net6: "2a00:8a00:a000:1193::/64", Ipv6Mode: "slaac"}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "flannel"},
+      Spec: danmtypes.DanmNetSpec{NetworkType: "flannel", NetworkID: "flannel"},
+    },
+  }
+)
+
+func TestValidatePod(t *testing.T) {
+  validator := admit.Validator{}
+  for _, tc := range validatePodTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      writerStub := httpstub.NewWriterStub()
+      pod := corev1.Pod {
+        ObjectMeta: meta_v1.ObjectMeta {Name: "testpod", Namespace: "podns"},
+      }
+      if tc.annotation != "" {
+        pod.ObjectMeta.Annotations = map[string]string{"danm.k8s.io/interfaces": tc.annotation}
+      }
+      podBinary,_ := json.Marshal(pod)
+      request,err := utils.CreateHttpRequest(nil, podBinary, false, false, "")
+      if err != nil {
+        t.Errorf("Could not create test HTTP Request object, because:%v", err)
+        return
+      }
+      testArtifacts := utils.TestArtifacts{TestNets: podNets}
+      validator.Client = stubs.NewClientSetStub(testArtifacts)
+      validator.ValidatePod(writerStub, request)
+      err = utils.ValidateHttpResponse(writerStub, tc.isErrorExpected, nil)
+      if err != nil {
+        t.Errorf("Received HTTP Response did not match expectation, because:%v", err)
+      }
+    })
+  }
+}
@@ -0,0 +1,109 @@
+package admit_tests
+
+import (
+  "testing"
+  "encoding/json"
+  "net/http/httptest"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/admit"
+  stubs "github.com/nokia/danm/test/stubs/danm"
+  httpstub "github.com/nokia/danm/test/stubs/http"
+  "github.com/nokia/danm/test/utils"
+  "k8s.io/api/admission/v1beta1"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+  quotaNets = []danmtypes.DanmNet {
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "quota-used", Namespace: "quota"},
+      TypeMeta: meta_v1.TypeMeta {Kind: TnetType},
+      Spec: danmtypes.DanmNetSpec{NetworkID: "used", NetworkType: "ipvlan", Options: danmtypes.DanmNetOption{Device: "ens4", Vlan: 100, Cidr: "10.50.0.0/24", Pool: danmtypes.IpPool{Start: "10.50.0.1", End: "10.50.0.10"}}},
+    },
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "quota-other", Namespace: "other"},
+      TypeMeta: meta_v1.TypeMeta {Kind: TnetType},
+      Spec: danmtypes.DanmNetSpec{NetworkID: "other", NetworkType: "ipvlan", Options: danmtypes.DanmNetOption{Device: "ens4", Vlan: 101, Cidr: "10.51.0.0/24", Pool: danmtypes.IpPool{Start: "10.51.0.1", End: "10.51.0.100"}}},
+    },
+  }
+)
+
+var tenantQuotaTcs = []struct {
+  tcName string
+  quota *danmtypes.TenantQuota
+  networkType string
+  isErrorExpected bool
+}{
+  {"NoQuota", nil, "ipvlan", false},
+  {"UnlimitedQuota", &danmtypes.TenantQuota{}, "ipvlan", false},
+  {"NetworksUnderLimit", &danmtypes.TenantQuota{MaxNetworks: 2}, "ipvlan", false},
+  {"NetworksReachedLimit", &danmtypes.TenantQuota{MaxNetworks: 1}, "ipvlan", true},
+  {"IpsUnderLimit", &danmtypes.TenantQuota{MaxIps: 30}, "ipvlan", false},
+  {"IpsExceedLimit", &danmtypes.TenantQuota{MaxIps: 20}, "ipvlan", true},
+  {"VnisUnderLimit", &danmtypes.TenantQuota{MaxVnisPerProfile: 2}, "ipvlan", false},
+  {"VnisReachedLimit", &danmtypes.TenantQuota{MaxVnisPerProfile: 1}, "ipvlan", true},
+  {"VniQuotaDoesNotApplyToStaticBackends", &danmtypes.TenantQuota{MaxVnisPerProfile: 1}, "flannel", false},
+}
+
+func TestTenantQuota(t *testing.T) {
+  for _, tc := range tenantQuotaTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      tnet := danmtypes.DanmNet {
+        ObjectMeta: meta_v1.ObjectMeta {Name: "quota-new", Namespace: "quota"},
+        TypeMeta: meta_v1.TypeMeta {Kind: TnetType},
+        Spec: danmtypes.DanmNetSpec{NetworkID: "new", NetworkType: tc.networkType, Options: danmtypes.DanmNetOption{Cidr: "10.60.0.0/24", Pool: danmtypes.IpPool{Start: "10.60.0.1", End: "10.60.0.15"}}},
+      }
+      tnetBinary, _ := json.Marshal(tnet)
+      request, err := utils.CreateHttpRequest(nil, tnetBinary, false, false, v1beta1.Create)
+      if err != nil {
+        t.Errorf("Could not create test HTTP Request object, because:%v", err)
+        return
+      }
+      nets := append([]danmtypes.DanmNet{}, quotaNets...)
+      validator := admit.Validator{Client: stubs.NewClientSetStub(utils.TestArtifacts{TestNets: nets, TestTconfs: []danmtypes.TenantConfig{createQuotaConf(tc.quota)}})}
+      writerStub := httpstub.NewWriterStub()
+      validator.ValidateNetwork(writerStub, request)
+      response, err := writerStub.GetAdmissionResponse()
+      if err != nil {
+        t.Errorf("Admission response could not be read, because:%v", err)
+        return
+      }
+      if response.Allowed == tc.isErrorExpected {
+        t.Errorf("TenantNetwork was admitted:%t, but we expected:%t", response.Allowed, !tc.isErrorExpected)
+      }
+    })
+  }
+}
+
+func TestQuotaUsage(t *testing.T) {
+  nets := append([]danmtypes.DanmNet{}, quotaNets...)
+  validator := admit.Validator{Client: stubs.NewClientSetStub(utils.TestArtifacts{TestNets: nets, TestTconfs: []danmtypes.TenantConfig{createQuotaConf(&danmtypes.TenantQuota{MaxNetworks: 3, MaxIps: 50, MaxVnisPerProfile: 1})}})}
+  recorder := httptest.NewRecorder()
+  validator.ServeQuotaUsage(recorder, httptest.NewRequest("GET", "/quotas", nil))
+  var usages []admit.QuotaUsage
+  err := json.Unmarshal(recorder.Body.Bytes(), &usages)
+  if err != nil {
+    t.Errorf("Quota usage could not be decoded, because:%v", err)
+    return
+  }
+  if len(usages) != 2 || usages[0].Namespace != "other" || usages[1].Namespace != "quota" {
+    t.Errorf("Quota usage should have been reported for namespaces other and quota, but it was:%v", usages)
+    return
+  }
+  usage := usages[1]
+  if usage.TenantConfig != "quota-conf" || usage.Networks.Used != 1 || usage.Networks.Limit != 3 || usage.Ips.Used != 10 || usage.Ips.Limit != 50 {
+    t.Errorf("Quota usage of namespace:quota does not match expectation:%v", usage)
+  }
+  if len(usage.Vnis) != 1 || usage.Vnis[0].Profile != "ens4" || usage.Vnis[0].Used != 1 || usage.Vnis[0].Limit != 1 {
+    t.Errorf("VNI usage of namespace:quota does not match expectation:%v", usage.Vnis)
+  }
+}
+
+func createQuotaConf(quota *danmtypes.TenantQuota) danmtypes.TenantConfig {
+  return danmtypes.TenantConfig {
+    ObjectMeta: meta_v1.ObjectMeta {Name: "quota-conf"},
+    HostDevices: []danmtypes.IfaceProfile{danmtypes.IfaceProfile{Name: "ens4", VniType: "vlan", VniRange: "100-110", Alloc: utils.AllocFor5k}},
+    NetworkIds: map[string]string {"flannel": "flannel"},
+    Quota: quota,
+  }
+}
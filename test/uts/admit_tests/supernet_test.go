@@ -0,0 +1,199 @@
+package admit_tests
+
+import (
+  "reflect"
+  "testing"
+  "encoding/json"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/admit"
+  "github.com/nokia/danm/pkg/bitarray"
+  "github.com/nokia/danm/pkg/confman"
+  stubs "github.com/nokia/danm/test/stubs/danm"
+  httpstub "github.com/nokia/danm/test/stubs/http"
+  "github.com/nokia/danm/test/utils"
+  "k8s.io/api/admission/v1beta1"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+  noCarvedSubnet = "AA=="
+  thirdSubnetCarved = "IA=="
+  allSubnetsCarved = "8A=="
+)
+
+var (
+  supernetNets = []danmtypes.DanmNet {
+    danmtypes.DanmNet {
+      ObjectMeta: meta_v1.ObjectMeta {Name: "supernet-user"},
+      TypeMeta: meta_v1.TypeMeta {Kind: "DanmNet"},
+      Spec: danmtypes.DanmNetSpec{NetworkID: "user", NetworkType: "ipvlan", Options: danmtypes.DanmNetOption{Cidr: "10.80.0.0/26"}},
+    },
+  }
+)
+
+var carveSubnetTcs = []struct {
+  tcName string
+  namespace string
+  cidr string
+  supernets []danmtypes.TenantSupernet
+  quota *danmtypes.TenantQuota
+  isErrorExpected bool
+  expectedCidr string
+  expectedCarvedSubnets int
+}{
+  {"SubnetOverlappingWithExistingNetworkIsSkipped", "default", "", []danmtypes.TenantSupernet{createSupernet("10.80.0.0/24", "", noCarvedSubnet)}, nil, false, "10.80.0.64/26", 1},
+  {"ExplicitCidrIsKept", "default", "10.90.0.0/24", []danmtypes.TenantSupernet{createSupernet("10.80.0.0/24", "", noCarvedSubnet)}, nil, false, "", 0},
+  {"NamespaceSupernetIsPreferred", "blue", "", []danmtypes.TenantSupernet{createSupernet("10.80.0.0/24", "", noCarvedSubnet), createSupernet("10.81.0.0/24", "blue", noCarvedSubnet)}, nil, false, "10.81.0.0/26", 1},
+  {"NoSupernetForNamespace", "default", "", []danmtypes.TenantSupernet{createSupernet("10.81.0.0/24", "blue", noCarvedSubnet)}, nil, false, "", 0},
+  {"ExhaustedSupernet", "default", "", []danmtypes.TenantSupernet{createSupernet("10.80.0.0/24", "", allSubnetsCarved)}, nil, true, "", 4},
+  {"SubnetIsReleasedWhenNetworkIsDenied", "default", "", []danmtypes.TenantSupernet{createSupernet("10.80.0.0/24", "", noCarvedSubnet)}, &danmtypes.TenantQuota{MaxIps: 10}, true, "", 0},
+}
+
+func TestCarveSubnetFromSupernet(t *testing.T) {
+  for _, tc := range carveSubnetTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      tnet := danmtypes.DanmNet {
+        ObjectMeta: meta_v1.ObjectMeta {Name: "carved", Namespace: tc.namespace},
+        TypeMeta: meta_v1.TypeMeta {Kind: TnetType},
+        Spec: danmtypes.DanmNetSpec{NetworkID: "carved", NetworkType: "flannel", Options: danmtypes.DanmNetOption{Cidr: tc.cidr}},
+      }
+      tnetBinary, _ := json.Marshal(tnet)
+      request, err := utils.CreateHttpRequest(nil, tnetBinary, false, false, v1beta1.Create)
+      if err != nil {
+        t.Errorf("Could not create test HTTP Request object, because:%v", err)
+        return
+      }
+      tconf := danmtypes.TenantConfig {
+        ObjectMeta: meta_v1.ObjectMeta {Name: "supernet-conf"},
+        NetworkIds: map[string]string {"flannel": "flannel"},
+        Supernets: tc.supernets,
+        Quota: tc.quota,
+      }
+      nets := append([]danmtypes.DanmNet{}, supernetNets...)
+      testClient := stubs.NewClientSetStub(utils.TestArtifacts{TestNets: nets, TestTconfs: []danmtypes.TenantConfig{tconf}})
+      validator := admit.Validator{Client: testClient}
+      writerStub := httpstub.NewWriterStub()
+      validator.ValidateNetwork(writerStub, request)
+      response, err := writerStub.GetAdmissionResponse()
+      if err != nil {
+        t.Errorf("Admission response could not be read, because:%v", err)
+        return
+      }
+      if response.Allowed == tc.isErrorExpected {
+        t.Errorf("TenantNetwork was admitted:%t, but we expected:%t", response.Allowed, !tc.isErrorExpected)
+        return
+      }
+      var patches []admit.Patch
+      json.Unmarshal(response.Patch, &patches)
+      var carvedCidr interface{}
+      for _, patch := range patches {
+        if patch.Path == "/spec/Options/cidr" {
+          carvedCidr = patch.Value
+        }
+      }
+      if (tc.expectedCidr == "" && carvedCidr != nil) || (tc.expectedCidr != "" && carvedCidr != tc.expectedCidr) {
+        t.Errorf("TenantNetwork got cidr:%v, but we expected:%s", carvedCidr, tc.expectedCidr)
+      }
+      var carvedSubnets int
+      for _, supernet := range tconf.Supernets {
+        carvedSubnets += confman.GetNumberOfCarvedSubnets(supernet)
+      }
+      if carvedSubnets != tc.expectedCarvedSubnets {
+        t.Errorf("%d subnets are carved out of the supernets, but we expected:%d", carvedSubnets, tc.expectedCarvedSubnets)
+      }
+    })
+  }
+}
+
+var carvedSubnetUpdateTcs = []struct {
+  tcName string
+  newCidr string
+  newCarvedSubnet string
+  quota *danmtypes.TenantQuota
+  isErrorExpected bool
+  expectedCarvedSubnets []int
+}{
+  {"ClearedCidrGetsNewSubnetAndOldOneIsReleased", "", "10.80.0.128/26", nil, false, []int{1}},
+  {"ChangedCidrReleasesCarvedSubnet", "10.90.0.0/24", "10.80.0.128/26", nil, false, nil},
+  {"UnchangedCidrKeepsCarvedSubnet", "10.80.0.128/26", "10.80.0.128/26", nil, false, []int{2}},
+  {"ChangedCarvedSubnetAnnotationIsDenied", "10.80.0.128/26", "10.80.0.192/26", nil, true, []int{2}},
+  {"RemovedCarvedSubnetAnnotationIsDenied", "10.80.0.128/26", "", nil, true, []int{2}},
+  {"DeniedUpdateKeepsOldSubnetAndReleasesNewOne", "", "10.80.0.128/26", &danmtypes.TenantQuota{MaxIps: 10}, true, []int{2}},
+}
+
+func TestCarvedSubnetOnUpdate(t *testing.T) {
+  for _, tc := range carvedSubnetUpdateTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      oldTnet := danmtypes.DanmNet {
+        ObjectMeta: meta_v1.ObjectMeta {Name: "carved", Namespace: "default", Annotations: map[string]string{admit.TenantConfigAnnotation: "supernet-conf", confman.CarvedSubnetAnnotation: "10.80.0.128/26"}},
+        TypeMeta: meta_v1.TypeMeta {Kind: TnetType},
+        Spec: danmtypes.DanmNetSpec{NetworkID: "flannel", NetworkType: "flannel", Options: danmtypes.DanmNetOption{Cidr: "10.80.0.128/26"}},
+      }
+      newTnet := oldTnet.DeepCopy()
+      newTnet.Spec.Options.Cidr = tc.newCidr
+      newTnet.ObjectMeta.Annotations[confman.CarvedSubnetAnnotation] = tc.newCarvedSubnet
+      oldTnetBinary, _ := json.Marshal(oldTnet)
+      newTnetBinary, _ := json.Marshal(newTnet)
+      request, err := utils.CreateHttpRequest(oldTnetBinary, newTnetBinary, false, false, v1beta1.Update)
+      if err != nil {
+        t.Errorf("Could not create test HTTP Request object, because:%v", err)
+        return
+      }
+      tconf := danmtypes.TenantConfig {
+        ObjectMeta: meta_v1.ObjectMeta {Name: "supernet-conf"},
+        NetworkIds: map[string]string {"flannel": "flannel"},
+        Supernets: []danmtypes.TenantSupernet{createSupernet("10.80.0.0/24", "", thirdSubnetCarved)},
+        Quota: tc.quota,
+      }
+      nets := append([]danmtypes.DanmNet{*oldTnet.DeepCopy()}, supernetNets...)
+      testClient := stubs.NewClientSetStub(utils.TestArtifacts{TestNets: nets, TestTconfs: []danmtypes.TenantConfig{tconf}})
+      validator := admit.Validator{Client: testClient}
+      writerStub := httpstub.NewWriterStub()
+      validator.ValidateNetwork(writerStub, request)
+      response, err := writerStub.GetAdmissionResponse()
+      if err != nil {
+        t.Errorf("Admission response could not be read, because:%v", err)
+        return
+      }
+      if response.Allowed == tc.isErrorExpected {
+        t.Errorf("TenantNetwork was admitted:%t, but we expected:%t", response.Allowed, !tc.isErrorExpected)
+        return
+      }
+      allocs := bitarray.NewBitArrayFromBase64(tconf.Supernets[0].Alloc)
+      var carvedSubnets []int
+      for subnetIndex := uint32(0); subnetIndex < 4; subnetIndex++ {
+        if allocs.Get(subnetIndex) {
+          carvedSubnets = append(carvedSubnets, int(subnetIndex))
+        }
+      }
+      if !reflect.DeepEqual(carvedSubnets, tc.expectedCarvedSubnets) {
+        t.Errorf("Subnets:%v are carved out of the supernet, but we expected:%v", carvedSubnets, tc.expectedCarvedSubnets)
+      }
+    })
+  }
+}
+
+func TestCarvedSubnetCannotBeSetOnCreate(t *testing.T) {
+  tnet := danmtypes.DanmNet {
+    ObjectMeta: meta_v1.ObjectMeta {Name: "carved", Namespace: "default", Annotations: map[string]string{confman.CarvedSubnetAnnotation: "10.80.0.128/26"}},
+    TypeMeta: meta_v1.TypeMeta {Kind: TnetType},
+    Spec: danmtypes.DanmNetSpec{NetworkID: "flannel", NetworkType: "flannel", Options: danmtypes.DanmNetOption{Cidr: "10.80.0.128/26"}},
+  }
+  tnetBinary, _ := json.Marshal(tnet)
+  request, err := utils.CreateHttpRequest(nil, tnetBinary, false, false, v1beta1.Create)
+  if err != nil {
+    t.Errorf("Could not create test HTTP Request object, because:%v", err)
+    return
+  }
+  validator := admit.Validator{Client: stubs.NewClientSetStub(utils.TestArtifacts{TestNets: supernetNets})}
+  writerStub := httpstub.NewWriterStub()
+  validator.ValidateNetwork(writerStub, request)
+  err = utils.ValidateHttpResponse(writerStub, true, nil)
+  if err != nil {
+    t.Errorf("Received HTTP Response did not match expectation, because:%v", err)
+  }
+}
+
+func createSupernet(cidr, namespace, alloc string) danmtypes.TenantSupernet {
+  return danmtypes.TenantSupernet{Cidr: cidr, PrefixLength: 26, Namespace: namespace, Alloc: alloc}
+}
@@ -0,0 +1,172 @@
+package admit_tests
+
+import (
+  "strings"
+  "testing"
+  "net/http"
+  "net/http/httptest"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/admit"
+  stubs "github.com/nokia/danm/test/stubs/danm"
+  httpstub "github.com/nokia/danm/test/stubs/http"
+  "github.com/nokia/danm/test/utils"
+  admissionv1 "k8s.io/api/admission/v1"
+  metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var reviewVersionTcs = []struct {
+  tcName string
+  netName string
+  apiVersion string
+  expectedApiVersion string
+  isErrorExpected bool
+  expectedPatches []admit.Patch
+}{
+  {"V1RequestWithPatches", "no-netype", "admission.k8s.io/v1", "admission.k8s.io/v1", false, neTypeAndAlloc},
+  {"V1beta1RequestWithPatches", "no-netype", "admission.k8s.io/v1beta1", "admission.k8s.io/v1beta1", false, neTypeAndAlloc},
+  {"V1RequestDenied", "no-cidr", "admission.k8s.io/v1", "admission.k8s.io/v1", true, nil},
+  {"V1beta1RequestDenied", "no-cidr", "admission.k8s.io/v1beta1", "admission.k8s.io/v1beta1", true, nil},
+  {"RequestWithoutVersion", "no-netype", "", "admission.k8s.io/v1", false, neTypeAndAlloc},
+  {"UnsupportedVersion", "no-netype", "admission.k8s.io/v2", "admission.k8s.io/v1", true, nil},
+}
+
+func TestAdmissionReviewVersions(t *testing.T) {
+  validator := admit.Validator{}
+  for _, tc := range reviewVersionTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      writerStub := httpstub.NewWriterStub()
+      newNet, _, _ := getNetForValidate(tc.netName, valNets, DnetType)
+      request, err := utils.CreateVersionedHttpRequest(nil, newNet, false, false, admissionv1.Create, tc.apiVersion)
+      if err != nil {
+        t.Errorf("Could not create test HTTP Request object, because:%v", err)
+        return
+      }
+      validator.Client = stubs.NewClientSetStub(utils.TestArtifacts{TestNets: valNets})
+      validator.ValidateNetwork(writerStub, request)
+      err = utils.ValidateHttpResponse(writerStub, tc.isErrorExpected, tc.expectedPatches)
+      if err != nil {
+        t.Errorf("Received HTTP Response did not match expectation, because:%v", err)
+        return
+      }
+      review, err := writerStub.GetAdmissionReview()
+      if err != nil {
+        t.Errorf("Received AdmissionReview could not be decoded, because:%v", err)
+        return
+      }
+      if review.APIVersion != tc.expectedApiVersion || review.Kind != "AdmissionReview" {
+        t.Errorf("AdmissionReview was answered with apiVersion:" + review.APIVersion + " and kind:" + review.Kind + ", but we expected apiVersion:" + tc.expectedApiVersion)
+      }
+      if !tc.isErrorExpected && review.Response.UID != utils.TestReviewUid {
+        t.Errorf("AdmissionResponse UID:" + string(review.Response.UID) + " does not match the UID of the request:" + utils.TestReviewUid)
+      }
+    })
+  }
+}
+
+var denialStatusTcs = []struct {
+  tcName string
+  netName string
+  expectedReason metav1.StatusReason
+  expectedCode int32
+  expectedField string
+  expectedCauseType metav1.CauseType
+}{
+  {"RoutesWithoutCidr", "no-cidr", metav1.StatusReasonInvalid, http.StatusUnprocessableEntity, "spec.Options.routes", admit.CauseTypeFieldValueForbidden},
+  {"TooBigCidr", "long-cidr", metav1.StatusReasonInvalid, http.StatusUnprocessableEntity, "spec.Options.cidr", metav1.CauseTypeFieldValueInvalid},
+  {"VlanAndVxlan", "invalid-vids", metav1.StatusReasonInvalid, http.StatusUnprocessableEntity, "spec.Options.vxlan", admit.CauseTypeFieldValueForbidden},
+  {"MissingNid", "missing-nid", metav1.StatusReasonInvalid, http.StatusUnprocessableEntity, "spec.NetworkID", metav1.CauseTypeFieldValueRequired},
+  {"UnknownFields", "malformed", metav1.StatusReasonBadRequest, http.StatusBadRequest, "", ""},
+}
+
+func TestDenialStatus(t *testing.T) {
+  validator := admit.Validator{Client: stubs.NewClientSetStub(utils.TestArtifacts{TestNets: valNets})}
+  for _, tc := range denialStatusTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      writerStub := httpstub.NewWriterStub()
+      newNet, _, shouldMalform := getNetForValidate(tc.netName, valNets, DnetType)
+      request, err := utils.CreateVersionedHttpRequest(nil, newNet, false, shouldMalform, admissionv1.Create, "admission.k8s.io/v1")
+      if err != nil {
+        t.Errorf("Could not create test HTTP Request object, because:%v", err)
+        return
+      }
+      validator.ValidateNetwork(writerStub, request)
+      response, err := writerStub.GetAdmissionResponse()
+      if err != nil || response.Allowed || response.Result == nil {
+        t.Errorf("Expected a denial with a result Status, but received:%v, error:%v", response, err)
+        return
+      }
+      status := response.Result
+      if status.Status != metav1.StatusFailure || status.Reason != tc.expectedReason || status.Code != tc.expectedCode || status.Message == "" {
+        t.Errorf("Received Status:%v does not have the expected reason:%s, and code:%d", status, tc.expectedReason, tc.expectedCode)
+        return
+      }
+      if tc.expectedField == "" {
+        if status.Details != nil && len(status.Details.Causes) > 0 {
+          t.Errorf("Received Status:%v should not point to any fields", status)
+        }
+        return
+      }
+      if status.Details == nil || len(status.Details.Causes) != 1 ||
+         status.Details.Causes[0].Field != tc.expectedField || status.Details.Causes[0].Type != tc.expectedCauseType {
+        t.Errorf("Received Status:%v does not point to field:%s with cause type:%s", status, tc.expectedField, tc.expectedCauseType)
+      }
+    })
+  }
+}
+
+func TestServeMetrics(t *testing.T) {
+  validator := admit.Validator{Client: stubs.NewClientSetStub(utils.TestArtifacts{TestNets: valNets})}
+  for _, netName := range []string{"no-netype", "invalid-vids"} {
+    newNet, _, _ := getNetForValidate(netName, valNets, DnetType)
+    request, _ := utils.CreateVersionedHttpRequest(nil, newNet, false, false, admissionv1.Create, "admission.k8s.io/v1")
+    validator.ValidateNetwork(httpstub.NewWriterStub(), request)
+  }
+  recorder := httptest.NewRecorder()
+  admit.ServeMetrics(recorder, httptest.NewRequest("GET", "/metrics", nil))
+  metrics := recorder.Body.String()
+  expectedSamples := []string {
+    "# TYPE danm_webhook_admission_reviews_total counter",
+    `danm_webhook_admission_reviews_total{kind="",operation="CREATE",outcome="allowed"}`,
+    `danm_webhook_admission_reviews_total{kind="",operation="CREATE",outcome="denied"}`,
+    "# TYPE danm_webhook_validations_total counter",
+    `danm_webhook_validations_total{validator="validateVids",outcome="passed"}`,
+    `danm_webhook_validations_total{validator="validateVids",outcome="failed"}`,
+  }
+  for _, sample := range expectedSamples {
+    if !strings.Contains(metrics, sample) {
+      t.Errorf("Exposed metrics:\n%s\ndo not contain:%s", metrics, sample)
+    }
+  }
+}
+
+var readinessTcs = []struct {
+  tcName string
+  client bool
+  tconfs []danmtypes.TenantConfig
+  expectedCode int
+}{
+  {"ApiIsReachable", true, []danmtypes.TenantConfig{danmtypes.TenantConfig{ObjectMeta: metav1.ObjectMeta{Name: "tconf"}}}, http.StatusOK},
+  {"ApiIsNotReachable", true, []danmtypes.TenantConfig{danmtypes.TenantConfig{ObjectMeta: metav1.ObjectMeta{Name: "error"}}}, http.StatusServiceUnavailable},
+  {"NoClient", false, nil, http.StatusServiceUnavailable},
+}
+
+func TestHealthEndpoints(t *testing.T) {
+  recorder := httptest.NewRecorder()
+  admit.Healthz(recorder, httptest.NewRequest("GET", "/healthz", nil))
+  if recorder.Code != http.StatusOK {
+    t.Errorf("Liveness endpoint returned:%d, but we expected:%d", recorder.Code, http.StatusOK)
+  }
+  for _, tc := range readinessTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      validator := admit.Validator{}
+      if tc.client {
+        validator.Client = stubs.NewClientSetStub(utils.TestArtifacts{TestTconfs: tc.tconfs})
+      }
+      recorder := httptest.NewRecorder()
+      validator.Readyz(recorder, httptest.NewRequest("GET", "/readyz", nil))
+      if recorder.Code != tc.expectedCode {
+        t.Errorf("Readiness endpoint returned:%d, but we expected:%d", recorder.Code, tc.expectedCode)
+      }
+    })
+  }
+}
@@ -0,0 +1,102 @@
+package certwatcher_test
+
+import (
+  "io/ioutil"
+  "math/big"
+  "os"
+  "path/filepath"
+  "testing"
+  "time"
+  "crypto/ecdsa"
+  "crypto/elliptic"
+  "crypto/rand"
+  "crypto/x509"
+  "crypto/x509/pkix"
+  "encoding/pem"
+  "github.com/nokia/danm/pkg/certwatcher"
+)
+
+func TestCertWatcher(t *testing.T) {
+  certDir, err := ioutil.TempDir("", "certwatcher")
+  if err != nil {
+    t.Fatalf("Temporary directory could not be created, because:%v", err)
+  }
+  defer os.RemoveAll(certDir)
+  certFile, keyFile := filepath.Join(certDir, "cert.pem"), filepath.Join(certDir, "key.pem")
+  _, err = certwatcher.NewCertWatcher(certFile, keyFile)
+  if err == nil {
+    t.Errorf("CertWatcher was created without existing key pair files")
+  }
+  writeKeyPair(t, certFile, keyFile, "first", time.Now().Add(-time.Minute))
+  watcher, err := certwatcher.NewCertWatcher(certFile, keyFile)
+  if err != nil {
+    t.Fatalf("CertWatcher could not be created, because:%v", err)
+  }
+  assertServedCert(t, watcher, "first")
+  isReloaded, err := watcher.ReloadIfChanged()
+  if isReloaded || err != nil {
+    t.Errorf("Unchanged key pair was reloaded:%t, or reload failed with:%v", isReloaded, err)
+  }
+  writeKeyPair(t, certFile, keyFile, "second", time.Now())
+  isReloaded, err = watcher.ReloadIfChanged()
+  if !isReloaded || err != nil {
+    t.Errorf("Changed key pair was not reloaded, error:%v", err)
+  }
+  assertServedCert(t, watcher, "second")
+  err = ioutil.WriteFile(certFile, []byte("invalid"), 0600)
+  if err != nil {
+    t.Fatalf("Certificate file could not be overwritten, because:%v", err)
+  }
+  os.Chtimes(certFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+  isReloaded, err = watcher.ReloadIfChanged()
+  if isReloaded || err == nil {
+    t.Errorf("Invalid key pair was reloaded")
+  }
+  assertServedCert(t, watcher, "second")
+}
+
+func writeKeyPair(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+  privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+  if err != nil {
+    t.Fatalf("Private key could not be generated, because:%v", err)
+  }
+  template := x509.Certificate {
+    SerialNumber: big.NewInt(1),
+    Subject: pkix.Name{CommonName: commonName},
+    NotBefore: time.Now().Add(-time.Hour),
+    NotAfter: time.Now().Add(time.Hour),
+  }
+  certDer, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+  if err != nil {
+    t.Fatalf("Certificate could not be generated, because:%v", err)
+  }
+  keyDer, err := x509.MarshalECPrivateKey(privateKey)
+  if err != nil {
+    t.Fatalf("Private key could not be marshalled, because:%v", err)
+  }
+  err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer}), 0600)
+  if err != nil {
+    t.Fatalf("Certificate file could not be written, because:%v", err)
+  }
+  err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+  if err != nil {
+    t.Fatalf("Private key file could not be written, because:%v", err)
+  }
+  //Explicit modification times make the test independent of the resolution of the file system's timestamps
+  os.Chtimes(certFile, modTime, modTime)
+  os.Chtimes(keyFile, modTime, modTime)
+}
+
+func assertServedCert(t *testing.T, watcher *certwatcher.CertWatcher, expCommonName string) {
+  cert, err := watcher.GetCertificate(nil)
+  if err != nil || cert == nil {
+    t.Fatalf("No certificate is served, error:%v", err)
+  }
+  parsedCert, err := x509.ParseCertificate(cert.Certificate[0])
+  if err != nil {
+    t.Fatalf("Served certificate cannot be parsed, because:%v", err)
+  }
+  if parsedCert.Subject.CommonName != expCommonName {
+    t.Errorf("Served certificate belongs to:%s, but we expected:%s", parsedCert.Subject.CommonName, expCommonName)
+  }
+}
@@ -0,0 +1,138 @@
+package confman_test
+
+import (
+  "reflect"
+  "testing"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/bitarray"
+  "github.com/nokia/danm/pkg/confman"
+  stubs "github.com/nokia/danm/test/stubs/danm"
+  "github.com/nokia/danm/test/utils"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+  auditNets = []danmtypes.TenantNetwork {
+    createAuditNet("reserved", "ens4", "", 0, 700),
+    createAuditNet("unreserved", "ens4", "", 0, 702),
+    createAuditNet("duplicate-1", "ens4", "", 0, 703),
+    createAuditNet("duplicate-2", "ens4", "", 0, 703),
+    createAuditNet("vlan", "ens4", "", 500, 0),
+    createAuditNet("pool", "", "nokia.k8s.io/sriov_ens1f0", 1500, 0),
+    createAuditNet("other-profile", "ens5", "", 0, 701),
+  }
+)
+
+var auditTcs = []struct {
+  tcName string
+  repair bool
+  previouslyLeakedVnis []int
+  expectedStatus danmtypes.IfaceProfileStatus
+  expectedReservedVnis []int
+  expectedFreeVnis []int
+}{
+  {"ReportOnly", false, []int{701}, danmtypes.IfaceProfileStatus{Name: "ens4", VniType: "vxlan", UsedVnis: 3, LeakedVnis: []int{701}, UnreservedVnis: []int{702}, DuplicateVnis: []int{703}}, []int{700, 701, 703}, []int{702}},
+  {"RepairWithoutPreviousAudit", true, nil, danmtypes.IfaceProfileStatus{Name: "ens4", VniType: "vxlan", UsedVnis: 3, LeakedVnis: []int{701}, DuplicateVnis: []int{703}, RepairedVnis: []int{702}}, []int{700, 701, 702, 703}, nil},
+  {"RepairLeakedInPreviousAudit", true, []int{701}, danmtypes.IfaceProfileStatus{Name: "ens4", VniType: "vxlan", UsedVnis: 3, DuplicateVnis: []int{703}, RepairedVnis: []int{701, 702}}, []int{700, 702, 703}, []int{701}},
+}
+
+func TestAuditTenantConfig(t *testing.T) {
+  for _, tc := range auditTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      tconf := createAuditConf(tc.previouslyLeakedVnis)
+      status := confman.AuditTenantConfig(&tconf, auditNets, tc.repair)
+      if len(status.Profiles) != 3 {
+        t.Errorf("Status of %d interface profiles were reported, but we expected 3", len(status.Profiles))
+        return
+      }
+      if !reflect.DeepEqual(status.Profiles[0], tc.expectedStatus) {
+        t.Errorf("Audit status:%+v does not match with expected status:%+v", status.Profiles[0], tc.expectedStatus)
+      }
+      for _, profileStatus := range status.Profiles[1:] {
+        if len(profileStatus.LeakedVnis) != 0 || len(profileStatus.UnreservedVnis) != 0 || len(profileStatus.DuplicateVnis) != 0 || profileStatus.UsedVnis != 1 {
+          t.Errorf("Consistent interface profile:%s was reported with status:%+v", profileStatus.Name, profileStatus)
+        }
+      }
+      allocs := bitarray.NewBitArrayFromBase64(tconf.HostDevices[0].Alloc)
+      for _, vni := range tc.expectedReservedVnis {
+        if !allocs.Get(uint32(vni)) {
+          t.Errorf("VNI:%d is not reserved after the audit", vni)
+        }
+      }
+      for _, vni := range tc.expectedFreeVnis {
+        if allocs.Get(uint32(vni)) {
+          t.Errorf("VNI:%d is reserved after the audit", vni)
+        }
+      }
+    })
+  }
+}
+
+func TestAudit(t *testing.T) {
+  tconf := createAuditConf([]int{701})
+  testClient := stubs.NewClientSetStub(utils.TestArtifacts{TestTconfs: []danmtypes.TenantConfig{tconf}})
+  status, err := confman.Audit(testClient, &tconf, auditNets, true)
+  if err != nil {
+    t.Errorf("Audit result could not be persisted, because:%v", err)
+    return
+  }
+  updatedTconf := testClient.DanmClient.TconfClient.LastUpdatedTconf
+  if updatedTconf == nil || updatedTconf.Status == nil || !reflect.DeepEqual(*updatedTconf.Status, *status) {
+    t.Errorf("Audit status was not persisted in the TenantConfig")
+    return
+  }
+  if bitarray.NewBitArrayFromBase64(updatedTconf.HostDevices[0].Alloc).Get(701) {
+    t.Errorf("Repaired allocation was not persisted in the TenantConfig")
+  }
+}
+
+func TestAuditWithUnchangedResult(t *testing.T) {
+  tconf := createAuditConf([]int{701})
+  tconf.Status.Profiles = []danmtypes.IfaceProfileStatus{
+    danmtypes.IfaceProfileStatus{Name: "ens4", VniType: "vxlan", UsedVnis: 3, LeakedVnis: []int{701}, DuplicateVnis: []int{703}, UnreservedVnis: []int{702}},
+    danmtypes.IfaceProfileStatus{Name: "ens4", VniType: "vlan", UsedVnis: 1},
+    danmtypes.IfaceProfileStatus{Name: "nokia.k8s.io/sriov_ens1f0", VniType: "vlan", UsedVnis: 1},
+  }
+  testClient := stubs.NewClientSetStub(utils.TestArtifacts{TestTconfs: []danmtypes.TenantConfig{tconf}})
+  _, err := confman.Audit(testClient, &tconf, auditNets, false)
+  if err != nil {
+    t.Errorf("TenantConfig could not be audited, because:%v", err)
+    return
+  }
+  if testClient.DanmClient.TconfClient != nil && testClient.DanmClient.TconfClient.TimesUpdateWasCalled != 0 {
+    t.Errorf("TenantConfig was updated:%d times, even though the result of the audit did not change", testClient.DanmClient.TconfClient.TimesUpdateWasCalled)
+  }
+}
+
+func createAuditConf(previouslyLeakedVnis []int) danmtypes.TenantConfig {
+  vxlanAllocs := bitarray.NewBitArrayFromBase64(utils.AllocFor5k)
+  for _, vni := range []int{700, 701, 703} {
+    vxlanAllocs.Set(uint32(vni))
+  }
+  vlanAllocs := bitarray.NewBitArrayFromBase64(utils.AllocFor5k)
+  vlanAllocs.Set(500)
+  poolAllocs := bitarray.NewBitArrayFromBase64(utils.AllocFor5k)
+  poolAllocs.Set(1500)
+  tconf := danmtypes.TenantConfig {
+    ObjectMeta: meta_v1.ObjectMeta {Name: "audit"},
+    HostDevices: []danmtypes.IfaceProfile {
+      danmtypes.IfaceProfile{Name: "ens4", VniType: "vxlan", VniRange: "700-710", Alloc: vxlanAllocs.Encode()},
+      danmtypes.IfaceProfile{Name: "ens4", VniType: "vlan", VniRange: "500-510", Alloc: vlanAllocs.Encode()},
+      danmtypes.IfaceProfile{Name: "nokia.k8s.io/sriov_ens1f0", VniType: "vlan", VniRange: "1500-1550", Alloc: poolAllocs.Encode()},
+      danmtypes.IfaceProfile{Name: "ens6"},
+    },
+  }
+  if previouslyLeakedVnis != nil {
+    tconf.Status = &danmtypes.TenantConfigStatus {
+      Profiles: []danmtypes.IfaceProfileStatus{danmtypes.IfaceProfileStatus{Name: "ens4", VniType: "vxlan", LeakedVnis: previouslyLeakedVnis}},
+    }
+  }
+  return tconf
+}
+
+func createAuditNet(name, device, devicePool string, vlan, vxlan int) danmtypes.TenantNetwork {
+  return danmtypes.TenantNetwork {
+    ObjectMeta: meta_v1.ObjectMeta {Name: name, Namespace: "default"},
+    Spec: danmtypes.DanmNetSpec{NetworkID: name, NetworkType: "ipvlan", Options: danmtypes.DanmNetOption{Device: device, DevicePool: devicePool, Vlan: vlan, Vxlan: vxlan}},
+  }
+}
@@ -34,6 +34,15 @@ var (
     danmtypes.TenantConfig{ObjectMeta: meta_v1.ObjectMeta {Name: "firstConf"}},
     danmtypes.TenantConfig{ObjectMeta: meta_v1.ObjectMeta {Name: "secondConf"}},
   }
+  selectionTconfs = []danmtypes.TenantConfig {
+    danmtypes.TenantConfig{ObjectMeta: meta_v1.ObjectMeta {Name: "default-b"}},
+    danmtypes.TenantConfig{ObjectMeta: meta_v1.ObjectMeta {Name: "listed"}, Namespaces: []string{"blue-ns", "red-ns"}},
+    danmtypes.TenantConfig{ObjectMeta: meta_v1.ObjectMeta {Name: "labelled"}, NamespaceSelector: &meta_v1.LabelSelector{MatchLabels: map[string]string{"tenant": "blue"}}},
+    danmtypes.TenantConfig{ObjectMeta: meta_v1.ObjectMeta {Name: "default-a"}},
+  }
+  noDefaultTconfs = []danmtypes.TenantConfig {
+    danmtypes.TenantConfig{ObjectMeta: meta_v1.ObjectMeta {Name: "listed"}, Namespaces: []string{"green-ns"}},
+  }
   reserveConfs = []danmtypes.TenantConfig {
     danmtypes.TenantConfig {
       ObjectMeta: meta_v1.ObjectMeta {Name: "tconf"},
@@ -82,6 +91,8 @@ var (
     TconfSet{name: "emptyTcs", tconfs: emptyTconfs},
     TconfSet{name: "errorTconfs", tconfs: errorTconfs},
     TconfSet{name: "multipleConfigs", tconfs: multipleTconfs},
+    TconfSet{name: "selection", tconfs: selectionTconfs},
+    TconfSet{name: "noDefault", tconfs: noDefaultTconfs},
   }
   testConfigs = TconfSets {sets: tconfSets}
   testNets = []danmtypes.DanmNet {
@@ -126,12 +137,40 @@ var (
 
 var getTconfTcs = []struct {
   tcName string
-  tconfSets TconfSets
+  tconfSetName string
+  namespace string
+  namespaceLabels map[string]string
   isErrorExpected bool
+  expectedTconf string
+}{
+  {"emptyTcs", "emptyTcs", "default", nil, true, ""},
+  {"errorTconfs", "errorTconfs", "default", nil, true, ""},
+  {"multipleConfigs", "multipleConfigs", "default", nil, false, "firstConf"},
+  {"defaultSelectionIsDeterministic", "selection", "other-ns", map[string]string{"tenant": "red"}, false, "default-a"},
+  {"selectionByLabels", "selection", "blue-team", map[string]string{"tenant": "blue"}, false, "labelled"},
+  {"listTakesPrecedenceOverLabels", "selection", "blue-ns", map[string]string{"tenant": "blue"}, false, "listed"},
+  {"selectionByList", "selection", "red-ns", nil, false, "listed"},
+  {"noApplicableConfig", "noDefault", "other-ns", nil, true, ""},
+}
+
+var overlapTcs = []struct {
+  tcName string
+  tconf1 danmtypes.TenantConfig
+  tconf2 danmtypes.TenantConfig
+  expectedMatch int
 }{
-  {"emptyTcs", testConfigs, true},
-  {"errorTconfs", testConfigs, true},
-  {"multipleConfigs", testConfigs, false},
+  {"bothDefault", danmtypes.TenantConfig{}, danmtypes.TenantConfig{}, confman.DefaultMatch},
+  {"defaultAndList", danmtypes.TenantConfig{}, danmtypes.TenantConfig{Namespaces: []string{"a"}}, confman.NoMatch},
+  {"sameNamespaceListed", danmtypes.TenantConfig{Namespaces: []string{"a", "b"}}, danmtypes.TenantConfig{Namespaces: []string{"c", "b"}}, confman.NamespaceListMatch},
+  {"differentNamespacesListed", danmtypes.TenantConfig{Namespaces: []string{"a"}}, danmtypes.TenantConfig{Namespaces: []string{"b"}}, confman.NoMatch},
+  {"sameLabelDifferentValue", createSelectorConf(map[string]string{"tenant": "a"}), createSelectorConf(map[string]string{"tenant": "b"}), confman.NoMatch},
+  {"differentLabels", createSelectorConf(map[string]string{"tenant": "a"}), createSelectorConf(map[string]string{"zone": "b"}), confman.LabelMatch},
+  {"emptySelector", createSelectorConf(nil), createSelectorConf(map[string]string{"zone": "b"}), confman.LabelMatch},
+  {"valueNotIn", createSelectorConf(map[string]string{"tenant": "a"}), createSelectorConf(nil, meta_v1.LabelSelectorRequirement{Key: "tenant", Operator: meta_v1.LabelSelectorOpNotIn, Values: []string{"a"}}), confman.NoMatch},
+  {"valuesIn", createSelectorConf(map[string]string{"tenant": "a"}), createSelectorConf(nil, meta_v1.LabelSelectorRequirement{Key: "tenant", Operator: meta_v1.LabelSelectorOpIn, Values: []string{"a", "b"}}), confman.LabelMatch},
+  {"disjointIn", createSelectorConf(nil, meta_v1.LabelSelectorRequirement{Key: "tenant", Operator: meta_v1.LabelSelectorOpIn, Values: []string{"c"}}), createSelectorConf(nil, meta_v1.LabelSelectorRequirement{Key: "tenant", Operator: meta_v1.LabelSelectorOpIn, Values: []string{"a", "b"}}), confman.NoMatch},
+  {"existsAndDoesNotExist", createSelectorConf(nil, meta_v1.LabelSelectorRequirement{Key: "tenant", Operator: meta_v1.LabelSelectorOpExists}), createSelectorConf(nil, meta_v1.LabelSelectorRequirement{Key: "tenant", Operator: meta_v1.LabelSelectorOpDoesNotExist}), confman.NoMatch},
+  {"notInAndDoesNotExist", createSelectorConf(nil, meta_v1.LabelSelectorRequirement{Key: "tenant", Operator: meta_v1.LabelSelectorOpNotIn, Values: []string{"a"}}), createSelectorConf(nil, meta_v1.LabelSelectorRequirement{Key: "tenant", Operator: meta_v1.LabelSelectorOpDoesNotExist}), confman.LabelMatch},
 }
 
 var reserveTcs = []struct {
@@ -140,19 +179,22 @@ var reserveTcs = []struct {
   ifaceName string
   vniType string
   reserveVnis []int
+  excludedVnis []int
   isErrorExpected bool
   expectedVni int
   timesUpdateShouldBeCalled int
 }{
-  {"invalidVni", "tconf", "invalidVni", "", nil, true, 0, 0},
-  {"reserveFirstFreeInEmptyIface", "tconf", "ens4", "vlan", nil, false, 200, 1},
-  {"reserveLastFreeInIface", "tconf", "ens4", "vlan", []int{200,509}, false, 510, 1},
-  {"noFreeVniInIface", "tconf", "ens4", "vlan", []int{200,510}, true, 0, 0},
-  {"errorUpdating", "error", "ens4", "vxlan", nil, true, 0, 1},
-  {"nonExistentProfile", "tconf", "hupak", "vlan", nil, true, 0, 0},
-  {"corruptedVniAllocation", "corrupt", "corrupt", "vxlan", nil, true, 0, 0},
-  {"conflictDuringFirstUpdate", "conflict", "conflict", "vxlan", []int{700,708}, false, 710, 2},
-  {"failsToRefreshAfterConflict", "conflicterror", "conflict", "vxlan", []int{700,708}, true, 0, 1},
+  {"invalidVni", "tconf", "invalidVni", "", nil, nil, true, 0, 0},
+  {"reserveFirstFreeInEmptyIface", "tconf", "ens4", "vlan", nil, nil, false, 200, 1},
+  {"reserveLastFreeInIface", "tconf", "ens4", "vlan", []int{200,509}, nil, false, 510, 1},
+  {"noFreeVniInIface", "tconf", "ens4", "vlan", []int{200,510}, nil, true, 0, 0},
+  {"errorUpdating", "error", "ens4", "vxlan", nil, nil, true, 0, 1},
+  {"nonExistentProfile", "tconf", "hupak", "vlan", nil, nil, true, 0, 0},
+  {"corruptedVniAllocation", "corrupt", "corrupt", "vxlan", nil, nil, true, 0, 0},
+  {"conflictDuringFirstUpdate", "conflict", "conflict", "vxlan", []int{700,708}, nil, false, 710, 2},
+  {"skipExcludedVnis", "tconf", "ens4", "vxlan", nil, []int{700,701}, false, 702, 1},
+  {"onlyExcludedVnisLeft", "tconf", "ens4", "vxlan", []int{700,708}, []int{709,710}, true, 0, 0},
+  {"failsToRefreshAfterConflict", "conflicterror", "conflict", "vxlan", []int{700,708}, nil, true, 0, 1},
 }
 
 var freeTcs = []struct {
@@ -180,16 +222,31 @@ var freeTcs = []struct {
 func TestGetTenantConfig(t *testing.T) {
   for _, tc := range getTconfTcs {
     t.Run(tc.tcName, func(t *testing.T) {
-      tconfSet := getTconfSet(tc.tcName, tc.tconfSets.sets)
+      tconfSet := getTconfSet(tc.tconfSetName, testConfigs.sets)
       testArtifacts := utils.TestArtifacts{TestTconfs: tconfSet}
       tconfClientStub := stubs.NewClientSetStub(testArtifacts)
-      tconf, err := confman.GetTenantConfig(tconfClientStub)
+      tconf, err := confman.GetTenantConfig(tconfClientStub, tc.namespace, tc.namespaceLabels)
       if (err != nil && !tc.isErrorExpected) || (err == nil && tc.isErrorExpected) {
         t.Errorf("Received error:%v does not match with expectation", err)
         return
       }
-      if tconf != nil && tconf.ObjectMeta.Name != tconfSet[0].ObjectMeta.Name {
-        t.Errorf("The name of the returned TenantConfig:%s does not match with the expected:%s", tconf.ObjectMeta.Name, tconfSet[0].ObjectMeta.Name)
+      if tconf != nil && tconf.ObjectMeta.Name != tc.expectedTconf {
+        t.Errorf("The name of the returned TenantConfig:%s does not match with the expected:%s", tconf.ObjectMeta.Name, tc.expectedTconf)
+      }
+    })
+  }
+}
+
+func TestGetOverlappingSelection(t *testing.T) {
+  for _, tc := range overlapTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      match, _ := confman.GetOverlappingSelection(&tc.tconf1, &tc.tconf2)
+      if match != tc.expectedMatch {
+        t.Errorf("Received overlap precedence:%d does not match with the expected:%d", match, tc.expectedMatch)
+      }
+      reverseMatch, _ := confman.GetOverlappingSelection(&tc.tconf2, &tc.tconf1)
+      if reverseMatch != match {
+        t.Errorf("Overlap precedence:%d depends on the order of the TenantConfigs, reverse order resulted:%d", match, reverseMatch)
       }
     })
   }
@@ -211,7 +268,7 @@ func TestReserve(t *testing.T) {
       }
       testArtifacts := utils.TestArtifacts{TestTconfs: reserveConfs, ExhaustAllocs: exhaustAllocs}
       tconfClientStub := stubs.NewClientSetStub(testArtifacts)
-      vni, err := confman.Reserve(tconfClientStub, tconf, iface)
+      vni, err := confman.Reserve(tconfClientStub, tconf, iface, tc.excludedVnis...)
       if (err != nil && !tc.isErrorExpected) || (err == nil && tc.isErrorExpected) {
         t.Errorf("Received error:%v does not match with expectation", err)
         return
@@ -311,4 +368,8 @@ func getIfaceFromTconf(ifaceName string, vniType string, tconf *danmtypes.Tenant
 func isVniSet(iface danmtypes.IfaceProfile, vni int) bool {
   allocs := bitarray.NewBitArrayFromBase64(iface.Alloc)
   return allocs.Get(uint32(vni))
-}
\ No newline at end of file
+}
+
+func createSelectorConf(matchLabels map[string]string, requirements ...meta_v1.LabelSelectorRequirement) danmtypes.TenantConfig {
+  return danmtypes.TenantConfig{NamespaceSelector: &meta_v1.LabelSelector{MatchLabels: matchLabels, MatchExpressions: requirements}}
+}
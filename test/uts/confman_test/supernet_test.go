@@ -0,0 +1,125 @@
+package confman_test
+
+import (
+  "net"
+  "testing"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/bitarray"
+  "github.com/nokia/danm/pkg/confman"
+  stubs "github.com/nokia/danm/test/stubs/danm"
+  "github.com/nokia/danm/test/utils"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var supernetSelectionTcs = []struct {
+  tcName string
+  namespace string
+  networkType string
+  expectedSupernet string
+}{
+  {"GenericSupernet", "default", "macvlan", "10.0.0.0/16"},
+  {"NetworkTypeSupernet", "default", "ipvlan", "10.1.0.0/16"},
+  {"NamespaceSupernet", "blue", "macvlan", "10.2.0.0/16"},
+  {"NamespaceSupernetPreferredOverNetworkType", "blue", "ipvlan", "10.2.0.0/16"},
+}
+
+var reserveSubnetTcs = []struct {
+  tcName string
+  carvedSubnets []int
+  excludedCidrs []string
+  isErrorExpected bool
+  expectedCidr string
+}{
+  {"FirstSubnet", nil, nil, false, "10.10.0.0/26"},
+  {"NextFreeSubnet", []int{0, 1}, nil, false, "10.10.0.128/26"},
+  {"OverlappingSubnetIsSkipped", []int{0}, []string{"10.10.0.64/27"}, false, "10.10.0.128/26"},
+  {"ExhaustedSupernet", []int{0, 1, 2, 3}, nil, true, ""},
+}
+
+func TestGetSupernetIndex(t *testing.T) {
+  tconf := danmtypes.TenantConfig {
+    Supernets: []danmtypes.TenantSupernet {
+      danmtypes.TenantSupernet{Cidr: "10.0.0.0/16", PrefixLength: 24},
+      danmtypes.TenantSupernet{Cidr: "10.1.0.0/16", PrefixLength: 24, NetworkType: "ipvlan"},
+      danmtypes.TenantSupernet{Cidr: "10.2.0.0/16", PrefixLength: 24, Namespace: "blue"},
+    },
+  }
+  for _, tc := range supernetSelectionTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      index := confman.GetSupernetIndex(&tconf, tc.namespace, tc.networkType)
+      if index == -1 || tconf.Supernets[index].Cidr != tc.expectedSupernet {
+        t.Errorf("Supernet with index:%d was selected, but we expected:%s", index, tc.expectedSupernet)
+      }
+    })
+  }
+  if confman.GetSupernetIndex(&danmtypes.TenantConfig{}, "default", "ipvlan") != -1 {
+    t.Errorf("Supernet was selected from a TenantConfig without supernets")
+  }
+}
+
+func TestReserveSubnet(t *testing.T) {
+  for _, tc := range reserveSubnetTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      tconf := createSupernetConf(tc.carvedSubnets...)
+      var excludedSubnets []*net.IPNet
+      for _, cidr := range tc.excludedCidrs {
+        _, subnet, _ := net.ParseCIDR(cidr)
+        excludedSubnets = append(excludedSubnets, subnet)
+      }
+      testClient := stubs.NewClientSetStub(utils.TestArtifacts{TestTconfs: []danmtypes.TenantConfig{tconf}})
+      cidr, err := confman.ReserveSubnet(testClient, &tconf, "10.10.0.0/24", excludedSubnets...)
+      if (err != nil) != tc.isErrorExpected {
+        t.Errorf("Received error:%v does not match with expectation:%t", err, tc.isErrorExpected)
+        return
+      }
+      if cidr != tc.expectedCidr {
+        t.Errorf("Subnet:%s was carved, but we expected:%s", cidr, tc.expectedCidr)
+        return
+      }
+      if tc.isErrorExpected {
+        return
+      }
+      _, subnet, _ := net.ParseCIDR(cidr)
+      subnetIndex := (subnet.IP.To4()[3]) / 64
+      if !bitarray.NewBitArrayFromBase64(tconf.Supernets[0].Alloc).Get(uint32(subnetIndex)) {
+        t.Errorf("Subnet:%s was not reserved in the supernet", cidr)
+      }
+    })
+  }
+}
+
+func TestFreeSubnet(t *testing.T) {
+  tconf := createSupernetConf(0, 2)
+  testClient := stubs.NewClientSetStub(utils.TestArtifacts{TestTconfs: []danmtypes.TenantConfig{tconf}})
+  dnet := danmtypes.DanmNet{ObjectMeta: meta_v1.ObjectMeta {Name: "explicit"}, Spec: danmtypes.DanmNetSpec{Options: danmtypes.DanmNetOption{Cidr: "10.10.0.128/26"}}}
+  err := confman.FreeSubnet(testClient, &tconf, &dnet)
+  if err != nil || confman.GetNumberOfCarvedSubnets(tconf.Supernets[0]) != 2 {
+    t.Errorf("Subnet of a network without carved subnet annotation should have been kept, but error:%v was received", err)
+  }
+  dnet = danmtypes.DanmNet{ObjectMeta: meta_v1.ObjectMeta {Name: "carved", Annotations: map[string]string{confman.CarvedSubnetAnnotation: "10.10.0.128/26"}}}
+  err = confman.FreeSubnet(testClient, &tconf, &dnet)
+  if err != nil {
+    t.Errorf("Subnet could not be freed, because:%v", err)
+    return
+  }
+  if confman.GetNumberOfCarvedSubnets(tconf.Supernets[0]) != 1 || bitarray.NewBitArrayFromBase64(tconf.Supernets[0].Alloc).Get(2) {
+    t.Errorf("Subnet:%s recorded in the carved subnet annotation was not freed in the supernet", dnet.ObjectMeta.Annotations[confman.CarvedSubnetAnnotation])
+  }
+  dnet.ObjectMeta.Annotations[confman.CarvedSubnetAnnotation] = "10.20.0.0/26"
+  err = confman.FreeSubnet(testClient, &tconf, &dnet)
+  if err != nil || confman.GetNumberOfCarvedSubnets(tconf.Supernets[0]) != 1 {
+    t.Errorf("Subnet outside of any supernet should have been ignored, but error:%v was received", err)
+  }
+}
+
+func createSupernetConf(carvedSubnets ...int) danmtypes.TenantConfig {
+  allocs, _ := bitarray.NewBitArray(4)
+  allocs.Reset(0)
+  for _, subnetIndex := range carvedSubnets {
+    allocs.Set(uint32(subnetIndex))
+  }
+  return danmtypes.TenantConfig {
+    ObjectMeta: meta_v1.ObjectMeta {Name: "supernet-conf"},
+    Supernets: []danmtypes.TenantSupernet{danmtypes.TenantSupernet{Cidr: "10.10.0.0/24", PrefixLength: 26, Alloc: allocs.Encode()}},
+  }
+}
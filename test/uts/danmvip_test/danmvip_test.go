@@ -0,0 +1,177 @@
+package danmvip_test
+
+import (
+  "testing"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/danmep"
+  "github.com/nokia/danm/pkg/danmvip"
+  "github.com/nokia/danm/pkg/ipam"
+  stubs "github.com/nokia/danm/test/stubs/danm"
+  "github.com/nokia/danm/test/utils"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+  testNode = "node-1"
+)
+
+var (
+  deletionTime = meta_v1.Now()
+  testNets = []danmtypes.DanmNet {
+    danmtypes.DanmNet {ObjectMeta: meta_v1.ObjectMeta {Name: "vipnet", Namespace: "default"},Spec: danmtypes.DanmNetSpec{NetworkID: "vipnet", Options: danmtypes.DanmNetOption{Cidr: "192.168.1.64/26"}}},
+  }
+  testEps = []danmtypes.DanmEp {
+    danmtypes.DanmEp {ObjectMeta: meta_v1.ObjectMeta {Name: "remote-ep", Namespace: "default"},Spec: danmtypes.DanmEpSpec{NetworkName: "vipnet", Host: "node-2"}},
+    danmtypes.DanmEp {ObjectMeta: meta_v1.ObjectMeta {Name: "foreign-ep", Namespace: "default"},Spec: danmtypes.DanmEpSpec{NetworkName: "othernet", Host: testNode}},
+  }
+)
+
+var allocateTcs = []struct {
+  tcName string
+  vip danmtypes.DanmVip
+  expectedIp4 string
+  isFinalizerExpected bool
+  isUpdateExpected bool
+  isErrorExpected bool
+  timesNetUpdateShouldBeCalled int
+}{
+  {"noAddressRequested", newVip("novip", "vipnet", "", "", "", "", false, false), "", false, false, true, 0},
+  {"unknownNetwork", newVip("unknown", "nonexistent", "dynamic", "", "", "", false, false), "", false, false, true, 0},
+  {"dynamicIpv4", newVip("dynamic", "vipnet", "dynamic", "", "", "", false, false), "192.168.1.65/26", true, true, false, 1},
+  {"alreadyReserved", newVip("reserved", "vipnet", "dynamic", "192.168.1.70/26", "", "", true, false), "", false, false, false, 0},
+  {"recordingFailureFreesAddress", newVip("error", "vipnet", "dynamic", "", "", "", false, false), "", false, false, true, 2},
+  {"deletedStillHeld", newVip("held", "vipnet", "dynamic", "192.168.1.70/26", "remote-ep", "remote-ep", true, true), "", false, false, false, 0},
+  {"deletedHolderGone", newVip("gone", "vipnet", "dynamic", "192.168.1.70/26", "gone-ep", "gone-ep", true, true), "192.168.1.70/26", false, true, false, 1},
+  {"deletedReleased", newVip("released", "vipnet", "dynamic", "192.168.1.70/26", "", "", true, true), "192.168.1.70/26", false, true, false, 1},
+  {"deletedWithoutFinalizer", newVip("unreserved", "vipnet", "dynamic", "", "", "", false, true), "", false, false, false, 0},
+  {"deletedAlreadyFreed", markFreed(newVip("freed", "vipnet", "dynamic", "192.168.1.70/26", "", "", true, true)), "192.168.1.70/26", false, true, false, 0},
+  {"deletedFreeingFailureKeepsFinalizer", newVip("netgone", "nonexistent", "dynamic", "192.168.1.70/26", "", "", true, true), "", false, false, true, 0},
+  {"deletedRecordingFailureKeepsFinalizer", newVip("error-deleted", "vipnet", "dynamic", "192.168.1.70/26", "", "", true, true), "", false, false, true, 1},
+}
+
+var plumbTcs = []struct {
+  tcName string
+  vip danmtypes.DanmVip
+  isUpdateExpected bool
+  isErrorExpected bool
+}{
+  {"notReservedYet", newVip("notreserved", "vipnet", "dynamic", "", "remote-ep", "", false, false), false, false},
+  {"holderOnOtherNode", newVip("remote", "vipnet", "dynamic", "192.168.1.70/26", "remote-ep", "", true, false), false, false},
+  {"holderMissing", newVip("missing", "vipnet", "dynamic", "192.168.1.70/26", "gone-ep", "", true, false), false, false},
+  {"holderOnOtherNetwork", newVip("foreign", "vipnet", "dynamic", "192.168.1.70/26", "foreign-ep", "", true, false), false, true},
+  {"alreadyAssigned", newVip("assigned", "vipnet", "dynamic", "192.168.1.70/26", "remote-ep", "remote-ep", true, false), false, false},
+  {"oldHolderOnOtherNode", newVip("moving", "vipnet", "dynamic", "192.168.1.70/26", "foreign-ep", "remote-ep", true, false), false, false},
+  {"oldHolderGone", newVip("orphaned", "vipnet", "dynamic", "192.168.1.70/26", "remote-ep", "gone-ep", true, false), true, false},
+  {"deletedOldHolderOnOtherNode", newVip("deleted", "vipnet", "dynamic", "192.168.1.70/26", "remote-ep", "remote-ep", true, true), false, false},
+}
+
+func TestAllocateDanmVip(t *testing.T) {
+  err := utils.SetupAllocationPools(testNets)
+  if err != nil {
+    t.Errorf("Allocation pool for testnets could not be set-up because:%v", err)
+  }
+  for _, tc := range allocateTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      //Addresses of the already reserved DanmVips are really reserved in a fresh network, so freeing them can be verified
+      nets := []danmtypes.DanmNet{*testNets[0].DeepCopy()}
+      if tc.vip.Status.Address != "" {
+        _, _, err = ipam.Reserve(stubs.NewClientSetStub(utils.TestArtifacts{TestNets: nets}), nets[0], tc.vip.Status.Address, "")
+        if err != nil {
+          t.Errorf("Address of the reserved DanmVip could not be reserved because:%v", err)
+          return
+        }
+      }
+      testClient := stubs.NewClientSetStub(utils.TestArtifacts{TestNets: nets, TestEps: testEps})
+      err := danmvip.AllocateDanmVip(testClient, &tc.vip)
+      if (err != nil) != tc.isErrorExpected {
+        t.Errorf("Received error:%v does not match with expectation:%t", err, tc.isErrorExpected)
+        return
+      }
+      var timesNetUpdateWasCalled int
+      if testClient.DanmClient.NetClient != nil {
+        timesNetUpdateWasCalled = testClient.DanmClient.NetClient.TimesUpdateWasCalled
+      }
+      if timesNetUpdateWasCalled != tc.timesNetUpdateShouldBeCalled {
+        t.Errorf("Network should have been updated:%d times, but it happened:%d times instead", tc.timesNetUpdateShouldBeCalled, timesNetUpdateWasCalled)
+      }
+      updatedVips := getUpdatedVips(testClient)
+      if (len(updatedVips) != 0) != tc.isUpdateExpected {
+        t.Errorf("DanmVip update:%v does not match with expectation:%t", updatedVips, tc.isUpdateExpected)
+        return
+      }
+      if !tc.isUpdateExpected {
+        return
+      }
+      if updatedVips[0].Status.Address != tc.expectedIp4 {
+        t.Errorf("Reserved IPv4 address:%s does not match with expected:%s", updatedVips[0].Status.Address, tc.expectedIp4)
+      }
+      lastVip := updatedVips[len(updatedVips)-1]
+      if isFinalizerSet(lastVip) != tc.isFinalizerExpected {
+        t.Errorf("Finalizers:%v do not match with expectation:%t", lastVip.ObjectMeta.Finalizers, tc.isFinalizerExpected)
+      }
+      if tc.vip.ObjectMeta.DeletionTimestamp != nil && lastVip.ObjectMeta.Annotations[danmep.IpsFreedAnnotation] != "true" {
+        t.Errorf("Finalizer of DanmVip:%s was removed without recording its freed addresses", lastVip.ObjectMeta.Name)
+      }
+    })
+  }
+}
+
+func TestPlumbDanmVip(t *testing.T) {
+  for _, tc := range plumbTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      testClient := stubs.NewClientSetStub(utils.TestArtifacts{TestNets: testNets, TestEps: testEps})
+      err := danmvip.PlumbDanmVip(testClient, &tc.vip, testNode)
+      if (err != nil) != tc.isErrorExpected {
+        t.Errorf("Received error:%v does not match with expectation:%t", err, tc.isErrorExpected)
+        return
+      }
+      updatedVips := getUpdatedVips(testClient)
+      if (len(updatedVips) != 0) != tc.isUpdateExpected {
+        t.Errorf("DanmVip update:%v does not match with expectation:%t", updatedVips, tc.isUpdateExpected)
+        return
+      }
+      if tc.isUpdateExpected && (updatedVips[0].Status.Endpoint != "" || updatedVips[0].Status.Host != "") {
+        t.Errorf("DanmVip:%s should have been released, but its holder is still:%s", updatedVips[0].ObjectMeta.Name, updatedVips[0].Status.Endpoint)
+      }
+    })
+  }
+}
+
+func newVip(name, netName, req4, reserved4, endpoint, holder string, isReserved, isDeleted bool) danmtypes.DanmVip {
+  vip := danmtypes.DanmVip {
+    ObjectMeta: meta_v1.ObjectMeta {Name: name, Namespace: "default"},
+    Spec: danmtypes.DanmVipSpec {NetworkName: netName, Address: req4, Endpoint: endpoint},
+    Status: danmtypes.DanmVipStatus {Address: reserved4, Endpoint: holder},
+  }
+  if holder != "" {
+    vip.Status.Host = "node-2"
+  }
+  if isReserved {
+    vip.ObjectMeta.Finalizers = []string{danmvip.VipFinalizer}
+  }
+  if isDeleted {
+    vip.ObjectMeta.DeletionTimestamp = &deletionTime
+  }
+  return vip
+}
+
+func markFreed(vip danmtypes.DanmVip) danmtypes.DanmVip {
+  vip.ObjectMeta.Annotations = map[string]string{danmep.IpsFreedAnnotation: "true"}
+  return vip
+}
+
+func getUpdatedVips(testClient *stubs.ClientSetStub) []danmtypes.DanmVip {
+  if testClient.DanmClient.VipClient == nil {
+    return nil
+  }
+  return testClient.DanmClient.VipClient.UpdatedVips
+}
+
+func isFinalizerSet(vip danmtypes.DanmVip) bool {
+  for _, finalizer := range vip.ObjectMeta.Finalizers {
+    if finalizer == danmvip.VipFinalizer {
+      return true
+    }
+  }
+  return false
+}
@@ -0,0 +1,34 @@
+package datastructs_test
+
+import (
+  "testing"
+  "github.com/nokia/danm/pkg/datastructs"
+)
+
+func TestGetDefaultRouteOwners(t *testing.T) {
+  noClaims := []datastructs.Interface{{Network: "a", Ip: "dynamic"}, {Network: "b", Ip: "dynamic"}}
+  v4, v6, err := datastructs.GetDefaultRouteOwners(noClaims)
+  if v4 != datastructs.NoDefaultRouteOwner || v6 != datastructs.NoDefaultRouteOwner || err != nil {
+    t.Errorf("Nobody should own the default routes when no connection claims them.")
+  }
+  splitClaims := []datastructs.Interface{{Network: "a", Ip: "dynamic", DefaultRoute: true}, {Network: "b", Ip6: "dynamic", DefaultRoute: true}}
+  v4, v6, err = datastructs.GetDefaultRouteOwners(splitClaims)
+  if v4 != 0 || v6 != 1 || err != nil {
+    t.Errorf("Connections claiming different IP families should own their respective default routes.")
+  }
+  implicitClaim := []datastructs.Interface{{Network: "a", DefaultRoute: true}}
+  v4, v6, err = datastructs.GetDefaultRouteOwners(implicitClaim)
+  if v4 != 0 || v6 != 0 || err != nil {
+    t.Errorf("Connection without explicit IP requests should own the default routes of both IP families.")
+  }
+  doubleClaims := []datastructs.Interface{{Network: "a", Ip: "dynamic", DefaultRoute: true}, {Network: "b", Ip: "dynamic", Ip6: "dynamic", DefaultRoute: true}}
+  _, _, err = datastructs.GetDefaultRouteOwners(doubleClaims)
+  if err == nil {
+    t.Errorf("Two connections claiming the IPv4 default route should expect error.")
+  }
+  claimWithoutIp := []datastructs.Interface{{Network: "a", Ip: "none", Ip6: "none", DefaultRoute: true}}
+  _, _, err = datastructs.GetDefaultRouteOwners(claimWithoutIp)
+  if err == nil {
+    t.Errorf("Connection claiming the default route without IPs should expect error.")
+  }
+}
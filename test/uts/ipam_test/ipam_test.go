@@ -1,10 +1,12 @@
 package ipam_test
 
 import (
+  "net"
   "os"
   "strconv"
   "testing"
   danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/bitarray"
   "github.com/nokia/danm/pkg/ipam"
   stubs "github.com/nokia/danm/test/stubs/danm"
   "github.com/nokia/danm/test/utils"
@@ -27,6 +29,8 @@ var testNets = []danmtypes.DanmNet {
   danmtypes.DanmNet {ObjectMeta: meta_v1.ObjectMeta {Name: "fullinitv6"},Spec: danmtypes.DanmNetSpec{NetworkID: "net6", Options: danmtypes.DanmNetOption{Cidr: "192.168.1.64/26", Net6: "2a00:8a00:a000:1193::/64"}}},
   danmtypes.DanmNet {ObjectMeta: meta_v1.ObjectMeta {Name: "v4RestrictedPool"},Spec: danmtypes.DanmNetSpec{NetworkID: "v4RestrictedPool", Options: danmtypes.DanmNetOption{Cidr: "192.168.1.64/26", Pool: danmtypes.IpPool{Start: "192.168.1.70", End: "192.168.1.80"}}}},
   danmtypes.DanmNet {ObjectMeta: meta_v1.ObjectMeta {Name: "v4RestrictedPoolWithLastIp"},Spec: danmtypes.DanmNetSpec{NetworkID: "v4RestrictedPoolWithLastIp", Options: danmtypes.DanmNetOption{Cidr: "192.168.1.64/26", Pool: danmtypes.IpPool{Start: "192.168.1.70", End: "192.168.1.80", LastIp: "192.168.1.80"}}}},
+  danmtypes.DanmNet {ObjectMeta: meta_v1.ObjectMeta {Name: "slaac"},Spec: danmtypes.DanmNetSpec{NetworkID: "slaac", Options: danmtypes.DanmNetOption{Cidr: "192.168.1.64/26", Net6: "2a00:8a00:a000:1193::/64", Ipv6Mode: "slaac"}}},
+  danmtypes.DanmNet {ObjectMeta: meta_v1.ObjectMeta {Name: "dhcpv6"},Spec: danmtypes.DanmNetSpec{NetworkID: "dhcpv6", Options: danmtypes.DanmNetOption{Net6: "2a00:8a00:a000:1193::/64", Ipv6Mode: "dhcpv6-passthrough"}}},
 }
 
 var reserveTcs = []struct {
@@ -70,6 +74,9 @@ var reserveTcs = []struct {
   {"errorUpdate", 10, "dynamic", "", "", "", true, 1},
   {"dyanmicV4FromAllocationPool", 13, "dynamic", "", "192.168.1.70/26", "", false, 1},
   {"dyanmicV4FromAllocationPoolWithLastIpSet", 14, "dynamic", "", "192.168.1.70/26", "", false, 1},
+  {"dynamicIPv6SlaacSkipped", 15, "", "dynamic", "", "", false, 0},
+  {"dynamicDualStackSlaacOnlyIPv4", 15, "dynamic", "dynamic", "192.168.1.65/26", "", false, 1},
+  {"staticIPv6Dhcpv6PassthroughSkipped", 16, "", "2a00:8a00:a000:1193::3e:1010/64", "", "", false, 0},
 }
 
 var freeTcs = []struct {
@@ -90,6 +97,7 @@ var freeTcs = []struct {
   {"unresolvedConflictAfterUpdate", 8, "192.168.1.69/26", true, 1},
   {"errorUpdate", 9, "192.168.1.69/26", true, 1},
   {"ipv6SuccesfulFree", 12, "2a00:8a00:a000:1193::1/106", false, 1},
+  {"ipv6SlaacNotFreed", 15, "2a00:8a00:a000:1193:f816:3eff:fe24:e348/64", false, 0},
 }
 
 var gcTcs = []struct {
@@ -103,6 +111,24 @@ var gcTcs = []struct {
   {"dualStackGc", 12, "192.168.1.115", "2a00:8a00:a000:1193::5"},
 }
 
+var remapTcs = []struct {
+  tcName string
+  oldCidr string
+  newCidr string
+  allocatedIps []string
+  oldRoutes map[string]string
+  newRoutes map[string]string
+  isErrorExpected bool
+}{
+  {"growCidr", "192.168.1.64/26", "192.168.1.0/24", []string{"192.168.1.65", "192.168.1.100"}, nil, nil, false},
+  {"shrinkCidr", "192.168.1.0/24", "192.168.1.64/26", []string{"192.168.1.65", "192.168.1.100"}, nil, nil, false},
+  {"shrinkCidrWithIpOutside", "192.168.1.0/24", "192.168.1.64/26", []string{"192.168.1.65", "192.168.1.200"}, nil, nil, true},
+  {"shiftedCidr", "192.168.1.64/26", "192.168.2.0/24", []string{"192.168.1.65"}, nil, nil, true},
+  {"oldGatewayOutside", "192.168.1.0/24", "192.168.1.64/26", []string{"192.168.1.65", "192.168.1.1"}, map[string]string{"10.0.0.0/24": "192.168.1.1"}, map[string]string{"10.0.0.0/24": "192.168.1.66"}, false},
+  {"growNet6", "2a00:8a00:a000:1193::/120", "2a00:8a00:a000:1193::/112", []string{"2a00:8a00:a000:1193::5", "2a00:8a00:a000:1193::fe"}, nil, nil, false},
+  {"shrinkNet6WithIpOutside", "2a00:8a00:a000:1193::/112", "2a00:8a00:a000:1193::/120", []string{"2a00:8a00:a000:1193::5", "2a00:8a00:a000:1193::1fe"}, nil, nil, true},
+}
+
 func TestReserve(t *testing.T) {
   err := utils.SetupAllocationPools(testNets)
   if err != nil {
@@ -181,6 +207,49 @@ func TestGarbageCollectIps(t *testing.T) {
   }
 }
 
+func TestRemapAllocationArray(t *testing.T) {
+  for _, tc := range remapTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      _, oldSubnet, _ := net.ParseCIDR(tc.oldCidr)
+      oldArray := bitarray.NewBitArrayFromBase64(ipam.CreateAllocationArray(oldSubnet, tc.oldRoutes))
+      for _, ip := range tc.allocatedIps {
+        oldArray.Set(ipam.GetIndexOfIp(net.ParseIP(ip), oldSubnet))
+      }
+      newAlloc, err := ipam.RemapAllocationArray(oldArray.Encode(), tc.oldCidr, tc.newCidr, tc.oldRoutes, tc.newRoutes)
+      if (err != nil && !tc.isErrorExpected) || (err == nil && tc.isErrorExpected) {
+        t.Errorf("Received error:%v does not match with expectation", err)
+        return
+      }
+      if tc.isErrorExpected {
+        return
+      }
+      _, newSubnet, _ := net.ParseCIDR(tc.newCidr)
+      newArray := bitarray.NewBitArrayFromBase64(newAlloc)
+      if newArray.Len() != bitarray.NewBitArrayFromBase64(ipam.CreateAllocationArray(newSubnet, nil)).Len() {
+        t.Errorf("Re-mapped allocation array is not sized to the new CIDR:" + tc.newCidr)
+      }
+      for _, ip := range tc.allocatedIps {
+        if tc.oldRoutes != nil && ip == tc.oldRoutes["10.0.0.0/24"] {
+          continue
+        }
+        if !newArray.Get(ipam.GetIndexOfIp(net.ParseIP(ip), newSubnet)) {
+          t.Errorf("Allocated IP:" + ip + " is not reserved in the re-mapped allocation array")
+        }
+      }
+      for _, gw := range tc.newRoutes {
+        if !newArray.Get(ipam.GetIndexOfIp(net.ParseIP(gw), newSubnet)) {
+          t.Errorf("Gateway IP:" + gw + " is not reserved in the re-mapped allocation array")
+        }
+      }
+      allocatedIps := ipam.GetAllocatedIps(newAlloc, tc.newCidr, tc.newRoutes)
+      expectedNumOfIps := len(tc.allocatedIps) - len(tc.oldRoutes)
+      if len(allocatedIps) != expectedNumOfIps {
+        t.Errorf("Re-mapped allocation array holds:%v allocated IPs, but we expected:%d", allocatedIps, expectedNumOfIps)
+      }
+    })
+  }
+}
+
 func TestMain(m *testing.M) {
   code := m.Run()
   os.Exit(code)
@@ -0,0 +1,88 @@
+package netcontrol_test
+
+import (
+  "strings"
+  "testing"
+  "time"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/netcontrol"
+  stubs "github.com/nokia/danm/test/stubs/danm"
+  "github.com/nokia/danm/test/utils"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+  failedNetworks = []danmtypes.NetworkProvisioningState {
+    {ApiType: "DanmNet", Namespace: "default", Name: "failed", Ready: false, Reason: netcontrol.ProvisioningFailedReason, Message: "host interface:vx_failed could not be created:no IP on ens4"},
+  }
+  testNodeStates = []danmtypes.NodeNetworkState {
+    danmtypes.NodeNetworkState {
+      ObjectMeta: meta_v1.ObjectMeta{Name: "node-1"},
+      ReconcileInterval: meta_v1.Duration{Duration: time.Minute},
+      LastReconcileTime: meta_v1.Now(),
+      Networks: []danmtypes.NetworkProvisioningState {
+        {ApiType: "DanmNet", Namespace: "default", Name: "ready", Ready: true},
+        {ApiType: "DanmNet", Namespace: "default", Name: "failed", Ready: false, Reason: netcontrol.ProvisioningFailedReason, Message: "host interface:vx_failed could not be created:no IP on ens4"},
+        {ApiType: "TenantNetwork", Namespace: "default", Name: "conflicting", Ready: false, Reason: netcontrol.InterfaceConflictReason, Message: "host interface:ens4.500 is already used by network:TenantNetwork:default/other"},
+        {ApiType: "ClusterNetwork", Name: "failed-cluster", Ready: false, Reason: netcontrol.ProvisioningFailedReason, Message: "host interface:ens5.600 could not be created:ens5 not found"},
+      },
+    },
+    danmtypes.NodeNetworkState {
+      ObjectMeta: meta_v1.ObjectMeta{Name: "stale-node"},
+      ReconcileInterval: meta_v1.Duration{Duration: time.Minute},
+      LastReconcileTime: meta_v1.NewTime(time.Now().Add(-3 * time.Minute)),
+      Networks: failedNetworks,
+    },
+    danmtypes.NodeNetworkState {
+      ObjectMeta: meta_v1.ObjectMeta{Name: "unreconciled-node"},
+      Networks: failedNetworks,
+    },
+  }
+)
+
+var checkProvisioningTcs = []struct {
+  tcName string
+  nodeName string
+  netKind string
+  netNamespace string
+  netName string
+  vlan int
+  vxlan int
+  isErrorExpected bool
+}{
+  {"ReadyNetwork", "node-1", "", "default", "ready", 0, 700, false},
+  {"FailedNetwork", "node-1", "", "default", "failed", 0, 701, true},
+  {"FailedNetworkExplicitKind", "node-1", "DanmNet", "default", "failed", 0, 701, true},
+  {"FailedNetworkOtherNamespace", "node-1", "", "kube-system", "failed", 0, 701, false},
+  {"ConflictingTenantNetwork", "node-1", "TenantNetwork", "default", "conflicting", 500, 0, true},
+  {"DanmNetWithTenantNetworkName", "node-1", "DanmNet", "default", "conflicting", 500, 0, false},
+  {"FailedClusterNetwork", "node-1", "ClusterNetwork", "", "failed-cluster", 600, 0, true},
+  {"NetworkWithoutHostInterface", "node-1", "", "default", "failed", 0, 0, false},
+  {"UnreportedNetwork", "node-1", "", "default", "new", 0, 702, false},
+  {"NodeWithoutState", "node-2", "", "default", "failed", 0, 701, false},
+  {"StaleNodeState", "stale-node", "", "default", "failed", 0, 701, false},
+  {"NodeStateWithoutReconciliation", "unreconciled-node", "", "default", "failed", 0, 701, false},
+}
+
+func TestCheckNetworkProvisioning(t *testing.T) {
+  testClient := stubs.NewClientSetStub(utils.TestArtifacts{TestNodeStates: testNodeStates})
+  for _, tc := range checkProvisioningTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      dnet := danmtypes.DanmNet{
+        TypeMeta: meta_v1.TypeMeta{Kind: tc.netKind},
+        ObjectMeta: meta_v1.ObjectMeta{Namespace: tc.netNamespace, Name: tc.netName},
+      }
+      dnet.Spec.Options.Device = "ens4"
+      dnet.Spec.Options.Vlan = tc.vlan
+      dnet.Spec.Options.Vxlan = tc.vxlan
+      err := netcontrol.CheckNetworkProvisioning(testClient, tc.nodeName, &dnet)
+      if (err != nil) != tc.isErrorExpected {
+        t.Errorf("Received error:%v does not match with expectation:%t", err, tc.isErrorExpected)
+        return
+      }
+      if err != nil && !strings.Contains(err.Error(), "NodeNetworkState:" + tc.nodeName) {
+        t.Errorf("Error:%v does not point to the NodeNetworkState of the node", err)
+      }
+    })
+  }
+}
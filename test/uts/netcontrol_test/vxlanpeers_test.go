@@ -0,0 +1,62 @@
+package netcontrol_test
+
+import (
+  "reflect"
+  "testing"
+  danmtypes "github.com/nokia/danm/crd/apis/danm/v1"
+  "github.com/nokia/danm/pkg/netcontrol"
+  meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+  peerNodeStates = []*danmtypes.NodeNetworkState {
+    createPeerNodeState("node-1", danmtypes.NetworkProvisioningState{ApiType: "ClusterNetwork", Name: "unicast", Ready: true, Vtep: "10.0.0.1"}),
+    createPeerNodeState("node-2",
+      danmtypes.NetworkProvisioningState{ApiType: "ClusterNetwork", Name: "unicast", Ready: true, Vtep: "10.0.0.2"},
+      danmtypes.NetworkProvisioningState{ApiType: "TenantNetwork", Namespace: "default", Name: "unicast", Ready: true, Vtep: "10.0.0.2"},
+    ),
+    createPeerNodeState("node-3", danmtypes.NetworkProvisioningState{ApiType: "ClusterNetwork", Name: "unicast", Ready: false, Vtep: "10.0.0.3"}),
+    createPeerNodeState("node-4", danmtypes.NetworkProvisioningState{ApiType: "ClusterNetwork", Name: "unicast", Ready: true}),
+    createPeerNodeState("node-5", danmtypes.NetworkProvisioningState{ApiType: "ClusterNetwork", Name: "unicast", Ready: true, Vtep: "fd00::5"}),
+    createPeerNodeState("node-6", danmtypes.NetworkProvisioningState{ApiType: "ClusterNetwork", Name: "multicast", Ready: true, Vtep: "10.0.0.6"}),
+  }
+)
+
+var (
+  existingNodes = map[string]bool{"node-1": true, "node-2": true, "node-3": true, "node-4": true, "node-6": true, "node-7": true}
+)
+
+var vxlanPeerTcs = []struct {
+  tcName string
+  nodeName string
+  netKind string
+  netNamespace string
+  existingNodes map[string]bool
+  expectedPeers []string
+}{
+  {"ClusterNetworkPeers", "node-1", "ClusterNetwork", "", nil, []string{"10.0.0.2", "fd00::5"}},
+  {"ClusterNetworkPeersOfNewNode", "node-7", "ClusterNetwork", "", nil, []string{"10.0.0.1", "10.0.0.2", "fd00::5"}},
+  {"TenantNetworkPeers", "node-1", "TenantNetwork", "default", nil, []string{"10.0.0.2"}},
+  {"TenantNetworkOfOtherNamespace", "node-1", "TenantNetwork", "kube-system", nil, []string{}},
+  {"PeerOfDepartedNode", "node-1", "ClusterNetwork", "", existingNodes, []string{"10.0.0.2"}},
+  {"NoNodeLeft", "node-1", "ClusterNetwork", "", map[string]bool{}, []string{}},
+}
+
+func TestGetVxlanPeers(t *testing.T) {
+  for _, tc := range vxlanPeerTcs {
+    t.Run(tc.tcName, func(t *testing.T) {
+      dnet := danmtypes.DanmNet{
+        TypeMeta: meta_v1.TypeMeta{Kind: tc.netKind},
+        ObjectMeta: meta_v1.ObjectMeta{Namespace: tc.netNamespace, Name: "unicast"},
+      }
+      peers := netcontrol.GetVxlanPeers(peerNodeStates, tc.existingNodes, tc.nodeName, &dnet)
+      if !reflect.DeepEqual(peers, tc.expectedPeers) {
+        t.Errorf("Received peers:%v do not match with expected peers:%v", peers, tc.expectedPeers)
+      }
+    })
+  }
+}
+
+func createPeerNodeState(nodeName string, netStates ...danmtypes.NetworkProvisioningState) *danmtypes.NodeNetworkState {
+  return &danmtypes.NodeNetworkState{ObjectMeta: meta_v1.ObjectMeta{Name: nodeName}, Networks: netStates}
+}